@@ -0,0 +1,373 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+const (
+	defaultBackfillWorkers       = 8
+	defaultBackfillMaxRetry      = 5
+	defaultBackfillRetryInterval = time.Second
+)
+
+// BackfillBlock is a historical block fetched by Backfill
+type BackfillBlock struct {
+	Number      uint64
+	Hash        string
+	SpecVersion uint32
+	Timestamp   uint64
+	// Extrinsics are keyed by their index in the block
+	Extrinsics map[int]*Transaction
+	Events     []*ChainEvent
+}
+
+type BackfillConfig struct {
+	// Workers is the number of blocks fetched concurrently
+	Workers int
+	// RateLimit is the maximum number of rpc requests per second over all workers, 0 means unlimited. Fetching a block
+	// takes three requests.
+	RateLimit int
+	// MaxRetry is the number of retries of a single block before Backfill gives up
+	MaxRetry      int
+	RetryInterval time.Duration
+}
+
+// specRange is a block range sharing one runtime spec version
+type specRange struct {
+	from, to    uint64
+	specVersion uint32
+}
+
+type backfillResult struct {
+	blk *BackfillBlock
+	err error
+	num uint64
+}
+
+// Backfill fetches the blocks from..to (inclusive) with a pool of workers and calls handler for each of them strictly
+// in block order. Metadata is loaded once per runtime spec version before the blocks of that version are fetched.
+// Backfill returns when all blocks were handled, when a block still fails after MaxRetry retries or when handler
+// returns an error
+func (sc *GsrpcClient) Backfill(from, to uint64, cfg BackfillConfig, handler func(blk *BackfillBlock) error) error {
+	return sc.BackfillContext(context.Background(), from, to, cfg, handler)
+}
+
+func (sc *GsrpcClient) BackfillContext(ctx context.Context, from, to uint64, cfg BackfillConfig, handler func(blk *BackfillBlock) error) error {
+	if from > to {
+		return fmt.Errorf("backfill from %d is larger than to %d", from, to)
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultBackfillWorkers
+	}
+	if cfg.MaxRetry <= 0 {
+		cfg.MaxRetry = defaultBackfillMaxRetry
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaultBackfillRetryInterval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	limiter := newRateLimiter(cfg.RateLimit)
+	defer limiter.close()
+
+	ranges, err := sc.specRanges(ctx, from, to, limiter)
+	if err != nil {
+		return backfillError(ctx, err)
+	}
+
+	for _, r := range ranges {
+		sc.log.Info("Backfill spec version range", "specVersion", r.specVersion, "from", r.from, "to", r.to)
+		// load the decoder of this spec version once, before the workers need it
+		md, err := sc.loadMetaDecoder(ctx, r, limiter)
+		if err != nil {
+			return backfillError(ctx, err)
+		}
+
+		if err := sc.backfillRange(ctx, r, md, cfg, limiter, handler); err != nil {
+			return backfillError(ctx, err)
+		}
+	}
+	return nil
+}
+
+// backfillError returns the error of ctx in place of err once ctx is done
+func backfillError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+func (sc *GsrpcClient) backfillRange(ctx context.Context, r specRange, md metaDecoder, cfg BackfillConfig, limiter *rateLimiter, handler func(blk *BackfillBlock) error) error {
+	jobs := make(chan uint64)
+	results := make(chan backfillResult, cfg.Workers)
+	// window bounds how far the workers may run ahead of the next block to deliver
+	window := make(chan struct{}, cfg.Workers*4)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		defer close(jobs)
+		for n := r.from; n <= r.to; n++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- n:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				blk, err := sc.fetchBackfillBlockWithRetry(ctx, n, r.specVersion, md, cfg, limiter)
+				select {
+				case results <- backfillResult{blk: blk, err: err, num: n}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[uint64]*BackfillBlock)
+	next := r.from
+	for res := range results {
+		if res.err != nil {
+			return fmt.Errorf("backfill block %d failed: %s", res.num, res.err)
+		}
+		pending[res.num] = res.blk
+
+		for {
+			blk, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if err := handler(blk); err != nil {
+				return err
+			}
+			<-window
+			if next == r.to {
+				return nil
+			}
+			next++
+		}
+	}
+	return fmt.Errorf("backfill stopped at block %d", next)
+}
+
+func (sc *GsrpcClient) fetchBackfillBlockWithRetry(ctx context.Context, number uint64, specVersion uint32, md metaDecoder, cfg BackfillConfig, limiter *rateLimiter) (*BackfillBlock, error) {
+	var err error
+	for i := 0; i <= cfg.MaxRetry; i++ {
+		var blk *BackfillBlock
+		blk, err = sc.fetchBackfillBlock(ctx, number, md, limiter)
+		if err == nil {
+			blk.SpecVersion = specVersion
+			return blk, nil
+		}
+		if err == ErrorTerminated {
+			return nil, err
+		}
+		sc.log.Warn("Backfill fetch block failed, will retry", "block", number, "retry", i, "err", err)
+
+		select {
+		case <-time.After(cfg.RetryInterval):
+		case <-ctx.Done():
+			return nil, ErrorTerminated
+		}
+	}
+	return nil, err
+}
+
+// fetchBackfillBlock takes one token per request, the blocks are decoded with md of their spec version
+func (sc *GsrpcClient) fetchBackfillBlock(ctx context.Context, number uint64, md metaDecoder, limiter *rateLimiter) (*BackfillBlock, error) {
+	if !limiter.wait(ctx.Done()) {
+		return nil, ErrorTerminated
+	}
+	blockHash, err := sc.GetBlockHashContext(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+
+	if !limiter.wait(ctx.Done()) {
+		return nil, ErrorTerminated
+	}
+	blk, err := sc.GetBlockContext(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	stamp, exts, err := decodeBlockTimestampAndExtrinsics(md, blockHash, blk)
+	if err != nil {
+		return nil, err
+	}
+
+	if !limiter.wait(ctx.Done()) {
+		return nil, ErrorTerminated
+	}
+	eventRaw, err := sc.getEventsRaw(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	events, err := md.DecodeEvents(eventRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BackfillBlock{
+		Number:     number,
+		Hash:       blockHash,
+		Timestamp:  stamp,
+		Extrinsics: exts,
+		Events:     events,
+	}, nil
+}
+
+// specRanges splits from..to into ranges of the same spec version. Spec versions only increase, so a range whose
+// ends share a version has no upgrade inside and the upgrade blocks are found by bisection
+func (sc *GsrpcClient) specRanges(ctx context.Context, from, to uint64, limiter *rateLimiter) ([]specRange, error) {
+	specAt := func(number uint64) (uint32, error) {
+		if !limiter.wait(ctx.Done()) {
+			return 0, ErrorTerminated
+		}
+		blockHash, err := sc.GetBlockHashContext(ctx, number)
+		if err != nil {
+			return 0, err
+		}
+		if !limiter.wait(ctx.Done()) {
+			return 0, ErrorTerminated
+		}
+		hash, err := types.NewHashFromHexString(blockHash)
+		if err != nil {
+			return 0, err
+		}
+		api, err := sc.FlashApi()
+		if err != nil {
+			return 0, err
+		}
+		rv, err := api.State.GetRuntimeVersionContext(ctx, hash)
+		if err != nil {
+			return 0, err
+		}
+		return uint32(rv.SpecVersion), nil
+	}
+
+	fromSpec, err := specAt(from)
+	if err != nil {
+		return nil, err
+	}
+	toSpec, err := specAt(to)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := make([]specRange, 0)
+	var split func(lo, hi uint64, loSpec, hiSpec uint32) error
+	split = func(lo, hi uint64, loSpec, hiSpec uint32) error {
+		if loSpec == hiSpec {
+			if len(ranges) > 0 && ranges[len(ranges)-1].specVersion == loSpec {
+				ranges[len(ranges)-1].to = hi
+			} else {
+				ranges = append(ranges, specRange{from: lo, to: hi, specVersion: loSpec})
+			}
+			return nil
+		}
+		if hi-lo == 1 {
+			if err := split(lo, lo, loSpec, loSpec); err != nil {
+				return err
+			}
+			return split(hi, hi, hiSpec, hiSpec)
+		}
+		mid := lo + (hi-lo)/2
+		midSpec, err := specAt(mid)
+		if err != nil {
+			return err
+		}
+		if err := split(lo, mid, loSpec, midSpec); err != nil {
+			return err
+		}
+		return split(mid, hi, midSpec, hiSpec)
+	}
+
+	if err := split(from, to, fromSpec, toSpec); err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// loadMetaDecoder returns the decoder of the spec version of r, it reads the runtime version and the metadata at the
+// first block of r unless the decoder is loaded already
+func (sc *GsrpcClient) loadMetaDecoder(ctx context.Context, r specRange, limiter *rateLimiter) (metaDecoder, error) {
+	sc.RLock()
+	md, exist := sc.metaDecoders[int(r.specVersion)]
+	sc.RUnlock()
+	if exist {
+		return md, nil
+	}
+
+	if !limiter.wait(ctx.Done()) {
+		return nil, ErrorTerminated
+	}
+	blockHash, err := sc.GetBlockHashContext(ctx, r.from)
+	if err != nil {
+		return nil, err
+	}
+	// getMetaDecoder reads the runtime version and the metadata
+	if !limiter.wait(ctx.Done()) || !limiter.wait(ctx.Done()) {
+		return nil, ErrorTerminated
+	}
+	return sc.getMetaDecoder(ctx, blockHash)
+}
+
+// rateLimiter hands out at most limit tokens per second, a limit of 0 never blocks. Limits above one token per
+// nanosecond are clamped.
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+func newRateLimiter(limit int) *rateLimiter {
+	if limit <= 0 {
+		return &rateLimiter{}
+	}
+	if limit > int(time.Second) {
+		limit = int(time.Second)
+	}
+	return &rateLimiter{ticker: time.NewTicker(time.Second / time.Duration(limit))}
+}
+
+// wait blocks until a token is available, it returns false if stop was closed first
+func (l *rateLimiter) wait(stop <-chan struct{}) bool {
+	if l.ticker == nil {
+		return true
+	}
+	select {
+	case <-l.ticker.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+func (l *rateLimiter) close() {
+	if l.ticker != nil {
+		l.ticker.Stop()
+	}
+}
@@ -0,0 +1,124 @@
+package client_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// simChain is a simulated node whose blocks carry a timestamp extrinsic, the n-th produced block is set at
+// 1600000000 plus n seconds. Blocks of a fork thus differ from the blocks they replace.
+type simChain struct {
+	node     *rpcmocksrv.Node
+	sc       *client.GsrpcClient
+	meta     *types.Metadata
+	produced int
+}
+
+func newSimChain(t *testing.T, cfg rpcmocksrv.NodeConfig) *simChain {
+	meta := types.NewMetadataV13()
+	assert.NoError(t, types.DecodeFromBytes(types.MustHexDecodeString(types.ExamplaryMetadataV13SubstrateString), meta))
+	eventsKey, err := types.CreateStorageKey(meta, config.SystemModuleId, "Events", nil)
+	assert.NoError(t, err)
+
+	cfg.Genesis = map[string][]byte{eventsKey.Hex(): {0x00}}
+	cfg.AllowUnsigned = true
+	node, err := rpcmocksrv.NewNode(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { node.Close() })
+	sc, err := client.NewGsrpcClient(client.ChainTypeStafi, node.URL, "", client.AddressTypeAccountId, AliceKey, tlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &simChain{node: node, sc: sc, meta: meta}
+}
+
+// produce adds n blocks on top of the best block and returns their hashes
+func (c *simChain) produce(t *testing.T, n int) []types.Hash {
+	api, err := c.sc.FlashApi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := make([]types.Hash, 0, n)
+	for i := 0; i < n; i++ {
+		c.produced++
+		stamp, err := types.NewCall(c.meta, "Timestamp.set", types.NewUCompactFromUInt(uint64(1600000000+c.produced)*1000))
+		assert.NoError(t, err)
+		_, err = api.Author.SubmitExtrinsic(types.NewExtrinsic(stamp))
+		assert.NoError(t, err)
+		hashes = append(hashes, c.node.ProduceBlock())
+	}
+	return hashes
+}
+
+func TestBackfill(t *testing.T) {
+	chain := newSimChain(t, rpcmocksrv.NodeConfig{})
+	chain.produce(t, 6)
+	chain.node.SetRuntimeVersion(types.RuntimeVersion{SpecName: "node-sim", ImplName: "node-sim", SpecVersion: 2, TransactionVersion: 1})
+	chain.produce(t, 6)
+	sc := chain.sc
+
+	numbers := make([]uint64, 0)
+	err := sc.Backfill(1, 12, client.BackfillConfig{Workers: 4}, func(blk *client.BackfillBlock) error {
+		numbers = append(numbers, blk.Number)
+		assert.Equal(t, uint64(1600000000)+blk.Number, blk.Timestamp)
+		if blk.Number < 7 {
+			assert.Equal(t, uint32(1), blk.SpecVersion)
+		} else {
+			assert.Equal(t, uint32(2), blk.SpecVersion)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, numbers)
+
+	// a handler error stops the delivery
+	stopped := errors.New("stopped")
+	numbers = numbers[:0]
+	err = sc.Backfill(2, 12, client.BackfillConfig{Workers: 4}, func(blk *client.BackfillBlock) error {
+		numbers = append(numbers, blk.Number)
+		if blk.Number == 4 {
+			return stopped
+		}
+		return nil
+	})
+	assert.Equal(t, stopped, err)
+	assert.Equal(t, []uint64{2, 3, 4}, numbers)
+
+	// a range beyond the best block fails
+	err = sc.Backfill(1, 13, client.BackfillConfig{Workers: 2, MaxRetry: 1, RetryInterval: time.Millisecond},
+		func(blk *client.BackfillBlock) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestBackfill_RateLimit(t *testing.T) {
+	chain := newSimChain(t, rpcmocksrv.NodeConfig{})
+	chain.produce(t, 6)
+	sc := chain.sc
+
+	// two requests for each end of the range and three for each block, the decoder is loaded already
+	start := time.Now()
+	count := 0
+	err := sc.Backfill(1, 6, client.BackfillConfig{Workers: 4, RateLimit: 100}, func(blk *client.BackfillBlock) error {
+		count++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, count)
+	assert.GreaterOrEqual(t, time.Since(start), 220*time.Millisecond)
+
+	// too high limits are clamped
+	err = sc.Backfill(1, 6, client.BackfillConfig{Workers: 4, RateLimit: math.MaxInt}, func(blk *client.BackfillBlock) error {
+		return nil
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+// BatchMode is the Utility call that dispatches the calls of a batch
+type BatchMode int
+
+const (
+	// BatchInterruptible is Utility.batch, it stops at the first failed call and keeps the calls before
+	BatchInterruptible BatchMode = iota
+	// BatchAll is Utility.batch_all, it reverts all calls if one fails
+	BatchAll
+	// BatchForce is Utility.force_batch, it dispatches all calls whether some fail
+	BatchForce
+)
+
+func (m BatchMode) Method() string {
+	switch m {
+	case BatchAll:
+		return config.MethodBatchAll
+	case BatchForce:
+		return config.MethodForceBatch
+	default:
+		return config.MethodBatch
+	}
+}
+
+// batchBaseLength is the length of a batch extrinsic without calls: extrinsic length, version, call index and the
+// length of the calls
+const batchBaseLength = 5 + 1 + 2 + 5 + signedExtrinsicOverhead
+
+// BatchBuilder splits calls into batch extrinsics that fit the block length and weight limits. Each extrinsic is
+// dispatched on its own, so a BatchAll builder fails to build calls that need several extrinsics unless Split is set.
+type BatchBuilder struct {
+	Mode BatchMode
+	// Limits default to the normal block limits of the chain
+	Limits *BlockLimits
+	// Split lets BatchAll calls span several extrinsics, a failed call reverts the calls of its extrinsic only
+	Split bool
+	calls []types.Call
+}
+
+func NewBatchBuilder(mode BatchMode) *BatchBuilder {
+	return &BatchBuilder{Mode: mode, calls: make([]types.Call, 0)}
+}
+
+// Add appends calls to the builder
+func (b *BatchBuilder) Add(calls ...types.Call) *BatchBuilder {
+	b.calls = append(b.calls, calls...)
+	return b
+}
+
+// Len returns the number of calls added
+func (b *BatchBuilder) Len() int {
+	return len(b.calls)
+}
+
+// Batch is one extrinsic built by a BatchBuilder
+type Batch struct {
+	Mode BatchMode
+	// From is the index of the first call of the batch among the calls of the builder
+	From   int
+	Calls  []types.Call
+	Ext    interface{}
+	Weight uint64
+}
+
+// Build splits the calls into batches, in order. A batch takes the calls that fit the max length and is shrunk
+// until its weight from the payment info fits the max weight.
+func (b *BatchBuilder) Build(c Chain) ([]*Batch, error) {
+	limits := b.Limits
+	if limits == nil {
+		var err error
+		if limits, err = NormalBlockLimits(c); err != nil {
+			return nil, err
+		}
+	}
+
+	batches := make([]*Batch, 0)
+	for from := 0; from < len(b.calls); {
+		next, err := b.nextChunk(from, limits.MaxLength)
+		if err != nil {
+			return nil, err
+		}
+
+		batch := &Batch{Mode: b.Mode, From: from}
+		for {
+			batch.Calls = b.calls[from:next]
+			if batch.Ext, err = c.NewUnsignedExtrinsic(b.Mode.Method(), batch.Calls); err != nil {
+				return nil, err
+			}
+			if batch.Weight, err = c.EstimateWeight(batch.Ext); err != nil {
+				return nil, err
+			}
+			if batch.Weight <= limits.MaxWeight {
+				break
+			}
+			n := next - from
+			if n == 1 {
+				return nil, fmt.Errorf("call %d: weight %d exceeds max weight %d", from, batch.Weight, limits.MaxWeight)
+			}
+			n = int(uint64(n) * limits.MaxWeight / batch.Weight)
+			if n >= next-from {
+				n = next - from - 1
+			}
+			if n < 1 {
+				n = 1
+			}
+			next = from + n
+		}
+		if b.Mode == BatchAll && !b.Split && next < len(b.calls) {
+			return nil, fmt.Errorf("only %d of %d calls fit one batch_all extrinsic", next, len(b.calls))
+		}
+		batches = append(batches, batch)
+		from = next
+	}
+	return batches, nil
+}
+
+// nextChunk returns the end of the calls from index from that fit maxLength
+func (b *BatchBuilder) nextChunk(from int, maxLength uint32) (int, error) {
+	length := batchBaseLength
+	next := from
+	for ; next < len(b.calls); next++ {
+		bz, err := types.EncodeToBytes(b.calls[next])
+		if err != nil {
+			return 0, fmt.Errorf("call %d: %s", next, err)
+		}
+		if uint32(length+len(bz)) > maxLength {
+			if next == from {
+				return 0, fmt.Errorf("call %d: length %d exceeds max length %d", next, length+len(bz), maxLength)
+			}
+			break
+		}
+		length += len(bz)
+	}
+	return next, nil
+}
+
+// BatchCallStatus is what happened to a call of a batch
+type BatchCallStatus int
+
+const (
+	BatchCallNotExecuted BatchCallStatus = iota
+	BatchCallSucceeded
+	BatchCallFailed
+)
+
+// BatchCallOutcome is the outcome of a call of a batch
+type BatchCallOutcome struct {
+	// Index is the index of the call among the calls of the builder
+	Index  int
+	Status BatchCallStatus
+	Error  *DispatchError
+}
+
+// BatchResult is a batch included in a block and the outcome of its calls
+type BatchResult struct {
+	Batch     *Batch
+	BlockHash types.Hash
+	Tx        *Transaction
+	Outcomes  []*BatchCallOutcome
+}
+
+// SubmitBatches submits the batches one after the other, each once the previous one is in a block, and reads the
+// outcome of their calls. It stops at the first batch that could not be submitted and returns the results so far.
+func (sc *GsrpcClient) SubmitBatches(ctx context.Context, batches []*Batch) ([]*BatchResult, error) {
+	results := make([]*BatchResult, 0, len(batches))
+	for _, batch := range batches {
+		blockHash, err := sc.SignAndSubmitTxInBlock(ctx, batch.Ext)
+		if err != nil {
+			return results, fmt.Errorf("batch from call %d: %s", batch.From, err)
+		}
+		bz, err := types.EncodeToBytes(batch.Ext)
+		if err != nil {
+			return results, err
+		}
+		extHash := blake2b.Sum256(bz)
+
+		view, err := sc.GetBlockViewContext(ctx, blockHash.Hex())
+		if err != nil {
+			return results, fmt.Errorf("batch from call %d: block %s: %s", batch.From, blockHash.Hex(), err)
+		}
+		result := &BatchResult{Batch: batch, BlockHash: blockHash}
+		for _, tx := range view.Transactions {
+			if strings.EqualFold(tx.ExtrinsicHash, types.HexEncodeToString(extHash[:])) {
+				result.Tx = tx
+				break
+			}
+		}
+		if result.Tx == nil {
+			return results, fmt.Errorf("batch from call %d: extrinsic not found in block %s", batch.From, blockHash.Hex())
+		}
+		if result.Outcomes, err = sc.BatchOutcomesContext(ctx, blockHash.Hex(), batch, result.Tx); err != nil {
+			return results, fmt.Errorf("batch from call %d: %s", batch.From, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// BatchOutcomes reads the outcome of the calls of batch from the events of its extrinsic tx. Calls are matched to
+// ItemCompleted and ItemFailed in order, runtimes without them tell the failed call by BatchInterrupted only. A
+// failed extrinsic fails all calls with its error. The calls must not emit Utility events themselves.
+func (sc *GsrpcClient) BatchOutcomes(blockHash string, batch *Batch, tx *Transaction) ([]*BatchCallOutcome, error) {
+	return sc.BatchOutcomesContext(context.Background(), blockHash, batch, tx)
+}
+
+func (sc *GsrpcClient) BatchOutcomesContext(ctx context.Context, blockHash string, batch *Batch, tx *Transaction) ([]*BatchCallOutcome, error) {
+	outcomes := make([]*BatchCallOutcome, 0, len(batch.Calls))
+	for i := range batch.Calls {
+		outcomes = append(outcomes, &BatchCallOutcome{Index: batch.From + i})
+	}
+	if !tx.Success {
+		for _, o := range outcomes {
+			o.Status, o.Error = BatchCallFailed, tx.Error
+		}
+		return outcomes, nil
+	}
+
+	item := 0
+	for _, evt := range tx.Events {
+		if evt.ModuleId != config.UtilityModuleId {
+			continue
+		}
+		switch evt.EventId {
+		case config.ItemCompletedEventId:
+			if item < len(outcomes) {
+				outcomes[item].Status = BatchCallSucceeded
+			}
+			item++
+		case config.ItemFailedEventId:
+			if len(evt.Params) != 1 {
+				return nil, fmt.Errorf("ItemFailed params number not right: %d, expected: 1", len(evt.Params))
+			}
+			if item < len(outcomes) {
+				outcomes[item].Status = BatchCallFailed
+				outcomes[item].Error = sc.parseDispatchError(ctx, blockHash, evt.Params[0].Value)
+			}
+			item++
+		case config.BatchInterruptedEventId:
+			if len(evt.Params) != 2 {
+				return nil, fmt.Errorf("BatchInterrupted params number not right: %d, expected: 2", len(evt.Params))
+			}
+			index, err := parseU32(evt.Params[0].Value)
+			if err != nil {
+				return nil, fmt.Errorf("BatchInterrupted params[0] -> index error: %s", err)
+			}
+			for i := 0; i < int(index) && i < len(outcomes); i++ {
+				outcomes[i].Status = BatchCallSucceeded
+			}
+			if int(index) < len(outcomes) {
+				outcomes[index].Status = BatchCallFailed
+				outcomes[index].Error = sc.parseDispatchError(ctx, blockHash, evt.Params[1].Value)
+			}
+		case config.BatchCompletedEventId, config.BatchCompletedWithErrorsEventId:
+			for _, o := range outcomes {
+				if o.Status == BatchCallNotExecuted {
+					o.Status = BatchCallSucceeded
+				}
+			}
+		}
+	}
+	return outcomes, nil
+}
@@ -0,0 +1,209 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	scale "github.com/itering/scale.go"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client/clientfake"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestBatchBuilder(t *testing.T) {
+	submitter := clientfake.NewSubmitter()
+	submitter.WeightOf = func(call *clientfake.Call) uint64 {
+		return uint64(len(call.Args[0].([]types.Call))) * 100
+	}
+	c := client.NewChain(nil, nil, nil, submitter, nil)
+
+	builder := client.NewBatchBuilder(client.BatchAll)
+	for i := 0; i < 10; i++ {
+		builder.Add(types.Call{CallIndex: types.CallIndex{SectionIndex: 5, MethodIndex: 3}, Args: make(types.Args, 30)})
+	}
+	assert.Equal(t, 10, builder.Len())
+
+	// each call is 32 bytes long, the length fits 4 calls and the weight 3
+	builder.Limits = &client.BlockLimits{MaxLength: 141 + 4*32, MaxWeight: 300}
+	_, err := builder.Build(c)
+	assert.EqualError(t, err, "only 3 of 10 calls fit one batch_all extrinsic")
+
+	builder.Split = true
+	batches, err := builder.Build(c)
+	assert.NoError(t, err)
+	assert.Len(t, batches, 4)
+	for i, n := range []int{3, 3, 3, 1} {
+		assert.Equal(t, i*3, batches[i].From)
+		assert.Len(t, batches[i].Calls, n)
+		assert.Equal(t, uint64(n*100), batches[i].Weight)
+		assert.Equal(t, config.MethodBatchAll, batches[i].Ext.(*clientfake.Call).Method)
+	}
+
+	builder.Limits = &client.BlockLimits{MaxLength: 141 + 4*32, MaxWeight: 50}
+	_, err = builder.Build(c)
+	assert.EqualError(t, err, "call 0: weight 100 exceeds max weight 50")
+
+	builder.Limits = &client.BlockLimits{MaxLength: 100, MaxWeight: 300}
+	_, err = builder.Build(c)
+	assert.EqualError(t, err, "call 0: length 173 exceeds max length 100")
+}
+
+func TestBatchOutcomes(t *testing.T) {
+	sc := &client.GsrpcClient{}
+	batch := &client.Batch{Mode: client.BatchInterruptible, From: 3, Calls: make([]types.Call, 3)}
+	utility := func(eventId string, values ...interface{}) *client.ChainEvent {
+		return &client.ChainEvent{ModuleId: config.UtilityModuleId, EventId: eventId, Params: params(values...)}
+	}
+	statuses := func(outcomes []*client.BatchCallOutcome) []client.BatchCallStatus {
+		s := make([]client.BatchCallStatus, 0, len(outcomes))
+		for _, o := range outcomes {
+			s = append(s, o.Status)
+		}
+		return s
+	}
+
+	// interrupted with item events
+	outcomes, err := sc.BatchOutcomes("", batch, &client.Transaction{Success: true, Events: []*client.ChainEvent{
+		utility(config.ItemCompletedEventId),
+		utility(config.BatchInterruptedEventId, float64(1), "BadOrigin"),
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, []client.BatchCallStatus{client.BatchCallSucceeded, client.BatchCallFailed, client.BatchCallNotExecuted}, statuses(outcomes))
+	assert.Equal(t, 4, outcomes[1].Index)
+	assert.Equal(t, &client.DispatchError{Kind: "BadOrigin"}, outcomes[1].Error)
+
+	// interrupted on a runtime without item events
+	outcomes, err = sc.BatchOutcomes("", batch, &client.Transaction{Success: true, Events: []*client.ChainEvent{
+		utility(config.BatchInterruptedEventId, float64(2), "BadOrigin"),
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, []client.BatchCallStatus{client.BatchCallSucceeded, client.BatchCallSucceeded, client.BatchCallFailed}, statuses(outcomes))
+
+	// forced
+	batch.Mode = client.BatchForce
+	outcomes, err = sc.BatchOutcomes("", batch, &client.Transaction{Success: true, Events: []*client.ChainEvent{
+		utility(config.ItemCompletedEventId),
+		utility(config.ItemFailedEventId, map[string]interface{}{"Other": nil}),
+		utility(config.ItemCompletedEventId),
+		utility(config.BatchCompletedWithErrorsEventId),
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, []client.BatchCallStatus{client.BatchCallSucceeded, client.BatchCallFailed, client.BatchCallSucceeded}, statuses(outcomes))
+	assert.Equal(t, "Other", outcomes[1].Error.Kind)
+
+	// all or nothing
+	batch.Mode = client.BatchAll
+	outcomes, err = sc.BatchOutcomes("", batch, &client.Transaction{Success: true, Events: []*client.ChainEvent{
+		utility(config.BatchCompletedEventId),
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, []client.BatchCallStatus{client.BatchCallSucceeded, client.BatchCallSucceeded, client.BatchCallSucceeded}, statuses(outcomes))
+
+	failed := &client.DispatchError{Kind: "Module", ModuleIndex: 5, ErrorIndex: 2}
+	outcomes, err = sc.BatchOutcomes("", batch, &client.Transaction{Success: false, Error: failed})
+	assert.NoError(t, err)
+	for _, o := range outcomes {
+		assert.Equal(t, client.BatchCallFailed, o.Status)
+		assert.Equal(t, failed, o.Error)
+	}
+
+	_, err = sc.BatchOutcomes("", batch, &client.Transaction{Success: true, Events: []*client.ChainEvent{
+		{ModuleId: config.UtilityModuleId, EventId: config.BatchInterruptedEventId, Params: []scale.EventParam{{Value: float64(0)}}},
+	}})
+	assert.EqualError(t, err, "BatchInterrupted params number not right: 1, expected: 2")
+}
+
+func TestSubmitBatches(t *testing.T) {
+	meta := types.NewMetadataV13()
+	assert.NoError(t, types.DecodeFromBytes(types.MustHexDecodeString(types.ExamplaryMetadataV13SubstrateString), meta))
+	eventsKey, err := types.CreateStorageKey(meta, config.SystemModuleId, "Events", nil)
+	assert.NoError(t, err)
+
+	accountKey, err := types.CreateStorageKey(meta, config.SystemModuleId, config.StorageAccount, AliceKey.PublicKey)
+	assert.NoError(t, err)
+	info := types.AccountInfo{}
+	info.Data.Free = types.NewU128(*big.NewInt(1e15))
+	info.Data.Reserved = types.NewU128(*big.NewInt(0))
+	info.Data.MiscFrozen = types.NewU128(*big.NewInt(0))
+	info.Data.FreeFrozen = types.NewU128(*big.NewInt(0))
+	account, err := types.EncodeToBytes(info)
+	assert.NoError(t, err)
+
+	node, err := rpcmocksrv.NewNode(rpcmocksrv.NodeConfig{Genesis: map[string][]byte{accountKey.Hex(): account}, AllowUnsigned: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+	sc, err := client.NewGsrpcClient(client.ChainTypeStafi, node.URL, "", client.AddressTypeAccountId, AliceKey, tlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bob, err := types.NewAddressFromHexAccountID("0x8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48")
+	assert.NoError(t, err)
+	calls := make([]types.Call, 0)
+	for i := 1; i <= 3; i++ {
+		call, err := types.NewCall(meta, config.MethodTransfer, bob, types.NewUCompactFromUInt(uint64(i)))
+		assert.NoError(t, err)
+		calls = append(calls, call)
+	}
+	batches := make([]*client.Batch, 0)
+	for _, from := range []int{0, 2} {
+		batch := &client.Batch{Mode: client.BatchInterruptible, From: from, Calls: calls[from:]}
+		if from == 0 {
+			batch.Calls = calls[:2]
+		}
+		batch.Ext, err = sc.NewUnsignedExtrinsic(config.MethodBatch, batch.Calls)
+		assert.NoError(t, err)
+		batches = append(batches, batch)
+	}
+
+	// a block has the timestamp at index 0 and the batch at index 1, the first batch completes and the second is
+	// interrupted at its only call
+	blockEvents := []string{
+		"0x08" + "00010000000101" + "00" + "00010000000000" + "e8030000" + "0000" + "00",
+		"0x08" + "0001000000010000000000" + "02" + "00" + "00010000000000" + "e8030000" + "0000" + "00",
+	}
+	api, err := sc.FlashApi()
+	assert.NoError(t, err)
+	blocks := make(chan types.Hash, len(blockEvents))
+	go func() {
+		for i, events := range blockEvents {
+			stamp, err := types.NewCall(meta, "Timestamp.set", types.NewUCompactFromUInt(uint64(1600000000000+i)))
+			assert.NoError(t, err)
+			_, err = api.Author.SubmitExtrinsic(types.NewExtrinsic(stamp))
+			assert.NoError(t, err)
+			for len(node.Pending()) < 2 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			node.SetStorage(eventsKey, types.MustHexDecodeString(events))
+			blocks <- node.ProduceBlock()
+		}
+	}()
+
+	results, err := sc.SubmitBatches(context.Background(), batches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, results, 2)
+	for i, result := range results {
+		assert.Equal(t, <-blocks, result.BlockHash)
+		assert.Equal(t, 1, result.Tx.Index)
+		signed, err := types.EncodeToBytes(batches[i].Ext)
+		assert.NoError(t, err)
+		hash := blake2b.Sum256(signed)
+		assert.Equal(t, types.HexEncodeToString(hash[:]), result.Tx.ExtrinsicHash)
+	}
+	statuses := []client.BatchCallStatus{client.BatchCallSucceeded, client.BatchCallSucceeded, client.BatchCallFailed}
+	for i, o := range append(results[0].Outcomes, results[1].Outcomes...) {
+		assert.Equal(t, i, o.Index)
+		assert.Equal(t, statuses[i], o.Status)
+	}
+	assert.Equal(t, "BadOrigin", results[1].Outcomes[0].Error.Kind)
+}
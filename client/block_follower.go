@@ -0,0 +1,438 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+const (
+	defaultFollowBufferSize   = 16
+	defaultFollowPollInterval = 6 * time.Second
+	defaultFollowReorgDepth   = 256
+)
+
+// FollowedBlock is a block delivered by BlockFollower. When Reverted is true the block was dropped from the best chain
+// by a reorg and was already delivered before, reverted blocks are delivered from the highest to the lowest number
+type FollowedBlock struct {
+	Number     uint64
+	Hash       string
+	Header     *types.Header
+	Extrinsics []*Transaction
+	Events     []*ChainEvent
+	Reverted   bool
+
+	// parentHash is set for reverted blocks, which have no header
+	parentHash string
+}
+
+// BlockHandler processes a followed block, a returned error makes the follower retry the same block
+type BlockHandler func(blk *FollowedBlock) error
+
+// Checkpoint is the last block that was handled successfully
+type Checkpoint struct {
+	Number uint64 `json:"number"`
+	Hash   string `json:"hash"`
+}
+
+// CheckpointStore persists the progress of a BlockFollower
+type CheckpointStore interface {
+	// Load returns the latest checkpoint, exist is false if nothing was saved yet
+	Load() (cp Checkpoint, exist bool, err error)
+	Save(cp Checkpoint) error
+}
+
+type MemoryCheckpointStore struct {
+	mu    sync.Mutex
+	cp    Checkpoint
+	exist bool
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{}
+}
+
+func (m *MemoryCheckpointStore) Load() (Checkpoint, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cp, m.exist, nil
+}
+
+func (m *MemoryCheckpointStore) Save(cp Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cp = cp
+	m.exist = true
+	return nil
+}
+
+// FileCheckpointStore keeps the checkpoint as json in a file, writes go through a temp file and a rename
+type FileCheckpointStore struct {
+	path string
+}
+
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+func (f *FileCheckpointStore) Load() (Checkpoint, bool, error) {
+	var cp Checkpoint
+	bz, err := os.ReadFile(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cp, false, nil
+		}
+		return cp, false, err
+	}
+	if err := json.Unmarshal(bz, &cp); err != nil {
+		return cp, false, err
+	}
+	return cp, true, nil
+}
+
+func (f *FileCheckpointStore) Save(cp Checkpoint) error {
+	bz, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, bz, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+type BlockFollowerConfig struct {
+	// StartBlock is used when the checkpoint store is empty
+	StartBlock uint64
+	// Finalized follows finalized blocks only, otherwise the best chain is followed and reorgs are delivered as
+	// reverted blocks
+	Finalized bool
+	// BufferSize is the number of fetched blocks waiting for the handler, the fetcher blocks when it is full
+	BufferSize int
+	// PollInterval is the wait between polls once the follower caught up with the chain
+	PollInterval time.Duration
+	// ReorgDepth is the number of best chain blocks remembered for reorg detection, a deeper reorg stops the follower
+	// with ErrorReorgTooDeep
+	ReorgDepth int
+	Checkpoint CheckpointStore
+}
+
+// BlockFollower streams blocks with their extrinsics and events to a handler, at least once and in block order
+type BlockFollower struct {
+	sc      *GsrpcClient
+	cfg     BlockFollowerConfig
+	handler BlockHandler
+
+	blocks   chan *FollowedBlock
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	errMu    sync.Mutex
+	err      error
+
+	// best chain hashes of fetched blocks, used by the fetcher only
+	recent map[uint64]string
+}
+
+func (sc *GsrpcClient) NewBlockFollower(cfg BlockFollowerConfig, handler BlockHandler) *BlockFollower {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultFollowBufferSize
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultFollowPollInterval
+	}
+	if cfg.ReorgDepth <= 0 {
+		cfg.ReorgDepth = defaultFollowReorgDepth
+	}
+	if cfg.Checkpoint == nil {
+		cfg.Checkpoint = NewMemoryCheckpointStore()
+	}
+	return &BlockFollower{
+		sc:      sc,
+		cfg:     cfg,
+		handler: handler,
+		blocks:  make(chan *FollowedBlock, cfg.BufferSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		recent:  make(map[uint64]string),
+	}
+}
+
+// Start resumes from the checkpoint, or from StartBlock if there is none, and follows the chain until Stop is called
+func (f *BlockFollower) Start() error {
+	cp, exist, err := f.cfg.Checkpoint.Load()
+	if err != nil {
+		return err
+	}
+	next := f.cfg.StartBlock
+	if exist {
+		next = cp.Number + 1
+		if cp.Hash != "" {
+			if err := f.seedRecent(cp); err != nil {
+				return err
+			}
+		}
+	}
+
+	go f.fetchLoop(next)
+	go f.handleLoop()
+	return nil
+}
+
+// Stop stops the follower and waits for the handler to return. Blocks that were fetched but not handled are dropped,
+// they are fetched again on the next start
+func (f *BlockFollower) Stop() {
+	f.signalStop()
+	<-f.done
+}
+
+// Done is closed when the follower stopped, Err returns the reason once Done is closed
+func (f *BlockFollower) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *BlockFollower) Err() error {
+	f.errMu.Lock()
+	defer f.errMu.Unlock()
+	return f.err
+}
+
+// fail stops the follower with err, unless it stopped for another reason already
+func (f *BlockFollower) fail(err error) {
+	f.errMu.Lock()
+	if f.err == nil {
+		f.err = err
+	}
+	f.errMu.Unlock()
+	f.signalStop()
+}
+
+// seedRecent remembers the checkpoint block and its ancestors up to ReorgDepth blocks, walking the parent hashes of
+// the checkpoint so that a reorg of the blocks handled before the restart is still detected
+func (f *BlockFollower) seedRecent(cp Checkpoint) error {
+	f.recent[cp.Number] = cp.Hash
+	number, blockHash := cp.Number, cp.Hash
+	for i := 1; i < f.cfg.ReorgDepth && number > 0; i++ {
+		hash, err := types.NewHashFromHexString(blockHash)
+		if err != nil {
+			return err
+		}
+		header, err := f.sc.GetHeader(hash)
+		if err != nil {
+			return fmt.Errorf("get header of block %d %s: %s", number, blockHash, err)
+		}
+		number, blockHash = number-1, header.ParentHash.Hex()
+		f.recent[number] = blockHash
+	}
+	return nil
+}
+
+func (f *BlockFollower) fetchLoop(next uint64) {
+	for {
+		select {
+		case <-f.stop:
+			return
+		default:
+		}
+
+		target, err := f.targetBlock()
+		if err != nil {
+			f.sc.log.Warn("BlockFollower get target block failed", "err", err)
+			if !f.sleep() {
+				return
+			}
+			continue
+		}
+		if next > target {
+			if !f.sleep() {
+				return
+			}
+			continue
+		}
+
+		blk, err := f.fetchBlock(next)
+		if err != nil {
+			f.sc.log.Warn("BlockFollower fetch block failed", "block", next, "err", err)
+			if !f.sleep() {
+				return
+			}
+			continue
+		}
+
+		if !f.cfg.Finalized {
+			reverted, ancestor, err := f.checkReorg(blk)
+			if errors.Is(err, ErrorReorgTooDeep) {
+				f.sc.log.Error("BlockFollower reorg can not be followed", "block", next, "err", err)
+				f.fail(err)
+				return
+			}
+			if err != nil {
+				f.sc.log.Warn("BlockFollower check reorg failed", "block", next, "err", err)
+				if !f.sleep() {
+					return
+				}
+				continue
+			}
+			if reverted != nil {
+				f.sc.log.Info("BlockFollower reorg detected", "block", next, "depth", len(reverted))
+				for _, r := range reverted {
+					if !f.deliver(r) {
+						return
+					}
+				}
+				next = ancestor + 1
+				continue
+			}
+			f.remember(blk)
+		}
+
+		if !f.deliver(blk) {
+			return
+		}
+		next++
+	}
+}
+
+func (f *BlockFollower) targetBlock() (uint64, error) {
+	if f.cfg.Finalized {
+		return f.sc.GetFinalizedBlockNumber()
+	}
+	return f.sc.GetLatestBlockNumber()
+}
+
+func (f *BlockFollower) fetchBlock(number uint64) (*FollowedBlock, error) {
+	blockHash, err := f.sc.GetBlockHash(number)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := types.NewHashFromHexString(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	header, err := f.sc.GetHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+	exts, err := f.sc.GetExtrinsics(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	events, err := f.sc.GetChainEvents(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FollowedBlock{
+		Number:     number,
+		Hash:       blockHash,
+		Header:     header,
+		Extrinsics: exts,
+		Events:     events,
+	}, nil
+}
+
+// checkReorg compares the parent of blk with the remembered best chain. On a reorg it returns the dropped blocks from
+// the highest to the lowest and the number of the common ancestor
+func (f *BlockFollower) checkReorg(blk *FollowedBlock) ([]*FollowedBlock, uint64, error) {
+	if blk.Number == 0 {
+		return nil, 0, nil
+	}
+	parent, ok := f.recent[blk.Number-1]
+	if !ok || parent == blk.Header.ParentHash.Hex() {
+		return nil, 0, nil
+	}
+
+	reverted := make([]*FollowedBlock, 0)
+	number := blk.Number - 1
+	for {
+		old, ok := f.recent[number]
+		if !ok {
+			return nil, 0, fmt.Errorf("%w (%d) at block %d", ErrorReorgTooDeep, f.cfg.ReorgDepth, blk.Number)
+		}
+		canonical, err := f.sc.GetBlockHash(number)
+		if err != nil {
+			return nil, 0, err
+		}
+		if canonical == old {
+			return reverted, number, nil
+		}
+
+		if number == 0 {
+			return nil, 0, fmt.Errorf("%w: reached genesis at block %d", ErrorReorgTooDeep, blk.Number)
+		}
+		reverted = append(reverted, &FollowedBlock{Number: number, Hash: old, Reverted: true, parentHash: f.recent[number-1]})
+		delete(f.recent, number)
+		number--
+	}
+}
+
+func (f *BlockFollower) remember(blk *FollowedBlock) {
+	f.recent[blk.Number] = blk.Hash
+	if blk.Number >= uint64(f.cfg.ReorgDepth) {
+		delete(f.recent, blk.Number-uint64(f.cfg.ReorgDepth))
+	}
+}
+
+func (f *BlockFollower) deliver(blk *FollowedBlock) bool {
+	select {
+	case f.blocks <- blk:
+		return true
+	case <-f.stop:
+		return false
+	}
+}
+
+func (f *BlockFollower) sleep() bool {
+	select {
+	case <-time.After(f.cfg.PollInterval):
+		return true
+	case <-f.stop:
+		return false
+	}
+}
+
+func (f *BlockFollower) handleLoop() {
+	defer close(f.done)
+	for {
+		var blk *FollowedBlock
+		select {
+		case blk = <-f.blocks:
+		case <-f.stop:
+			f.fail(ErrorTerminated)
+			return
+		}
+
+		for {
+			err := f.handler(blk)
+			if err == nil {
+				break
+			}
+			f.sc.log.Warn("BlockFollower handler failed, will retry", "block", blk.Number, "reverted", blk.Reverted, "err", err)
+			if !f.sleep() {
+				f.fail(ErrorTerminated)
+				return
+			}
+		}
+
+		cp := Checkpoint{Number: blk.Number, Hash: blk.Hash}
+		if blk.Reverted {
+			// the parent of a reverted block is the last block that is still valid
+			cp = Checkpoint{Number: blk.Number - 1, Hash: blk.parentHash}
+		}
+		if err := f.cfg.Checkpoint.Save(cp); err != nil {
+			f.sc.log.Error("BlockFollower save checkpoint failed", "block", blk.Number, "err", err)
+			f.fail(err)
+			return
+		}
+	}
+}
+
+func (f *BlockFollower) signalStop() {
+	f.stopOnce.Do(func() { close(f.stop) })
+}
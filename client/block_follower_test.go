@@ -0,0 +1,233 @@
+package client_test
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileCheckpointStore(t *testing.T) {
+	store := client.NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+
+	_, exist, err := store.Load()
+	assert.NoError(t, err)
+	assert.False(t, exist)
+
+	cp := client.Checkpoint{Number: 100, Hash: "0x01"}
+	assert.NoError(t, store.Save(cp))
+
+	loaded, exist, err := store.Load()
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	assert.Equal(t, cp, loaded)
+}
+
+// handled records the blocks given to a follower handler
+type handled struct {
+	mu     sync.Mutex
+	blocks []*client.FollowedBlock
+}
+
+func (h *handled) add(blk *client.FollowedBlock) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.blocks = append(h.blocks, blk)
+}
+
+// wait returns the handled blocks once there are n of them
+func (h *handled) wait(t *testing.T, n int) []*client.FollowedBlock {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		h.mu.Lock()
+		if len(h.blocks) >= n {
+			blocks := append([]*client.FollowedBlock{}, h.blocks...)
+			h.mu.Unlock()
+			return blocks
+		}
+		h.mu.Unlock()
+	}
+	t.Fatalf("%d blocks not handled in time", n)
+	return nil
+}
+
+type step struct {
+	Number   uint64
+	Hash     types.Hash
+	Reverted bool
+}
+
+func steps(blocks []*client.FollowedBlock) []step {
+	s := make([]step, 0, len(blocks))
+	for _, blk := range blocks {
+		s = append(s, step{blk.Number, types.NewHash(types.MustHexDecodeString(blk.Hash)), blk.Reverted})
+	}
+	return s
+}
+
+// recordingStore keeps every saved checkpoint
+type recordingStore struct {
+	*client.MemoryCheckpointStore
+	mu    sync.Mutex
+	saved []client.Checkpoint
+}
+
+func (r *recordingStore) Save(cp client.Checkpoint) error {
+	r.mu.Lock()
+	r.saved = append(r.saved, cp)
+	r.mu.Unlock()
+	return r.MemoryCheckpointStore.Save(cp)
+}
+
+func TestBlockFollower_Reorg(t *testing.T) {
+	chain := newSimChain(t, rpcmocksrv.NodeConfig{FinalityLag: 20})
+	old := chain.produce(t, 4)
+
+	store := &recordingStore{MemoryCheckpointStore: client.NewMemoryCheckpointStore()}
+	h := &handled{}
+	follower := chain.sc.NewBlockFollower(client.BlockFollowerConfig{StartBlock: 1, PollInterval: 10 * time.Millisecond,
+		Checkpoint: store}, func(blk *client.FollowedBlock) error {
+		h.add(blk)
+		return nil
+	})
+	assert.NoError(t, follower.Start())
+	h.wait(t, 4)
+
+	assert.NoError(t, chain.node.Rewind(2))
+	fork := chain.produce(t, 3)
+	blocks := h.wait(t, 9)
+	follower.Stop()
+
+	assert.Equal(t, []step{
+		{1, old[0], false}, {2, old[1], false}, {3, old[2], false}, {4, old[3], false},
+		{4, old[3], true}, {3, old[2], true},
+		{3, fork[0], false}, {4, fork[1], false}, {5, fork[2], false},
+	}, steps(blocks))
+	assert.Equal(t, client.Checkpoint{Number: 3, Hash: old[2].Hex()}, store.saved[4])
+	assert.Equal(t, client.Checkpoint{Number: 2, Hash: old[1].Hex()}, store.saved[5])
+	assert.Equal(t, client.Checkpoint{Number: 5, Hash: fork[2].Hex()}, store.saved[8])
+}
+
+func TestBlockFollower_ReorgAfterRestart(t *testing.T) {
+	chain := newSimChain(t, rpcmocksrv.NodeConfig{FinalityLag: 20})
+	old := chain.produce(t, 4)
+
+	store := client.NewMemoryCheckpointStore()
+	h := &handled{}
+	handler := func(blk *client.FollowedBlock) error {
+		h.add(blk)
+		return nil
+	}
+	cfg := client.BlockFollowerConfig{StartBlock: 1, PollInterval: 10 * time.Millisecond, Checkpoint: store}
+	follower := chain.sc.NewBlockFollower(cfg, handler)
+	assert.NoError(t, follower.Start())
+	h.wait(t, 4)
+	follower.Stop()
+
+	// the checkpoint block and its parent are dropped while the follower is down
+	assert.NoError(t, chain.node.Rewind(2))
+	fork := chain.produce(t, 3)
+	follower = chain.sc.NewBlockFollower(cfg, handler)
+	assert.NoError(t, follower.Start())
+	blocks := h.wait(t, 9)
+	follower.Stop()
+
+	assert.Equal(t, []step{
+		{4, old[3], true}, {3, old[2], true},
+		{3, fork[0], false}, {4, fork[1], false}, {5, fork[2], false},
+	}, steps(blocks[4:]))
+}
+
+func TestBlockFollower_ReorgTooDeep(t *testing.T) {
+	chain := newSimChain(t, rpcmocksrv.NodeConfig{FinalityLag: 20})
+	chain.produce(t, 4)
+
+	h := &handled{}
+	follower := chain.sc.NewBlockFollower(client.BlockFollowerConfig{StartBlock: 1, PollInterval: 10 * time.Millisecond,
+		ReorgDepth: 2}, func(blk *client.FollowedBlock) error {
+		h.add(blk)
+		return nil
+	})
+	assert.NoError(t, follower.Start())
+	h.wait(t, 4)
+
+	assert.NoError(t, chain.node.Rewind(1))
+	chain.produce(t, 4)
+	select {
+	case <-follower.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("follower not stopped by the reorg")
+	}
+	assert.True(t, errors.Is(follower.Err(), client.ErrorReorgTooDeep), "%v", follower.Err())
+	follower.Stop()
+}
+
+func TestBlockFollower_HandlerRetry(t *testing.T) {
+	chain := newSimChain(t, rpcmocksrv.NodeConfig{})
+	hashes := chain.produce(t, 3)
+
+	failures := 2
+	h := &handled{}
+	store := client.NewMemoryCheckpointStore()
+	follower := chain.sc.NewBlockFollower(client.BlockFollowerConfig{StartBlock: 1, Finalized: true,
+		PollInterval: 10 * time.Millisecond, Checkpoint: store}, func(blk *client.FollowedBlock) error {
+		h.add(blk)
+		if blk.Number == 2 && failures > 0 {
+			failures--
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+	assert.NoError(t, follower.Start())
+	blocks := h.wait(t, 5)
+	follower.Stop()
+
+	numbers := make([]uint64, 0)
+	for _, blk := range blocks[:5] {
+		numbers = append(numbers, blk.Number)
+	}
+	assert.Equal(t, []uint64{1, 2, 2, 2, 3}, numbers)
+	cp, exist, err := store.Load()
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	assert.Equal(t, client.Checkpoint{Number: 3, Hash: hashes[2].Hex()}, cp)
+}
+
+func TestBlockFollower_BackPressure(t *testing.T) {
+	chain := newSimChain(t, rpcmocksrv.NodeConfig{FinalityLag: 20})
+	first := chain.produce(t, 1)
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	h := &handled{}
+	follower := chain.sc.NewBlockFollower(client.BlockFollowerConfig{StartBlock: 1, BufferSize: 1,
+		PollInterval: 10 * time.Millisecond}, func(blk *client.FollowedBlock) error {
+		if blk.Number == 1 && !blk.Reverted {
+			close(entered)
+			<-release
+		}
+		h.add(blk)
+		return nil
+	})
+	assert.NoError(t, follower.Start())
+	defer follower.Stop()
+	<-entered
+
+	// while the handler blocks, the follower fetches one block for the buffer and one waiting for it only. The
+	// blocks it fetches later come from the fork.
+	old := chain.produce(t, 9)
+	time.Sleep(200 * time.Millisecond)
+	assert.NoError(t, chain.node.Rewind(1))
+	fork := chain.produce(t, 9)
+	close(release)
+
+	expected := []step{{1, first[0], false}, {2, old[0], false}, {3, old[1], false}, {3, old[1], true}, {2, old[0], true}}
+	for i, hash := range fork {
+		expected = append(expected, step{uint64(i + 2), hash, false})
+	}
+	assert.Equal(t, expected, steps(h.wait(t, len(expected))))
+}
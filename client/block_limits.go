@@ -0,0 +1,74 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// signedExtrinsicOverhead bounds what signing adds to the encoding of an unsigned extrinsic: address, signature, era,
+// nonce and tip
+const signedExtrinsicOverhead = 128
+
+// BlockLimits are the length and weight a normal extrinsic may use in a block
+type BlockLimits struct {
+	MaxLength uint32
+	MaxWeight uint64
+}
+
+// perDispatchClassU32 is System.BlockLength
+type perDispatchClassU32 struct {
+	Normal      uint32
+	Operational uint32
+	Mandatory   uint32
+}
+
+type weightsPerClass struct {
+	BaseExtrinsic uint64
+	MaxExtrinsic  types.OptionU64
+	MaxTotal      types.OptionU64
+	Reserved      types.OptionU64
+}
+
+// blockWeights is System.BlockWeights before weight v2
+type blockWeights struct {
+	BaseBlock uint64
+	MaxBlock  uint64
+	PerClass  struct {
+		Normal      weightsPerClass
+		Operational weightsPerClass
+		Mandatory   weightsPerClass
+	}
+}
+
+// NormalBlockLimits reads the limits of normal extrinsics from the System.BlockLength and System.BlockWeights
+// constants. The weight limit is the max extrinsic weight of the normal class, its max total or the max block weight,
+// the first one set.
+func NormalBlockLimits(s StorageReader, blockHash ...types.Hash) (*BlockLimits, error) {
+	var length perDispatchClassU32
+	if err := s.GetConst(config.SystemModuleId, config.ConstBlockLength, &length, blockHash...); err != nil {
+		return nil, fmt.Errorf("block length: %s", err)
+	}
+	var weights blockWeights
+	if err := s.GetConst(config.SystemModuleId, config.ConstBlockWeights, &weights, blockHash...); err != nil {
+		return nil, fmt.Errorf("block weights: %s", err)
+	}
+
+	limits := &BlockLimits{MaxLength: length.Normal, MaxWeight: weights.MaxBlock}
+	if ok, max := weights.PerClass.Normal.MaxExtrinsic.Unwrap(); ok {
+		limits.MaxWeight = uint64(max)
+	} else if ok, max := weights.PerClass.Normal.MaxTotal.Unwrap(); ok {
+		limits.MaxWeight = uint64(max)
+	}
+	return limits, nil
+}
+
+// encodedLength returns the length of ext once signed
+func encodedLength(ext interface{}) (int, error) {
+	bz, err := types.EncodeToBytes(ext)
+	if err != nil {
+		return 0, err
+	}
+	return len(bz) + signedExtrinsicOverhead, nil
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// GetBlockView returns the signed extrinsics of the block with their own events, result, weight and fee
+func (sc *GsrpcClient) GetBlockView(blockHash string) (*BlockView, error) {
+	return sc.GetBlockViewContext(context.Background(), blockHash)
+}
+
+func (sc *GsrpcClient) GetBlockViewContext(ctx context.Context, blockHash string) (*BlockView, error) {
+	stamp, exts, err := sc.getBlockTimestampAndExtrinsics(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	events, err := sc.GetChainEventsContext(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int, 0, len(exts))
+	for index := range exts {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	var author *types.AccountID
+	blockAuthor := func() (types.AccountID, error) {
+		if author == nil {
+			hash, err := types.NewHashFromHexString(blockHash)
+			if err != nil {
+				return types.AccountID{}, err
+			}
+			a, err := sc.BlockAuthorContext(ctx, hash)
+			if err != nil {
+				return types.AccountID{}, err
+			}
+			author = &a
+		}
+		return *author, nil
+	}
+
+	txs := make([]*Transaction, 0, len(indexes))
+	for _, index := range indexes {
+		tx := exts[index]
+		tx.Index = index
+		tx.Events = make([]*ChainEvent, 0)
+		for _, evt := range events {
+			if evt.Phase == 0 && evt.ExtrinsicIndex == index {
+				tx.Events = append(tx.Events, evt)
+			}
+		}
+		if err := sc.fillTransactionResult(ctx, blockHash, tx, blockAuthor); err != nil {
+			return nil, fmt.Errorf("extrinsic %d: %s", index, err)
+		}
+		txs = append(txs, tx)
+	}
+
+	return &BlockView{
+		Hash:         blockHash,
+		Timestamp:    stamp,
+		Transactions: txs,
+		Events:       events,
+	}, nil
+}
+
+// fillTransactionResult sets success, error, weight and fee from the events of the transaction. The fee is taken from
+// TransactionFeePaid, on runtimes without it from the fee deposits that end the events, see depositedFee
+func (sc *GsrpcClient) fillTransactionResult(ctx context.Context, blockHash string, tx *Transaction, blockAuthor func() (types.AccountID, error)) error {
+	var feePaid *big.Int
+
+	for _, evt := range tx.Events {
+		switch {
+		case evt.ModuleId == config.SystemModuleId && evt.EventId == config.ExtrinsicSuccessEventId:
+			if len(evt.Params) < 1 {
+				return fmt.Errorf("ExtrinsicSuccess params number not right: %d", len(evt.Params))
+			}
+			tx.Success = true
+			tx.Weight = parseDispatchInfoWeight(evt.Params[0].Value)
+		case evt.ModuleId == config.SystemModuleId && evt.EventId == config.ExtrinsicFailedEventId:
+			if len(evt.Params) < 2 {
+				return fmt.Errorf("ExtrinsicFailed params number not right: %d", len(evt.Params))
+			}
+			tx.Success = false
+			tx.Error = sc.parseDispatchError(ctx, blockHash, evt.Params[0].Value)
+			tx.Weight = parseDispatchInfoWeight(evt.Params[1].Value)
+		case evt.ModuleId == config.TransactionPaymentModuleId && evt.EventId == config.TransactionFeePaidEventId:
+			if len(evt.Params) < 2 {
+				return fmt.Errorf("TransactionFeePaid params number not right: %d", len(evt.Params))
+			}
+			fee, err := parseBigint(evt.Params[1].Value)
+			if err != nil {
+				return fmt.Errorf("TransactionFeePaid params[1] -> actual fee error: %s", err)
+			}
+			feePaid = fee
+		}
+	}
+
+	if feePaid == nil {
+		fee, err := depositedFee(tx.Events, blockAuthor)
+		if err != nil {
+			return err
+		}
+		feePaid = fee
+	}
+	if feePaid != nil {
+		fee := types.NewU128(*feePaid)
+		tx.Fee = &fee
+	}
+	return nil
+}
+
+// depositedFee reads the fee of runtimes without TransactionFeePaid. Their fee handler deposits to the treasury and
+// then to the block author right before ExtrinsicSuccess or ExtrinsicFailed, so the fee is the amount of the last
+// Treasury.Deposit plus that of the Balances.Deposit to the block author following it, if any. The fee is unknown
+// (nil) if the events do not end like that.
+func depositedFee(events []*ChainEvent, blockAuthor func() (types.AccountID, error)) (*big.Int, error) {
+	end := len(events)
+	if end > 0 && events[end-1].ModuleId == config.SystemModuleId &&
+		(events[end-1].EventId == config.ExtrinsicSuccessEventId || events[end-1].EventId == config.ExtrinsicFailedEventId) {
+		end--
+	}
+
+	var authorDeposit *ChainEvent
+	if end > 0 && events[end-1].ModuleId == config.BalancesModuleId && events[end-1].EventId == config.BalancesDepositEventId {
+		authorDeposit = events[end-1]
+		end--
+	}
+	if end == 0 || events[end-1].ModuleId != config.TreasuryModuleId || events[end-1].EventId != config.TreasuryDepositEventId {
+		return nil, nil
+	}
+
+	treasury := events[end-1]
+	if len(treasury.Params) < 1 {
+		return nil, fmt.Errorf("Treasury.Deposit params number not right: %d", len(treasury.Params))
+	}
+	fee, err := parseBigint(treasury.Params[0].Value)
+	if err != nil {
+		return nil, fmt.Errorf("Treasury.Deposit params[0] -> value error: %s", err)
+	}
+	if authorDeposit == nil {
+		return fee, nil
+	}
+
+	if len(authorDeposit.Params) < 2 {
+		return nil, fmt.Errorf("Balances.Deposit params number not right: %d", len(authorDeposit.Params))
+	}
+	who, err := parseAccountId(authorDeposit.Params[0].Value)
+	if err != nil {
+		return nil, fmt.Errorf("Balances.Deposit params[0] -> who error: %s", err)
+	}
+	author, err := blockAuthor()
+	if err != nil {
+		return nil, fmt.Errorf("block author: %s", err)
+	}
+	if who != author {
+		return nil, nil
+	}
+	value, err := parseBigint(authorDeposit.Params[1].Value)
+	if err != nil {
+		return nil, fmt.Errorf("Balances.Deposit params[1] -> value error: %s", err)
+	}
+	return fee.Add(fee, value), nil
+}
+
+// parseDispatchInfoWeight reads the weight of a DispatchInfo, which is a number before weight v2 and a
+// {ref_time, proof_size} struct after
+func parseDispatchInfoWeight(value interface{}) uint64 {
+	info, ok := value.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	switch w := info["weight"].(type) {
+	case float64:
+		return uint64(w)
+	case map[string]interface{}:
+		for _, key := range []string{"ref_time", "refTime"} {
+			if refTime, ok := w[key].(float64); ok {
+				return uint64(refTime)
+			}
+		}
+	}
+	return 0
+}
+
+func (sc *GsrpcClient) parseDispatchError(ctx context.Context, blockHash string, value interface{}) *DispatchError {
+	switch v := value.(type) {
+	case string:
+		return &DispatchError{Kind: v}
+	case map[string]interface{}:
+		for kind, detail := range v {
+			if kind != "Module" {
+				return &DispatchError{Kind: kind, Detail: detail}
+			}
+			de := &DispatchError{Kind: kind}
+			module, ok := detail.(map[string]interface{})
+			if !ok {
+				de.Detail = detail
+				return de
+			}
+			de.ModuleIndex = parseErrorIndex(module["index"])
+			de.ErrorIndex = parseErrorIndex(module["error"])
+			de.ModuleName, de.ErrorName = sc.moduleErrorName(ctx, blockHash, de.ModuleIndex, de.ErrorIndex)
+			return de
+		}
+	}
+	return &DispatchError{Kind: "Unknown", Detail: value}
+}
+
+// parseErrorIndex reads a module or error index, newer runtimes encode the error as 4 bytes with the index first
+func parseErrorIndex(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		s := strings.TrimPrefix(v, "0x")
+		if len(s) >= 2 {
+			i, err := strconv.ParseUint(s[:2], 16, 8)
+			if err == nil {
+				return int(i)
+			}
+		}
+	}
+	return 0
+}
+
+// moduleErrorName looks up module and error names in the metadata of the block, names are empty if not found
+func (sc *GsrpcClient) moduleErrorName(ctx context.Context, blockHash string, moduleIndex, errorIndex int) (string, string) {
+	md, err := sc.getMetaDecoder(ctx, blockHash)
+	if err != nil {
+		return "", ""
+	}
+	return md.ModuleError(moduleIndex, errorIndex)
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	scale "github.com/itering/scale.go"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var feeAuthor = types.NewAccountID(types.MustHexDecodeString("0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"))
+
+func blockAuthor() (types.AccountID, error) {
+	return feeAuthor, nil
+}
+
+func TestFillTransactionResult(t *testing.T) {
+	sc := &GsrpcClient{}
+
+	tx := &Transaction{Events: []*ChainEvent{
+		{ModuleId: config.TreasuryModuleId, EventId: config.TreasuryDepositEventId, Params: []scale.EventParam{{Value: "80"}}},
+		{ModuleId: config.BalancesModuleId, EventId: config.BalancesDepositEventId, Params: []scale.EventParam{{Value: types.HexEncodeToString(feeAuthor[:])}, {Value: "20"}}},
+		{ModuleId: config.SystemModuleId, EventId: config.ExtrinsicSuccessEventId, Params: []scale.EventParam{
+			{Value: map[string]interface{}{"weight": map[string]interface{}{"ref_time": float64(1000), "proof_size": float64(0)}}},
+		}},
+	}}
+	assert.NoError(t, sc.fillTransactionResult(context.Background(), "", tx, blockAuthor))
+	assert.True(t, tx.Success)
+	assert.Equal(t, uint64(1000), tx.Weight)
+	fee := types.NewU128(*big.NewInt(100))
+	assert.Equal(t, &fee, tx.Fee)
+
+	tx = &Transaction{Events: []*ChainEvent{
+		{ModuleId: config.TransactionPaymentModuleId, EventId: config.TransactionFeePaidEventId, Params: []scale.EventParam{{Value: "00"}, {Value: "100"}, {Value: "0"}}},
+		{ModuleId: config.SystemModuleId, EventId: config.ExtrinsicFailedEventId, Params: []scale.EventParam{
+			{Value: map[string]interface{}{"Module": map[string]interface{}{"index": float64(5), "error": "0x02000000"}}},
+			{Value: map[string]interface{}{"weight": float64(10)}},
+		}},
+	}}
+	assert.NoError(t, sc.fillTransactionResult(context.Background(), "", tx, blockAuthor))
+	assert.False(t, tx.Success)
+	assert.Equal(t, uint64(10), tx.Weight)
+	assert.Equal(t, &fee, tx.Fee)
+	assert.Equal(t, &DispatchError{Kind: "Module", ModuleIndex: 5, ErrorIndex: 2}, tx.Error)
+}
+
+func TestDepositedFee(t *testing.T) {
+	treasury := &ChainEvent{ModuleId: config.TreasuryModuleId, EventId: config.TreasuryDepositEventId, Params: []scale.EventParam{{Value: "80"}}}
+	deposit := func(who types.AccountID, value string) *ChainEvent {
+		return &ChainEvent{ModuleId: config.BalancesModuleId, EventId: config.BalancesDepositEventId,
+			Params: []scale.EventParam{{Value: types.HexEncodeToString(who[:])}, {Value: value}}}
+	}
+	success := &ChainEvent{ModuleId: config.SystemModuleId, EventId: config.ExtrinsicSuccessEventId}
+	other := types.NewAccountID(types.MustHexDecodeString("0x8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48"))
+
+	for _, c := range []struct {
+		name   string
+		events []*ChainEvent
+		fee    *big.Int
+	}{
+		{"treasury and author", []*ChainEvent{deposit(other, "5"), treasury, deposit(feeAuthor, "20"), success}, big.NewInt(100)},
+		{"treasury only", []*ChainEvent{treasury, success}, big.NewInt(80)},
+		{"deposit to another account", []*ChainEvent{treasury, deposit(other, "20"), success}, nil},
+		{"deposit of the call", []*ChainEvent{treasury, deposit(other, "5"), deposit(feeAuthor, "20"), success}, nil},
+		{"author deposit only", []*ChainEvent{deposit(feeAuthor, "20"), success}, nil},
+		{"no deposits", []*ChainEvent{success}, nil},
+	} {
+		fee, err := depositedFee(c.events, blockAuthor)
+		assert.NoError(t, err, c.name)
+		assert.Equal(t, c.fee, fee, c.name)
+	}
+}
@@ -0,0 +1,83 @@
+package clientfake_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client/clientfake"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// bondReport is the kind of code the fakes are for, it only knows client.Chain
+func bondReport(c client.Chain, sym client.RSymbol, shotId types.Hash) error {
+	if _, err := c.CurrentChainEra(sym); err != nil {
+		return err
+	}
+	call, err := c.NewUnsignedExtrinsic(config.MethodBondReport, sym, shotId)
+	if err != nil {
+		return err
+	}
+	return c.SignAndSubmitTx(call)
+}
+
+func TestNewChain(t *testing.T) {
+	headers := clientfake.NewHeaders()
+	storage := clientfake.NewStorage()
+	events := clientfake.NewEvents(headers)
+	submitter := clientfake.NewSubmitter()
+	rTokens := clientfake.NewRTokens()
+	c := client.NewChain(headers, storage, events, submitter, rTokens)
+
+	hash := headers.Push()
+	headers.Push()
+	headers.Finalize(1)
+	latest, err := c.GetLatestBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), latest)
+	finalized, err := c.GetFinalizedHead()
+	assert.NoError(t, err)
+	assert.Equal(t, hash, finalized)
+	number, err := c.GetBlockNumber(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), number)
+
+	assert.NoError(t, storage.Set(config.RTokenLedgerModuleId, config.StorageChainEras, []byte{1}, nil, uint32(7)))
+	var era uint32
+	exists, err := c.QueryStorage(config.RTokenLedgerModuleId, config.StorageChainEras, []byte{1}, nil, &era)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, uint32(7), era)
+	exists, err = c.QueryStorage(config.RTokenLedgerModuleId, config.StorageChainEras, []byte{2}, nil, &era)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	events.Add(hash, &client.ChainEvent{ModuleId: "RTokenSeries", EventId: "EraPoolUpdated"})
+	evts, err := c.GetEvents(1)
+	assert.NoError(t, err)
+	assert.Len(t, evts, 1)
+	evts, err = c.GetEvents(2)
+	assert.NoError(t, err)
+	assert.Empty(t, evts)
+
+	shotId := types.NewHash([]byte{1})
+	assert.Equal(t, client.ErrorValueNotExist, bondReport(c, client.RDOT, shotId))
+	rTokens.ChainEras[client.RDOT] = 7
+	assert.NoError(t, bondReport(c, client.RDOT, shotId))
+	submitter.FailWith(errors.New("pool full"))
+	assert.EqualError(t, bondReport(c, client.RDOT, shotId), "pool full")
+	assert.Equal(t, []*clientfake.Call{{Method: config.MethodBondReport, Args: []interface{}{client.RDOT, shotId}}},
+		submitter.Submitted())
+
+	// the pallet readers are served by the same RTokens
+	var dex client.StafiRDexReader = c
+	_, err = dex.SwapPool(client.RDOT)
+	assert.Equal(t, client.ErrorValueNotExist, err)
+	rTokens.SwapPools[client.RDOT] = &client.SwapPool{Symbol: client.RDOT, TotalUnit: types.NewU128(*big.NewInt(10))}
+	pool, err := dex.SwapPool(client.RDOT)
+	assert.NoError(t, err)
+	assert.Equal(t, client.RDOT, pool.Symbol)
+}
@@ -0,0 +1,50 @@
+package clientfake
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+var _ client.EventReader = (*Events)(nil)
+
+// Events holds the events of blocks. Blocks without events have none, GetEvents needs the hash of the block from
+// Headers.
+type Events struct {
+	headers client.HeaderReader
+
+	mu     sync.Mutex
+	events map[string][]*client.ChainEvent
+}
+
+// NewEvents returns Events that finds block hashes by number with headers
+func NewEvents(headers client.HeaderReader) *Events {
+	return &Events{headers: headers, events: make(map[string][]*client.ChainEvent)}
+}
+
+// Add appends events to the block with blockHash
+func (e *Events) Add(blockHash types.Hash, events ...*client.ChainEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events[blockHash.Hex()] = append(e.events[blockHash.Hex()], events...)
+}
+
+func (e *Events) GetChainEvents(blockHash string) ([]*client.ChainEvent, error) {
+	hash, err := types.NewHashFromHexString(blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block hash %s: %s", blockHash, err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*client.ChainEvent{}, e.events[hash.Hex()]...), nil
+}
+
+func (e *Events) GetEvents(blockNum uint64) ([]*client.ChainEvent, error) {
+	blockHash, err := e.headers.GetBlockHash(blockNum)
+	if err != nil {
+		return nil, err
+	}
+	return e.GetChainEvents(blockHash)
+}
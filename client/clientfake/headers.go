@@ -0,0 +1,123 @@
+// Package clientfake has in-memory implementations of the client interfaces for unit tests of code built on
+// client.Chain.
+package clientfake
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+var _ client.HeaderReader = (*Headers)(nil)
+
+// Headers is a chain of headers starting at a genesis header
+type Headers struct {
+	mu        sync.Mutex
+	headers   []*types.Header
+	hashes    []types.Hash
+	byHash    map[types.Hash]int
+	finalized int
+}
+
+// NewHeaders returns a chain with only the genesis header, which is finalized
+func NewHeaders() *Headers {
+	h := &Headers{byHash: make(map[types.Hash]int)}
+	h.push(&types.Header{})
+	return h
+}
+
+// Push appends a header on top of the best header and returns its hash
+func (h *Headers) Push() types.Hash {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.push(&types.Header{
+		ParentHash: h.hashes[len(h.hashes)-1],
+		Number:     types.BlockNumber(len(h.headers)),
+	})
+}
+
+// Finalize finalizes the header at number, the best header if number is beyond it
+func (h *Headers) Finalize(number uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if number >= uint64(len(h.headers)) {
+		number = uint64(len(h.headers) - 1)
+	}
+	h.finalized = int(number)
+}
+
+// Hash returns the hash of the header at number
+func (h *Headers) Hash(number uint64) types.Hash {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if number >= uint64(len(h.hashes)) {
+		return types.Hash{}
+	}
+	return h.hashes[number]
+}
+
+func (h *Headers) push(header *types.Header) types.Hash {
+	hash, err := types.GetHash(header)
+	if err != nil {
+		panic(err)
+	}
+	h.byHash[hash] = len(h.headers)
+	h.headers = append(h.headers, header)
+	h.hashes = append(h.hashes, hash)
+	return hash
+}
+
+func (h *Headers) GetLatestBlockNumber() (uint64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return uint64(len(h.headers) - 1), nil
+}
+
+func (h *Headers) GetFinalizedBlockNumber() (uint64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return uint64(h.finalized), nil
+}
+
+func (h *Headers) GetHeaderLatest() (*types.Header, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	header := *h.headers[len(h.headers)-1]
+	return &header, nil
+}
+
+func (h *Headers) GetFinalizedHead() (types.Hash, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.hashes[h.finalized], nil
+}
+
+func (h *Headers) GetHeader(blockHash types.Hash) (*types.Header, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i, ok := h.byHash[blockHash]
+	if !ok {
+		return nil, fmt.Errorf("header %s not found", blockHash.Hex())
+	}
+	header := *h.headers[i]
+	return &header, nil
+}
+
+func (h *Headers) GetBlockNumber(blockHash types.Hash) (uint64, error) {
+	header, err := h.GetHeader(blockHash)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(header.Number), nil
+}
+
+func (h *Headers) GetBlockHash(blockNum uint64) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if blockNum >= uint64(len(h.hashes)) {
+		return "", fmt.Errorf("ChainGetBlockHash error, blockHash empty")
+	}
+	return h.hashes[blockNum].Hex(), nil
+}
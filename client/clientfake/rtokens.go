@@ -0,0 +1,299 @@
+package clientfake
+
+import (
+	"encoding/hex"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+var _ client.StafiRTokenReader = (*RTokens)(nil)
+
+// ActKey keys the mint reward acts of a symbol
+type ActKey struct {
+	Symbol client.RSymbol
+	Cycle  uint32
+}
+
+// EraKey keys the rates of a symbol
+type EraKey struct {
+	Symbol client.RSymbol
+	Era    uint32
+}
+
+// ShotKey keys the bond snapshots of a symbol
+type ShotKey struct {
+	Symbol client.RSymbol
+	ShotId types.Hash
+}
+
+// PoolKey keys the state of a pool, Pool is the hex of the pool without 0x prefix
+type PoolKey struct {
+	Symbol client.RSymbol
+	Pool   string
+}
+
+// UnbondKey keys the unbonds of a pool in an era, Pool is the hex of the pool without 0x prefix
+type UnbondKey struct {
+	Symbol client.RSymbol
+	Pool   string
+	Era    uint32
+}
+
+// MintKey keys the mints of an account in an act, Account is the 0x prefixed hex of the account
+type MintKey struct {
+	Account string
+	Symbol  client.RSymbol
+	Cycle   uint32
+}
+
+// ProviderKey keys the liquidity of an account in a swap pool, Account is the 0x prefixed hex of the account
+type ProviderKey struct {
+	Account string
+	Symbol  client.RSymbol
+}
+
+// RTokens serves the rToken state from its fields, missing entries return client.ErrorValueNotExist like
+// GsrpcClient does. The fields are set up before the code under test runs, block hashes are ignored.
+type RTokens struct {
+	ChainEras        map[client.RSymbol]uint32
+	ChangeRateLimits map[client.RSymbol]uint32
+	TotalIssuance    map[client.RSymbol]types.U128
+	Snapshots        map[client.RSymbol][]types.Hash
+	EraRates         map[EraKey]uint64
+	Receiver         *types.AccountID
+	RFisReceiver     *types.AccountID
+	ActLatestCycles  map[client.RSymbol]uint32
+	Acts             map[ActKey]*client.MintRewardAct
+	// REthLatestCycle and REthCurrentCycle are missing while 0
+	REthLatestCycle  uint32
+	REthCurrentCycle uint32
+	REthActs         map[uint32]*client.MintRewardAct
+	// MintTxHashes is keyed by the 0x prefixed hex of the tx hash
+	MintTxHashes map[string]bool
+
+	BondSnapshots   map[ShotKey]*client.BondSnapshot
+	Pools           map[client.RSymbol][]types.Bytes
+	Unbonds         map[UnbondKey][]client.Unbonding
+	SubAccountsOf   map[PoolKey][]types.Bytes
+	MultiThresholds map[PoolKey]uint16
+
+	// AccountUnbondsOf is keyed by the 0x prefixed hex of the account, a symbol without accounts has no AccountUnbonds
+	// storage as on runtimes before it
+	AccountUnbondsOf   map[client.RSymbol]map[string][]client.UserUnlockChunk
+	UnbondingDurations map[client.RSymbol]uint32
+
+	// ClaimInfos are the mints of an account in an act by index, their count is the mints count
+	ClaimInfos map[MintKey][]*client.ClaimInfo
+
+	SwapPools     map[client.RSymbol]*client.SwapPool
+	SwapProviders map[ProviderKey]*client.SwapLiquidityProvider
+}
+
+func NewRTokens() *RTokens {
+	return &RTokens{
+		ChainEras:          make(map[client.RSymbol]uint32),
+		ChangeRateLimits:   make(map[client.RSymbol]uint32),
+		TotalIssuance:      make(map[client.RSymbol]types.U128),
+		Snapshots:          make(map[client.RSymbol][]types.Hash),
+		EraRates:           make(map[EraKey]uint64),
+		ActLatestCycles:    make(map[client.RSymbol]uint32),
+		Acts:               make(map[ActKey]*client.MintRewardAct),
+		REthActs:           make(map[uint32]*client.MintRewardAct),
+		MintTxHashes:       make(map[string]bool),
+		BondSnapshots:      make(map[ShotKey]*client.BondSnapshot),
+		Pools:              make(map[client.RSymbol][]types.Bytes),
+		Unbonds:            make(map[UnbondKey][]client.Unbonding),
+		SubAccountsOf:      make(map[PoolKey][]types.Bytes),
+		MultiThresholds:    make(map[PoolKey]uint16),
+		AccountUnbondsOf:   make(map[client.RSymbol]map[string][]client.UserUnlockChunk),
+		UnbondingDurations: make(map[client.RSymbol]uint32),
+		ClaimInfos:         make(map[MintKey][]*client.ClaimInfo),
+		SwapPools:          make(map[client.RSymbol]*client.SwapPool),
+		SwapProviders:      make(map[ProviderKey]*client.SwapLiquidityProvider),
+	}
+}
+
+func (r *RTokens) CurrentChainEra(sym client.RSymbol, _ ...types.Hash) (uint32, error) {
+	v, ok := r.ChainEras[sym]
+	if !ok {
+		return 0, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) ActiveChangeRateLimit(sym client.RSymbol, _ ...types.Hash) (uint32, error) {
+	v, ok := r.ChangeRateLimits[sym]
+	if !ok {
+		return 0, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) RTokenTotalIssuance(sym client.RSymbol, _ ...types.Hash) (types.U128, error) {
+	v, ok := r.TotalIssuance[sym]
+	if !ok {
+		return types.U128{}, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) CurrentEraSnapshots(symbol client.RSymbol, _ ...types.Hash) ([]types.Hash, error) {
+	v, ok := r.Snapshots[symbol]
+	if !ok {
+		return nil, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) GetEraRate(symbol client.RSymbol, era uint32, _ ...types.Hash) (uint64, error) {
+	v, ok := r.EraRates[EraKey{symbol, era}]
+	if !ok {
+		return 0, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) GetReceiver(_ ...types.Hash) (*types.AccountID, error) {
+	if r.Receiver == nil {
+		return nil, client.ErrorValueNotExist
+	}
+	return r.Receiver, nil
+}
+
+func (r *RTokens) GetRFisReceiver(_ ...types.Hash) (*types.AccountID, error) {
+	if r.RFisReceiver == nil {
+		return nil, client.ErrorValueNotExist
+	}
+	return r.RFisReceiver, nil
+}
+
+func (r *RTokens) ActLatestCycle(sym client.RSymbol, _ ...types.Hash) (uint32, error) {
+	v, ok := r.ActLatestCycles[sym]
+	if !ok {
+		return 0, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) Act(sym client.RSymbol, cycle uint32, _ ...types.Hash) (*client.MintRewardAct, error) {
+	v, ok := r.Acts[ActKey{sym, cycle}]
+	if !ok {
+		return nil, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) REthActLatestCycle(_ ...types.Hash) (uint32, error) {
+	if r.REthLatestCycle == 0 {
+		return 0, client.ErrorValueNotExist
+	}
+	return r.REthLatestCycle, nil
+}
+
+func (r *RTokens) GetREthCurrentCycle(_ ...types.Hash) (uint32, error) {
+	if r.REthCurrentCycle == 0 {
+		return 0, client.ErrorValueNotExist
+	}
+	return r.REthCurrentCycle, nil
+}
+
+func (r *RTokens) RethAct(cycle uint32, _ ...types.Hash) (*client.MintRewardAct, error) {
+	v, ok := r.REthActs[cycle]
+	if !ok {
+		return nil, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) MintTxHashExist(txHash types.Bytes, _ ...types.Hash) (bool, error) {
+	return r.MintTxHashes[types.HexEncodeToString(txHash)], nil
+}
+
+func (r *RTokens) Snapshot(symbol client.RSymbol, shotId types.Hash, _ ...types.Hash) (*client.BondSnapshot, error) {
+	v, ok := r.BondSnapshots[ShotKey{symbol, shotId}]
+	if !ok {
+		return nil, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) BondedPools(symbol client.RSymbol, _ ...types.Hash) ([]types.Bytes, error) {
+	v, ok := r.Pools[symbol]
+	if !ok {
+		return nil, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) PoolUnbonds(symbol client.RSymbol, pool []byte, era uint32, _ ...types.Hash) ([]client.Unbonding, error) {
+	v, ok := r.Unbonds[UnbondKey{symbol, hex.EncodeToString(pool), era}]
+	if !ok {
+		return nil, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) SubAccounts(symbol client.RSymbol, pool []byte, _ ...types.Hash) ([]types.Bytes, error) {
+	v, ok := r.SubAccountsOf[PoolKey{symbol, hex.EncodeToString(pool)}]
+	if !ok {
+		return nil, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) MultiThreshold(symbol client.RSymbol, pool []byte, _ ...types.Hash) (uint16, error) {
+	v, ok := r.MultiThresholds[PoolKey{symbol, hex.EncodeToString(pool)}]
+	if !ok {
+		return 0, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) AccountUnbonds(symbol client.RSymbol, who types.AccountID, _ ...types.Hash) ([]client.UserUnlockChunk, error) {
+	accounts, ok := r.AccountUnbondsOf[symbol]
+	if !ok {
+		return nil, client.ErrorStorageNotFound
+	}
+	v, ok := accounts[types.HexEncodeToString(who[:])]
+	if !ok {
+		return nil, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) UnbondingDuration(symbol client.RSymbol, _ ...types.Hash) (uint32, error) {
+	v, ok := r.UnbondingDurations[symbol]
+	if !ok {
+		return 0, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) UserMintsCount(who types.AccountID, symbol client.RSymbol, cycle uint32, _ ...types.Hash) (uint64, error) {
+	return uint64(len(r.ClaimInfos[MintKey{types.HexEncodeToString(who[:]), symbol, cycle}])), nil
+}
+
+func (r *RTokens) ClaimInfo(who types.AccountID, symbol client.RSymbol, cycle uint32, index uint64, _ ...types.Hash) (*client.ClaimInfo, error) {
+	infos := r.ClaimInfos[MintKey{types.HexEncodeToString(who[:]), symbol, cycle}]
+	if index >= uint64(len(infos)) {
+		return nil, client.ErrorValueNotExist
+	}
+	return infos[index], nil
+}
+
+func (r *RTokens) SwapPool(symbol client.RSymbol, _ ...types.Hash) (*client.SwapPool, error) {
+	v, ok := r.SwapPools[symbol]
+	if !ok {
+		return nil, client.ErrorValueNotExist
+	}
+	return v, nil
+}
+
+func (r *RTokens) SwapLiquidityProvider(who types.AccountID, symbol client.RSymbol, _ ...types.Hash) (*client.SwapLiquidityProvider, error) {
+	v, ok := r.SwapProviders[ProviderKey{types.HexEncodeToString(who[:]), symbol}]
+	if !ok {
+		return nil, client.ErrorValueNotExist
+	}
+	return v, nil
+}
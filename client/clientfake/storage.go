@@ -0,0 +1,82 @@
+package clientfake
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+var _ client.StorageReader = (*Storage)(nil)
+
+// Storage holds SCALE encoded storage entries and constants. Block hashes are ignored, every query reads the
+// latest values.
+type Storage struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	consts map[string][]byte
+}
+
+func NewStorage() *Storage {
+	return &Storage{
+		values: make(map[string][]byte),
+		consts: make(map[string][]byte),
+	}
+}
+
+// Set stores the encoding of value under the entry QueryStorage reads with the same prefix, method and args
+func (s *Storage) Set(prefix, method string, arg1, arg2 []byte, value interface{}) error {
+	bz, err := types.EncodeToBytes(value)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[storageKey(prefix, method, arg1, arg2)] = bz
+	return nil
+}
+
+// Delete removes an entry
+func (s *Storage) Delete(prefix, method string, arg1, arg2 []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, storageKey(prefix, method, arg1, arg2))
+}
+
+// SetConst stores the encoding of value as constant name of module prefix
+func (s *Storage) SetConst(prefix, name string, value interface{}) error {
+	bz, err := types.EncodeToBytes(value)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consts[prefix+"."+name] = bz
+	return nil
+}
+
+func (s *Storage) QueryStorage(prefix, method string, arg1, arg2 []byte, result interface{},
+	_ ...types.Hash) (bool, error) {
+	s.mu.Lock()
+	bz, ok := s.values[storageKey(prefix, method, arg1, arg2)]
+	s.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return true, types.DecodeFromBytes(bz, result)
+}
+
+func (s *Storage) GetConst(prefix, name string, res interface{}, _ ...types.Hash) error {
+	s.mu.Lock()
+	bz, ok := s.consts[prefix+"."+name]
+	s.mu.Unlock()
+	if !ok {
+		return client.ErrorValueNotExist
+	}
+	return types.DecodeFromBytes(bz, res)
+}
+
+func storageKey(prefix, method string, arg1, arg2 []byte) string {
+	return prefix + "." + method + "/" + hex.EncodeToString(arg1) + "/" + hex.EncodeToString(arg2)
+}
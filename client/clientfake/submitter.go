@@ -0,0 +1,87 @@
+package clientfake
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/scale"
+)
+
+var _ client.TxSubmitter = (*Submitter)(nil)
+
+// Call is the extrinsic Submitter.NewUnsignedExtrinsic returns
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Submitter records the calls it is asked to submit
+type Submitter struct {
+	// WeightOf returns the weight EstimateWeight reports for a call, 0 if nil
+	WeightOf func(call *Call) uint64
+
+	mu        sync.Mutex
+	submitted []*Call
+	err       error
+}
+
+func NewSubmitter() *Submitter {
+	return &Submitter{}
+}
+
+// FailWith makes the following submissions fail with err, nil lets them succeed again
+func (s *Submitter) FailWith(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Submitted returns the calls submitted successfully, in order
+func (s *Submitter) Submitted() []*Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Call{}, s.submitted...)
+}
+
+func (s *Submitter) NewUnsignedExtrinsic(callMethod string, args ...interface{}) (interface{}, error) {
+	return &Call{Method: callMethod, Args: args}, nil
+}
+
+func (s *Submitter) SignAndSubmitTx(ext interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	call, ok := ext.(*Call)
+	if !ok {
+		return fmt.Errorf("clientfake: unsupported extrinsic %T", ext)
+	}
+	s.submitted = append(s.submitted, call)
+	return nil
+}
+
+func (s *Submitter) EstimateWeight(ext interface{}) (uint64, error) {
+	call, ok := ext.(*Call)
+	if !ok {
+		return 0, fmt.Errorf("clientfake: unsupported extrinsic %T", ext)
+	}
+	if s.WeightOf == nil {
+		return 0, nil
+	}
+	return s.WeightOf(call), nil
+}
+
+// Encode encodes the method and the args, it stands for the encoding of the call
+func (c Call) Encode(encoder scale.Encoder) error {
+	if err := encoder.Encode(c.Method); err != nil {
+		return err
+	}
+	for _, arg := range c.Args {
+		if err := encoder.Encode(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// BlockAuthorIndex returns the index of the block author in the authority set, taken from the babe pre-digest or
+// derived from the aura slot
+func (sc *GsrpcClient) BlockAuthorIndex(header *types.Header, blockHash types.Hash) (uint32, error) {
+	return sc.BlockAuthorIndexContext(context.Background(), header, blockHash)
+}
+
+func (sc *GsrpcClient) BlockAuthorIndexContext(ctx context.Context, header *types.Header, blockHash types.Hash) (uint32, error) {
+	if pre, ok := header.Digest.FindPreRuntime(types.BabeEngineID); ok {
+		babe, err := pre.BabePreDigest()
+		if err != nil {
+			return 0, err
+		}
+		return babe.AuthorityIndex(), nil
+	}
+
+	if pre, ok := header.Digest.FindPreRuntime(types.AuraEngineID); ok {
+		slot, err := pre.AuraSlot()
+		if err != nil {
+			return 0, err
+		}
+		authorities := make([]types.AuthorityID, 0)
+		exist, err := sc.QueryStorageContext(ctx, config.AuraModuleId, config.StorageAuthorities, nil, nil, &authorities, blockHash)
+		if err != nil {
+			return 0, err
+		}
+		if !exist || len(authorities) == 0 {
+			return 0, fmt.Errorf("aura authorities not exist at block: %s", blockHash.Hex())
+		}
+		return uint32(uint64(slot) % uint64(len(authorities))), nil
+	}
+
+	return 0, fmt.Errorf("no babe or aura pre runtime digest in block: %s", blockHash.Hex())
+}
+
+// BlockAuthor resolves the author of the block from its pre-runtime digest and Session.Validators at that block
+func (sc *GsrpcClient) BlockAuthor(blockHash types.Hash) (types.AccountID, error) {
+	return sc.BlockAuthorContext(context.Background(), blockHash)
+}
+
+func (sc *GsrpcClient) BlockAuthorContext(ctx context.Context, blockHash types.Hash) (types.AccountID, error) {
+	header, err := sc.GetHeaderContext(ctx, blockHash)
+	if err != nil {
+		return types.AccountID{}, err
+	}
+
+	index, err := sc.BlockAuthorIndexContext(ctx, header, blockHash)
+	if err != nil {
+		return types.AccountID{}, err
+	}
+
+	validators := make([]types.AccountID, 0)
+	exist, err := sc.QueryStorageContext(ctx, config.SessionModuleId, config.StorageValidators, nil, nil, &validators, blockHash)
+	if err != nil {
+		return types.AccountID{}, err
+	}
+	if !exist {
+		return types.AccountID{}, ErrorValueNotExist
+	}
+	if int(index) >= len(validators) {
+		return types.AccountID{}, fmt.Errorf("authority index %d out of range of %d validators", index, len(validators))
+	}
+
+	return validators[index], nil
+}
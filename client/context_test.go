@@ -0,0 +1,137 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newNodeClient starts a simulated node with the storage of genesis and connects a client to it
+func newNodeClient(t *testing.T, genesis map[string][]byte) (*rpcmocksrv.Node, *client.GsrpcClient) {
+	node, err := rpcmocksrv.NewNode(rpcmocksrv.NodeConfig{Genesis: genesis})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { node.Close() })
+
+	sc, err := client.NewGsrpcClient(client.ChainTypeStafi, node.URL, "", client.AddressTypeAccountId, AliceKey, tlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node, sc
+}
+
+func TestContextVariants(t *testing.T) {
+	meta := types.NewMetadataV13()
+	assert.NoError(t, types.DecodeFromBytes(types.MustHexDecodeString(types.ExamplaryMetadataV13SubstrateString), meta))
+	key, err := types.CreateStorageKey(meta, config.SystemModuleId, config.StorageAccount, AliceKey.PublicKey)
+	assert.NoError(t, err)
+	info := types.AccountInfo{Nonce: 7}
+	info.Data.Free = types.NewU128(*big.NewInt(500))
+	info.Data.Reserved = types.NewU128(*big.NewInt(0))
+	info.Data.MiscFrozen = types.NewU128(*big.NewInt(0))
+	info.Data.FreeFrozen = types.NewU128(*big.NewInt(0))
+	value, err := types.EncodeToBytes(info)
+	assert.NoError(t, err)
+
+	_, sc := newNodeClient(t, map[string][]byte{key.Hex(): value})
+	ctx := context.Background()
+
+	ac, err := sc.AccountInfoContext(ctx, AliceKey.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, types.U32(7), ac.Nonce)
+	free, err := sc.FreeBalanceContext(ctx, AliceKey.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "500", free.String())
+	nonce, err := sc.GetLatestNonceContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, types.U32(7), nonce)
+	var existentialDeposit types.U128
+	assert.NoError(t, sc.GetConstContext(ctx, config.BalancesModuleId, config.ConstExistentialDeposit, &existentialDeposit))
+	assert.Equal(t, "100000000000000", existentialDeposit.String())
+	_, err = sc.NewUnsignedExtrinsicContext(ctx, config.MethodTransfer, types.NewAddressFromAccountID(AliceKey.PublicKey), types.NewUCompactFromUInt(1))
+	assert.NoError(t, err)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	sym := client.RFIS
+	alice := types.NewAccountID(AliceKey.PublicKey)
+	hash, err := sc.GetFinalizedHead()
+	assert.NoError(t, err)
+	variants := map[string]func() error{
+		"AccountInfo": func() error { _, err := sc.AccountInfoContext(canceled, AliceKey.PublicKey); return err },
+		"FreeBalance": func() error { _, err := sc.FreeBalanceContext(canceled, AliceKey.PublicKey); return err },
+		"GetConst": func() error {
+			return sc.GetConstContext(canceled, config.BalancesModuleId, config.ConstExistentialDeposit, &existentialDeposit)
+		},
+		"GetLatestNonce": func() error { _, err := sc.GetLatestNonceContext(canceled); return err },
+		"StakingLedger": func() error {
+			_, err := sc.StakingLedgerContext(canceled, types.NewAccountID(AliceKey.PublicKey))
+			return err
+		},
+		"NewUnsignedExtrinsic": func() error {
+			_, err := sc.NewUnsignedExtrinsicContext(canceled, config.MethodTransfer, types.NewAddressFromAccountID(AliceKey.PublicKey), types.NewUCompactFromUInt(1))
+			return err
+		},
+		"UpdateRethClaimInfo": func() error {
+			return sc.UpdateRethClaimInfoContext(canceled, nil, nil, nil, nil)
+		},
+		"CurrentChainEra":       func() error { _, err := sc.CurrentChainEraContext(canceled, sym); return err },
+		"ActiveChangeRateLimit": func() error { _, err := sc.ActiveChangeRateLimitContext(canceled, sym); return err },
+		"RTokenTotalIssuance":   func() error { _, err := sc.RTokenTotalIssuanceContext(canceled, sym); return err },
+		"CurrentEraSnapshots":   func() error { _, err := sc.CurrentEraSnapshotsContext(canceled, sym); return err },
+		"ActLatestCycle":        func() error { _, err := sc.ActLatestCycleContext(canceled, sym); return err },
+		"REthActLatestCycle":    func() error { _, err := sc.REthActLatestCycleContext(canceled); return err },
+		"Act":                   func() error { _, err := sc.ActContext(canceled, sym, 1); return err },
+		"RethAct":               func() error { _, err := sc.RethActContext(canceled, 1); return err },
+		"GetEraRate":            func() error { _, err := sc.GetEraRateContext(canceled, sym, 1); return err },
+		"GetReceiver":           func() error { _, err := sc.GetReceiverContext(canceled); return err },
+		"GetRFisReceiver":       func() error { _, err := sc.GetRFisReceiverContext(canceled); return err },
+		"GetREthCurrentCycle":   func() error { _, err := sc.GetREthCurrentCycleContext(canceled); return err },
+		"MintTxHashExist":       func() error { _, err := sc.MintTxHashExistContext(canceled, types.Bytes{1}); return err },
+		"CurrentRethNeedSeed":   func() error { _, err := sc.CurrentRethNeedSeedContext(canceled); return err },
+		"CurrentEra":            func() error { _, err := sc.CurrentEraContext(canceled); return err },
+		"GetBlockView":          func() error { _, err := sc.GetBlockViewContext(canceled, hash.Hex()); return err },
+		"GetBlockTimestampAndExtrinsics": func() error {
+			_, _, err := sc.GetBlockTimestampAndExtrinsicsContext(canceled, 0)
+			return err
+		},
+		"FindStorageEntryMetadata": func() error {
+			_, err := sc.FindStorageEntryMetadataContext(canceled, config.SystemModuleId, config.StorageAccount)
+			return err
+		},
+		"VerifyFinality":          func() error { return sc.VerifyFinalityContext(canceled, hash, 0) },
+		"GrandpaAuthorities":      func() error { _, err := sc.GrandpaAuthoritiesContext(canceled, hash); return err },
+		"GetGrandpaJustification": func() error { _, err := sc.GetGrandpaJustificationContext(canceled, hash); return err },
+		"GrandpaCurrentSetId":     func() error { _, err := sc.GrandpaCurrentSetIdContext(canceled); return err },
+		"BlockAuthor":             func() error { _, err := sc.BlockAuthorContext(canceled, hash); return err },
+		"Backfill": func() error {
+			return sc.BackfillContext(canceled, 0, 0, client.BackfillConfig{}, func(*client.BackfillBlock) error { return nil })
+		},
+		"SingleTransferTo": func() error {
+			return sc.SingleTransferToContext(canceled, AliceKey.PublicKey, types.NewUCompactFromUInt(1))
+		},
+		"Snapshot":              func() error { _, err := sc.SnapshotContext(canceled, sym, hash); return err },
+		"BondedPools":           func() error { _, err := sc.BondedPoolsContext(canceled, sym); return err },
+		"PoolUnbonds":           func() error { _, err := sc.PoolUnbondsContext(canceled, sym, []byte{1}, 1); return err },
+		"SubAccounts":           func() error { _, err := sc.SubAccountsContext(canceled, sym, []byte{1}); return err },
+		"MultiThreshold":        func() error { _, err := sc.MultiThresholdContext(canceled, sym, []byte{1}); return err },
+		"AccountUnbonds":        func() error { _, err := sc.AccountUnbondsContext(canceled, sym, alice); return err },
+		"UnbondingDuration":     func() error { _, err := sc.UnbondingDurationContext(canceled, sym); return err },
+		"UserMintsCount":        func() error { _, err := sc.UserMintsCountContext(canceled, alice, sym, 1); return err },
+		"ClaimInfo":             func() error { _, err := sc.ClaimInfoContext(canceled, alice, sym, 1, 0); return err },
+		"SwapPool":              func() error { _, err := sc.SwapPoolContext(canceled, sym); return err },
+		"SwapLiquidityProvider": func() error { _, err := sc.SwapLiquidityProviderContext(canceled, alice, sym); return err },
+	}
+	for name, call := range variants {
+		err := call()
+		assert.True(t, errors.Is(err, context.Canceled), "%s: %v", name, err)
+	}
+}
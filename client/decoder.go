@@ -0,0 +1,343 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	scale "github.com/itering/scale.go"
+	scaleTypes "github.com/itering/scale.go/types"
+	scaleBytes "github.com/itering/scale.go/types/scaleBytes"
+	"github.com/itering/scale.go/utiles"
+	stafi_decoder "github.com/stafiprotocol/go-substrate-rpc-client/pkg/stafidecoder"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	commonTypes "github.com/stafiprotocol/go-substrate-rpc-client/types/common"
+)
+
+// maxStafiDecoderMetadataVersion is the latest metadata version pkg/stafidecoder decodes, later versions are decoded
+// with itering/scale.go
+const maxStafiDecoderMetadataVersion = 12
+
+// metaDecoder decodes extrinsics and events and looks up metadata entries with the metadata of one runtime
+// version. Both implementations produce the same output, newMetaDecoder picks one by metadata version.
+type metaDecoder interface {
+	MetadataVersion() int
+	DecodeExtrinsic(raw string) (*decodedExtrinsic, error)
+	DecodeEvents(raw string) ([]*ChainEvent, error)
+	// ModuleError returns the module and error names, empty if not found
+	ModuleError(moduleIndex, errorIndex int) (string, string)
+	ConstValue(prefix, name string) ([]byte, error)
+	StorageEntry(module, fn string) (types.StorageEntryMetadata, error)
+	CallIndex(call string) (types.CallIndex, error)
+}
+
+// decodedExtrinsic is an extrinsic of a block, signed or not
+type decodedExtrinsic struct {
+	// Hash is set for signed extrinsics, without 0x prefix
+	Hash           string
+	Signed         bool
+	CallModuleName string
+	CallName       string
+	Address        interface{}
+	Params         []commonTypes.ExtrinsicParam
+}
+
+// transaction returns the extrinsic as Transaction, nil if it is not signed
+func (e *decodedExtrinsic) transaction() *Transaction {
+	if e.Hash == "" || !e.Signed {
+		return nil
+	}
+	return &Transaction{
+		ExtrinsicHash:  e.Hash,
+		CallModuleName: e.CallModuleName,
+		CallName:       e.CallName,
+		Address:        e.Address,
+		Params:         e.Params,
+	}
+}
+
+// newMetaDecoder processes raw metadata with the decoder of its version
+func newMetaDecoder(raw []byte) (metaDecoder, error) {
+	if len(raw) < 5 || string(raw[:4]) != "meta" {
+		return nil, fmt.Errorf("not metadata")
+	}
+	if int(raw[4]) <= maxStafiDecoderMetadataVersion {
+		return newStafiMetaDecoder(raw)
+	}
+	return newScaleMetaDecoder(raw)
+}
+
+// catch turns a panic of the decoders, which panic on data they cannot decode, into an error
+func catch(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("decode error: %v", r)
+	}
+}
+
+// eventsFromValue converts the decoded events of either decoder to ChainEvent
+func eventsFromValue(value interface{}) ([]*ChainEvent, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var events []*ChainEvent
+	if err := json.Unmarshal(b, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// stafiMetaDecoder uses pkg/stafidecoder, metadata lookups go through types.Metadata which decodes the same versions
+type stafiMetaDecoder struct {
+	md   *stafi_decoder.MetadataDecoder
+	meta *types.Metadata
+}
+
+func newStafiMetaDecoder(raw []byte) (*stafiMetaDecoder, error) {
+	md := &stafi_decoder.MetadataDecoder{}
+	md.Init(raw)
+	if err := md.Process(); err != nil {
+		return nil, err
+	}
+	meta := &types.Metadata{}
+	if err := types.DecodeFromBytes(raw, meta); err != nil {
+		return nil, err
+	}
+	return &stafiMetaDecoder{md: md, meta: meta}, nil
+}
+
+func (d *stafiMetaDecoder) MetadataVersion() int {
+	return d.md.Metadata.MetadataVersion
+}
+
+func (d *stafiMetaDecoder) DecodeExtrinsic(raw string) (ext *decodedExtrinsic, err error) {
+	defer catch(&err)
+	e := new(stafi_decoder.ExtrinsicDecoder)
+	option := stafi_decoder.ScaleDecoderOption{Metadata: &d.md.Metadata, Spec: d.md.Spec}
+	e.Init(stafi_decoder.ScaleBytes{Data: utiles.HexToBytes(raw)}, &option)
+	e.Process()
+	return &decodedExtrinsic{
+		Hash:           e.ExtrinsicHash,
+		Signed:         e.ContainsTransaction,
+		CallModuleName: e.CallModule.Name,
+		CallName:       e.Call.Name,
+		Address:        e.Address,
+		Params:         e.Params,
+	}, nil
+}
+
+func (d *stafiMetaDecoder) DecodeEvents(raw string) (events []*ChainEvent, err error) {
+	defer catch(&err)
+	e := stafi_decoder.EventsDecoder{}
+	option := stafi_decoder.ScaleDecoderOption{Metadata: &d.md.Metadata}
+	e.Init(stafi_decoder.ScaleBytes{Data: utiles.HexToBytes(raw)}, &option)
+	e.Process()
+	return eventsFromValue(e.Value)
+}
+
+func (d *stafiMetaDecoder) ModuleError(moduleIndex, errorIndex int) (string, string) {
+	for _, mod := range d.md.Metadata.Metadata.Modules {
+		if mod.Index != moduleIndex {
+			continue
+		}
+		if errorIndex < len(mod.Errors) {
+			return mod.Name, mod.Errors[errorIndex].Name
+		}
+		return mod.Name, ""
+	}
+	return "", ""
+}
+
+func (d *stafiMetaDecoder) ConstValue(prefix, name string) ([]byte, error) {
+	return d.meta.FindConstantValue(prefix, name)
+}
+
+func (d *stafiMetaDecoder) StorageEntry(module, fn string) (types.StorageEntryMetadata, error) {
+	return d.meta.FindStorageEntryMetadata(module, fn)
+}
+
+func (d *stafiMetaDecoder) CallIndex(call string) (types.CallIndex, error) {
+	return d.meta.FindCallIndex(call)
+}
+
+// scaleMetaDecoder uses itering/scale.go
+type scaleMetaDecoder struct {
+	md *scale.MetadataDecoder
+}
+
+func newScaleMetaDecoder(raw []byte) (*scaleMetaDecoder, error) {
+	md := &scale.MetadataDecoder{}
+	md.Init(raw)
+	if err := md.Process(); err != nil {
+		return nil, err
+	}
+	return &scaleMetaDecoder{md: md}, nil
+}
+
+func (d *scaleMetaDecoder) MetadataVersion() int {
+	return d.md.Metadata.MetadataVersion
+}
+
+func (d *scaleMetaDecoder) DecodeExtrinsic(raw string) (ext *decodedExtrinsic, err error) {
+	defer catch(&err)
+	e := new(scale.ExtrinsicDecoder)
+	option := scaleTypes.ScaleDecoderOption{Metadata: &d.md.Metadata, Spec: d.md.Spec}
+	e.Init(scaleBytes.ScaleBytes{Data: utiles.HexToBytes(raw)}, &option)
+	e.Process()
+
+	call, exist := e.Metadata.CallIndex[e.CallIndex]
+	if !exist {
+		return nil, fmt.Errorf("callIndex: %s not exist metaData", e.CallIndex)
+	}
+	params := make([]commonTypes.ExtrinsicParam, 0, len(e.Params))
+	for _, p := range e.Params {
+		params = append(params, commonTypes.ExtrinsicParam{
+			Name:  p.Name,
+			Type:  p.Type,
+			Value: p.Value,
+		})
+	}
+	return &decodedExtrinsic{
+		Hash:           e.ExtrinsicHash,
+		Signed:         e.ContainsTransaction,
+		CallModuleName: call.Module.Name,
+		CallName:       call.Call.Name,
+		Address:        e.Address,
+		Params:         params,
+	}, nil
+}
+
+func (d *scaleMetaDecoder) DecodeEvents(raw string) (events []*ChainEvent, err error) {
+	defer catch(&err)
+	e := scale.EventsDecoder{}
+	option := scaleTypes.ScaleDecoderOption{Metadata: &d.md.Metadata}
+	e.Init(scaleBytes.ScaleBytes{Data: utiles.HexToBytes(raw)}, &option)
+	e.Process()
+	return eventsFromValue(e.Value)
+}
+
+func (d *scaleMetaDecoder) ModuleError(moduleIndex, errorIndex int) (string, string) {
+	for _, mod := range d.md.Metadata.Metadata.Modules {
+		if mod.Index != moduleIndex {
+			continue
+		}
+		if errorIndex < len(mod.Errors) {
+			return mod.Name, mod.Errors[errorIndex].Name
+		}
+		return mod.Name, ""
+	}
+	return "", ""
+}
+
+func (d *scaleMetaDecoder) ConstValue(prefix, name string) ([]byte, error) {
+	for _, mod := range d.md.Metadata.Metadata.Modules {
+		if string(mod.Prefix) == prefix {
+			for _, cons := range mod.Constants {
+				if cons.Name == name {
+					return types.HexDecodeString(cons.ConstantsValue)
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("could not find constant %s.%s", prefix, name)
+}
+
+func (d *scaleMetaDecoder) StorageEntry(module, fn string) (types.StorageEntryMetadata, error) {
+	for _, mod := range d.md.Metadata.Metadata.Modules {
+		if string(mod.Prefix) != module {
+			continue
+		}
+		for _, s := range mod.Storage {
+			if string(s.Name) != fn {
+				continue
+			}
+
+			sfm := types.StorageFunctionMetadataV13{
+				Name: types.Text(s.Name),
+			}
+
+			if s.Type.PlainType != nil {
+				sfm.Type = types.StorageFunctionTypeV13{
+					IsType: true,
+					AsType: types.Type(*s.Type.PlainType),
+				}
+			}
+
+			if s.Type.DoubleMapType != nil {
+				dmt := types.DoubleMapTypeV10{
+					Key1:       types.Type(s.Type.DoubleMapType.Key),
+					Key2:       types.Type(s.Type.DoubleMapType.Key2),
+					Value:      types.Type(s.Type.DoubleMapType.Value),
+					Hasher:     TransformHasher(s.Type.DoubleMapType.Hasher),
+					Key2Hasher: TransformHasher(s.Type.DoubleMapType.Key2Hasher),
+				}
+
+				sfm.Type = types.StorageFunctionTypeV13{
+					IsDoubleMap: true,
+					AsDoubleMap: dmt,
+				}
+			}
+
+			if s.Type.MapType != nil {
+				mt := types.MapTypeV10{
+					Key:    types.Type(s.Type.MapType.Key),
+					Value:  types.Type(s.Type.MapType.Value),
+					Linked: s.Type.MapType.IsLinked,
+					Hasher: TransformHasher(s.Type.MapType.Hasher),
+				}
+
+				sfm.Type = types.StorageFunctionTypeV13{
+					IsMap: true,
+					AsMap: mt,
+				}
+			}
+
+			if s.Type.NMapType != nil {
+				keys := make([]types.Type, 0)
+				for _, key := range s.Type.NMapType.KeyVec {
+					keys = append(keys, types.Type(key))
+				}
+
+				hashers := make([]types.StorageHasherV10, 0)
+				for _, hasher := range s.Type.NMapType.Hashers {
+					hashers = append(hashers, TransformHasher(hasher))
+				}
+
+				nmt := types.NMapTypeV13{
+					Keys:    keys,
+					Hashers: hashers,
+					Value:   types.Type(s.Type.NMapType.Value),
+				}
+
+				sfm.Type = types.StorageFunctionTypeV13{
+					IsNMap: true,
+					AsNMap: nmt,
+				}
+			}
+
+			return sfm, nil
+		}
+		return nil, fmt.Errorf("storage %v not found within module %v", fn, module)
+	}
+	return nil, fmt.Errorf("module %v not found in metadata", module)
+}
+
+func (d *scaleMetaDecoder) CallIndex(call string) (types.CallIndex, error) {
+	s := strings.Split(call, ".")
+	if len(s) != 2 {
+		return types.CallIndex{}, fmt.Errorf("invalid call %v", call)
+	}
+
+	for _, mod := range d.md.Metadata.Metadata.Modules {
+		if string(mod.Name) != s[0] {
+			continue
+		}
+		for ci, f := range mod.Calls {
+			if string(f.Name) == s[1] {
+				return types.CallIndex{SectionIndex: uint8(mod.Index), MethodIndex: uint8(ci)}, nil
+			}
+		}
+		return types.CallIndex{}, fmt.Errorf("method %v not found within module %v for call %v", s[1], mod.Name, call)
+	}
+	return types.CallIndex{}, fmt.Errorf("module %v not found in metadata for call %v", s[0], call)
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"testing"
+
+	stafi_decoder "github.com/stafiprotocol/go-substrate-rpc-client/pkg/stafidecoder"
+	"github.com/stafiprotocol/go-substrate-rpc-client/signature"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMetaDecoder(t *testing.T) {
+	stafi_decoder.RuntimeType{}.Reg()
+
+	for _, tc := range []struct {
+		metadata string
+		version  int
+		decoder  interface{}
+	}{
+		{types.ExamplaryMetadataV12PolkadotString, 12, &stafiMetaDecoder{}},
+		{types.ExamplaryMetadataV13SubstrateString, 13, &scaleMetaDecoder{}},
+	} {
+		raw := types.MustHexDecodeString(tc.metadata)
+		md, err := newMetaDecoder(raw)
+		assert.NoError(t, err)
+		assert.IsType(t, tc.decoder, md)
+		assert.Equal(t, tc.version, md.MetadataVersion())
+
+		meta := &types.Metadata{}
+		assert.NoError(t, types.DecodeFromBytes(raw, meta))
+
+		ci, err := md.CallIndex("Balances.transfer")
+		assert.NoError(t, err)
+		expected, err := meta.FindCallIndex("Balances.transfer")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, ci)
+
+		var deposit types.U128
+		value, err := md.ConstValue("Balances", "ExistentialDeposit")
+		assert.NoError(t, err)
+		assert.NoError(t, types.DecodeFromBytes(value, &deposit))
+		assert.NotZero(t, deposit.Int64())
+
+		entry, err := md.StorageEntry("System", "Account")
+		assert.NoError(t, err)
+		assert.True(t, entry.IsMap())
+
+		bob, err := types.NewAddressFromHexAccountID("0x8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48")
+		assert.NoError(t, err)
+		call, err := types.NewCall(meta, "Balances.transfer", bob, types.NewUCompactFromUInt(12345))
+		assert.NoError(t, err)
+		ext := types.NewExtrinsic(call)
+		assert.NoError(t, ext.Sign(signature.TestKeyringPairAlice, types.SignatureOptions{}))
+		hex, err := types.EncodeToHexString(ext)
+		assert.NoError(t, err)
+
+		decoded, err := md.DecodeExtrinsic(hex)
+		assert.NoError(t, err)
+		tx := decoded.transaction()
+		if assert.NotNil(t, tx) {
+			assert.Equal(t, "Balances", tx.CallModuleName)
+			assert.Equal(t, "transfer", tx.CallName)
+			assert.Len(t, tx.Params, 2)
+		}
+
+		_, err = md.DecodeExtrinsic("0x1234")
+		assert.Error(t, err)
+	}
+
+	_, err := newMetaDecoder([]byte("not metadata"))
+	assert.Error(t, err)
+}
+
+func TestMetaDecoders_DecodeEvents(t *testing.T) {
+	stafi_decoder.RuntimeType{}.Reg()
+	raw := types.MustHexDecodeString(types.ExamplaryMetadataV12PolkadotString)
+	stafi, err := newStafiMetaDecoder(raw)
+	assert.NoError(t, err)
+	scale, err := newScaleMetaDecoder(raw)
+	assert.NoError(t, err)
+
+	alice := "d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"
+	bob := "8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48"
+	// Balances.Transfer and System.ExtrinsicSuccess of extrinsic 0, System.NewAccount of extrinsic 1
+	blob := "0x0c" +
+		"00" + "00000000" + "0602" + alice + bob + "39300000000000000000000000000000" + "00" +
+		"00" + "00000000" + "0000" + "e8030000" + "00" + "00" + "00" +
+		"00" + "01000000" + "0003" + alice + "00"
+
+	expected, err := stafi.DecodeEvents(blob)
+	assert.NoError(t, err)
+	if assert.Len(t, expected, 3) {
+		assert.Equal(t, "Balances", expected[0].ModuleId)
+		assert.Equal(t, "Transfer", expected[0].EventId)
+		assert.Equal(t, "12345", expected[0].Params[2].Value)
+		assert.Equal(t, "ExtrinsicSuccess", expected[1].EventId)
+		assert.Equal(t, 1, expected[2].ExtrinsicIndex)
+	}
+	events, err := scale.DecodeEvents(blob)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, events)
+
+	_, err = scale.DecodeEvents("0x04ff")
+	assert.Error(t, err)
+}
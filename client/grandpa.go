@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// well-known storage key of the versioned grandpa authority list
+const grandpaAuthoritiesKey = ":grandpa_authorities"
+
+func (sc *GsrpcClient) GrandpaCurrentSetId(blockHash ...types.Hash) (uint64, error) {
+	return sc.GrandpaCurrentSetIdContext(context.Background(), blockHash...)
+}
+
+func (sc *GsrpcClient) GrandpaCurrentSetIdContext(ctx context.Context, blockHash ...types.Hash) (uint64, error) {
+	var setId types.U64
+	exist, err := sc.QueryStorageContext(ctx, config.GrandpaModuleId, config.StorageCurrentSetId, nil, nil, &setId, blockHash...)
+	if err != nil {
+		return 0, err
+	}
+	if !exist {
+		return 0, ErrorValueNotExist
+	}
+	return uint64(setId), nil
+}
+
+func (sc *GsrpcClient) GrandpaAuthorities(blockHash types.Hash) (types.GrandpaAuthorityList, error) {
+	return sc.GrandpaAuthoritiesContext(context.Background(), blockHash)
+}
+
+func (sc *GsrpcClient) GrandpaAuthoritiesContext(ctx context.Context, blockHash types.Hash) (types.GrandpaAuthorityList, error) {
+	api, err := sc.FlashApi()
+	if err != nil {
+		return nil, err
+	}
+
+	list := new(types.VersionedGrandpaAuthorityList)
+	exist, err := api.State.GetStorageContext(ctx, types.NewStorageKey([]byte(grandpaAuthoritiesKey)), list, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, ErrorValueNotExist
+	}
+	return list.AuthorityList, nil
+}
+
+func (sc *GsrpcClient) GetGrandpaJustification(blockHash types.Hash) (*types.GrandpaJustification, error) {
+	return sc.GetGrandpaJustificationContext(context.Background(), blockHash)
+}
+
+func (sc *GsrpcClient) GetGrandpaJustificationContext(ctx context.Context, blockHash types.Hash) (*types.GrandpaJustification, error) {
+	api, err := sc.FlashApi()
+	if err != nil {
+		return nil, err
+	}
+	blk, err := api.Chain.GetBlockContext(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := blk.GrandpaJustification()
+	if !ok {
+		return nil, ErrorValueNotExist
+	}
+
+	j, err := types.NewGrandpaJustification(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// VerifyFinality checks that the grandpa justification of blockHash commits to the block and is signed by the
+// authority set that was active before the block with the given set id, without trusting the finality reported by the
+// rpc node
+func (sc *GsrpcClient) VerifyFinality(blockHash types.Hash, setId uint64) error {
+	return sc.VerifyFinalityContext(context.Background(), blockHash, setId)
+}
+
+func (sc *GsrpcClient) VerifyFinalityContext(ctx context.Context, blockHash types.Hash, setId uint64) error {
+	j, err := sc.GetGrandpaJustificationContext(ctx, blockHash)
+	if err != nil {
+		return err
+	}
+	header, err := sc.GetHeaderContext(ctx, blockHash)
+	if err != nil {
+		return err
+	}
+	if j.Commit.TargetHash != blockHash || uint64(j.Commit.TargetNumber) != uint64(header.Number) {
+		return fmt.Errorf("justification commits to block %d %s, not to block %d %s", j.Commit.TargetNumber,
+			j.Commit.TargetHash.Hex(), header.Number, blockHash.Hex())
+	}
+	authorities, err := sc.GrandpaAuthoritiesContext(ctx, header.ParentHash)
+	if err != nil {
+		return err
+	}
+	return j.Verify(authorities, setId)
+}
@@ -0,0 +1,69 @@
+package client_test
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// justify returns a justification of the target signed by all but the last of keys in round 1 of set setId
+func justify(t *testing.T, keys []ed25519.PrivateKey, target types.Hash, number uint32, setId uint64) []byte {
+	precommit := types.GrandpaPrecommit{TargetHash: target, TargetNumber: types.U32(number)}
+	payload, err := precommit.SigningPayload(1, setId)
+	assert.NoError(t, err)
+	j := types.GrandpaJustification{Round: 1, Commit: types.GrandpaCommit{TargetHash: target, TargetNumber: types.U32(number)}}
+	for _, key := range keys[:len(keys)-1] {
+		var id [32]byte
+		copy(id[:], key.Public().(ed25519.PublicKey))
+		j.Commit.Precommits = append(j.Commit.Precommits, types.GrandpaSignedPrecommit{
+			Precommit: precommit, Signature: types.NewSignature(ed25519.Sign(key, payload)), ID: types.NewAuthorityID(id)})
+	}
+	bz, err := types.EncodeToBytes(j)
+	assert.NoError(t, err)
+	return bz
+}
+
+func TestVerifyFinality(t *testing.T) {
+	keys := make([]ed25519.PrivateKey, 4)
+	authorities := types.VersionedGrandpaAuthorityList{Version: 1}
+	for i := range keys {
+		seed := make([]byte, ed25519.SeedSize)
+		seed[0] = byte(i + 1)
+		keys[i] = ed25519.NewKeyFromSeed(seed)
+		var id [32]byte
+		copy(id[:], keys[i].Public().(ed25519.PublicKey))
+		authorities.AuthorityList = append(authorities.AuthorityList, types.GrandpaAuthority{ID: types.NewAuthorityID(id), Weight: 1})
+	}
+	node, err := rpcmocksrv.NewNode(rpcmocksrv.NodeConfig{Genesis: map[string][]byte{
+		types.NewStorageKey([]byte(":grandpa_authorities")).Hex(): mustEncode(t, authorities),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+	sc, err := client.NewGsrpcClient(client.ChainTypeStafi, node.URL, "", client.AddressTypeAccountId, AliceKey, tlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := node.ProduceBlock()
+	second := node.ProduceBlock()
+	assert.Equal(t, client.ErrorValueNotExist, sc.VerifyFinality(first, 0))
+
+	assert.NoError(t, node.Justify(first, justify(t, keys, first, 1, 0)))
+	assert.NoError(t, sc.VerifyFinality(first, 0))
+	assert.Error(t, sc.VerifyFinality(first, 1))
+
+	// a valid justification of another block does not finalize this one
+	assert.NoError(t, node.Justify(second, justify(t, keys, first, 1, 0)))
+	assert.EqualError(t, sc.VerifyFinality(second, 0),
+		"justification commits to block 1 "+first.Hex()+", not to block 2 "+second.Hex())
+	assert.NoError(t, node.Justify(second, justify(t, keys, second, 1, 0)))
+	assert.ErrorContains(t, sc.VerifyFinality(second, 0), "justification commits to block 1")
+	assert.NoError(t, node.Justify(second, justify(t, keys, second, 2, 0)))
+	assert.NoError(t, sc.VerifyFinality(second, 0))
+}
@@ -1,32 +1,34 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"sync"
 	"time"
 
-	scale "github.com/itering/scale.go"
 	"github.com/itering/scale.go/source"
 	scaleTypes "github.com/itering/scale.go/types"
 	"github.com/itering/scale.go/utiles"
 	"github.com/itering/substrate-api-rpc/model"
 	"github.com/itering/substrate-api-rpc/rpc"
 	gsrpcConfig "github.com/stafiprotocol/go-substrate-rpc-client/config"
-	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/recws"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/stafidecoder"
-	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/websocketpool"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/wsmux"
 	gsrpc "github.com/stafiprotocol/go-substrate-rpc-client/rpc"
 	"github.com/stafiprotocol/go-substrate-rpc-client/signature"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 const (
-	wsId       = 1
+	wsId       = 1 // replaced by a unique id in wsmux
 	storageKey = "0x26aa394eea5630e07c48ae0c9558cef780d41e5e16056765bc8461851072c9d7"
 )
 
+// The chain type passed to the constructors is kept for compatibility, extrinsics and events are decoded by the
+// metadata version of each runtime whatever the chain
 const (
 	ChainTypeStafi    = "stafi"
 	ChainTypePolkadot = "polkadot"
@@ -40,42 +42,101 @@ var (
 	ErrorBondEqualToUnbond    = errors.New("ErrorBondEqualToUnbond")
 	ErrorDiffSmallerThanLeast = errors.New("ErrorDiffSmallerThanLeast")
 	ErrorValueNotExist        = errors.New("value not exist")
+	ErrorNoEndpoint           = errors.New("client has no endpoint")
+	ErrorStorageNotFound      = errors.New("storage not found in metadata")
+	ErrorReorgTooDeep         = errors.New("reorg deeper than the remembered blocks")
 )
 
 type GsrpcClient struct {
 	endpoint    string
 	addressType string
 	rpcs        *gsrpc.RPCS
+	cfg         gsrpcConfig.Config
 	key         *signature.KeyringPair
 	genesisHash types.Hash
 
-	wsPool    websocket_pool.Pool
+	// multi is set when the client was created with several endpoints
+	multi     *client.MultiClient
+	wsClients map[string]*wsmux.Client
 	log       Logger
-	chainType string
 	typesPath string
 
 	currentSpecVersion int
 
-	stafiMetaDecoderMap map[int]*stafi_decoder.MetadataDecoder
-	polkaMetaDecoderMap map[int]*scale.MetadataDecoder
+	// metaDecoders by spec version
+	metaDecoders map[int]metaDecoder
+	rSymbols     *RSymbolRegistry
+	proxyTypes   *ProxyTypeRegistry
 	sync.RWMutex
 
 	metaDataVersion int
 }
 
+// DefaultConfig returns the configuration NewGsrpcClient uses, the defaults of the config package with a subscribe
+// timeout of 2 minutes
+func DefaultConfig() gsrpcConfig.Config {
+	cfg := gsrpcConfig.Default()
+	cfg.SubscribeTimeout = 2 * time.Minute
+	return cfg
+}
+
+// NewGsrpcClient connects to endpoint. The custom types at typesPath, the Stafi types if empty, are registered with
+// both decoders.
 func NewGsrpcClient(chainType, endpoint, typesPath, addressType string, key *signature.KeyringPair, log Logger) (*GsrpcClient, error) {
+	return NewGsrpcClientWithConfig(chainType, endpoint, typesPath, addressType, key, log, DefaultConfig())
+}
+
+// NewGsrpcClientWithConfig is NewGsrpcClient with the timeouts of cfg, other clients are not affected by them. Zero
+// timeouts and intervals default to those of DefaultConfig()
+func NewGsrpcClientWithConfig(chainType, endpoint, typesPath, addressType string, key *signature.KeyringPair, log Logger, cfg gsrpcConfig.Config) (*GsrpcClient, error) {
+	cfg = cfg.WithDefaults(DefaultConfig())
 	log.Info("Connecting to substrate chain with gsrpc client", "endpoint", endpoint)
 
 	if addressType != AddressTypeAccountId && addressType != AddressTypeMultiAddress {
 		return nil, errors.New("addressType not supported")
 	}
 
-	rpcs, err := gsrpc.NewRPCS(endpoint)
+	rpcs, err := gsrpc.NewRPCSWithConfig(endpoint, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGsrpcClient(endpoint, typesPath, addressType, rpcs, cfg, key, log)
+}
+
+// NewGsrpcClientWithEndpoints creates a client over several endpoints. Queries go to the healthy endpoints and fail
+// over between them, subscriptions and the websocket requests follow the active endpoint. Zero timeouts and intervals
+// of multiCfg.Client default to those of DefaultConfig()
+func NewGsrpcClientWithEndpoints(chainType string, endpoints []string, multiCfg client.MultiConfig, typesPath, addressType string, key *signature.KeyringPair, log Logger) (*GsrpcClient, error) {
+	log.Info("Connecting to substrate chain with gsrpc client", "endpoints", endpoints)
+
+	if addressType != AddressTypeAccountId && addressType != AddressTypeMultiAddress {
+		return nil, errors.New("addressType not supported")
+	}
+
+	multiCfg.Client = multiCfg.Client.WithDefaults(DefaultConfig())
+	onChange := multiCfg.OnEndpointChange
+	multiCfg.OnEndpointChange = func(oldURL, newURL string) {
+		log.Warn("Active endpoint changed", "old", oldURL, "new", newURL)
+		if onChange != nil {
+			onChange(oldURL, newURL)
+		}
+	}
+	multi, err := client.ConnectMulti(endpoints, multiCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sc, err := newGsrpcClient(multi.URL(), typesPath, addressType, gsrpc.NewRPCSWithClient(multi), multiCfg.Client, key, log)
 	if err != nil {
+		multi.Close()
 		return nil, err
 	}
+	sc.multi = multi
+	return sc, nil
+}
 
-	gsrpcConfig.SetSubscribeTimeout(2 * time.Minute)
+func newGsrpcClient(endpoint, typesPath, addressType string, rpcs *gsrpc.RPCS, cfg gsrpcConfig.Config, key *signature.KeyringPair, log Logger) (*GsrpcClient, error) {
 	latestHash, err := rpcs.Chain.GetFinalizedHead()
 	if err != nil {
 		return nil, err
@@ -88,18 +149,19 @@ func NewGsrpcClient(chainType, endpoint, typesPath, addressType string, key *sig
 	}
 
 	sc := &GsrpcClient{
-		endpoint:            endpoint,
-		chainType:           chainType,
-		addressType:         addressType,
-		rpcs:                rpcs,
-		key:                 key,
-		genesisHash:         genesisHash,
-		wsPool:              nil,
-		log:                 log,
-		typesPath:           typesPath,
-		currentSpecVersion:  -1,
-		stafiMetaDecoderMap: make(map[int]*stafi_decoder.MetadataDecoder),
-		polkaMetaDecoderMap: make(map[int]*scale.MetadataDecoder),
+		endpoint:           endpoint,
+		addressType:        addressType,
+		rpcs:               rpcs,
+		cfg:                cfg,
+		key:                key,
+		genesisHash:        genesisHash,
+		wsClients:          make(map[string]*wsmux.Client),
+		log:                log,
+		typesPath:          typesPath,
+		currentSpecVersion: -1,
+		metaDecoders:       make(map[int]metaDecoder),
+		rSymbols:           NewRSymbolRegistry(),
+		proxyTypes:         NewProxyTypeRegistry(),
 	}
 
 	err = sc.regCustomTypes()
@@ -107,28 +169,18 @@ func NewGsrpcClient(chainType, endpoint, typesPath, addressType string, key *sig
 		return nil, err
 	}
 
-	switch chainType {
-	case ChainTypeStafi:
-		_, err := sc.getStafiMetaDecoder(latestHash.Hex())
-		if err != nil {
-			return nil, err
-		}
-	case ChainTypePolkadot:
-		_, err := sc.getPolkaMetaDecoder(latestHash.Hex())
-		if err != nil {
-			return nil, err
-		}
-	default:
-		return nil, fmt.Errorf("chain type not support: %s", chainType)
+	if _, err := sc.getMetaDecoder(context.Background(), latestHash.Hex()); err != nil {
+		return nil, err
 	}
 
 	return sc, nil
 }
 
-func (s *GsrpcClient) getStafiMetaDecoder(blockHash string) (*stafi_decoder.MetadataDecoder, error) {
+// getMetaDecoder returns the decoder for the metadata of the runtime at blockHash
+func (s *GsrpcClient) getMetaDecoder(ctx context.Context, blockHash string) (metaDecoder, error) {
 	v := &model.JsonRpcResult{}
 	// runtime version
-	if err := s.sendWsRequest(v, rpc.ChainGetRuntimeVersion(wsId, blockHash)); err != nil {
+	if err := s.sendWsRequestContext(ctx, v, rpc.ChainGetRuntimeVersion(wsId, blockHash)); err != nil {
 		return nil, err
 	}
 
@@ -137,14 +189,14 @@ func (s *GsrpcClient) getStafiMetaDecoder(blockHash string) (*stafi_decoder.Meta
 		return nil, fmt.Errorf("runtime version nil")
 	}
 	s.RLock()
-	if decoder, exist := s.stafiMetaDecoderMap[r.SpecVersion]; exist {
+	if decoder, exist := s.metaDecoders[r.SpecVersion]; exist {
 		s.RUnlock()
 		return decoder, nil
 	}
 	s.RUnlock()
 
 	// check metadata need update, maybe  get ahead hash
-	if err := s.sendWsRequest(v, rpc.StateGetMetadata(wsId, blockHash)); err != nil {
+	if err := s.sendWsRequestContext(ctx, v, rpc.StateGetMetadata(wsId, blockHash)); err != nil {
 		return nil, err
 	}
 	metaRaw, err := v.ToString()
@@ -152,66 +204,41 @@ func (s *GsrpcClient) getStafiMetaDecoder(blockHash string) (*stafi_decoder.Meta
 		return nil, err
 	}
 
-	md := &stafi_decoder.MetadataDecoder{}
-	md.Init(utiles.HexToBytes(metaRaw))
-	if err := md.Process(); err != nil {
+	raw := utiles.HexToBytes(metaRaw)
+	// the RSymbol variants follow the metadata, variants that contradict the fixed aliases would encode wrong keys
+	if err := s.rSymbols.LoadMetadata(raw); err != nil {
+		return nil, fmt.Errorf("spec version %d: %s", r.SpecVersion, err)
+	}
+	md, err := newMetaDecoder(raw)
+	if err != nil {
 		return nil, err
 	}
 	s.Lock()
-	s.stafiMetaDecoderMap[r.SpecVersion] = md
-	s.Unlock()
-
-	if r.SpecVersion > s.currentSpecVersion {
+	s.metaDecoders[r.SpecVersion] = md
+	latest := r.SpecVersion > s.currentSpecVersion
+	if latest {
 		s.currentSpecVersion = r.SpecVersion
-		s.metaDataVersion = md.Metadata.MetadataVersion
+		s.metaDataVersion = md.MetadataVersion()
 	}
-	return md, nil
-
-}
+	s.Unlock()
 
-func (s *GsrpcClient) getPolkaMetaDecoder(blockHash string) (*scale.MetadataDecoder, error) {
-	v := &model.JsonRpcResult{}
-	// runtime version
-	if err := s.sendWsRequest(v, rpc.ChainGetRuntimeVersion(wsId, blockHash)); err != nil {
-		return nil, err
+	// the ProxyType variants follow the latest runtime
+	if latest {
+		if err := s.proxyTypes.LoadMetadata(raw); err != nil {
+			s.log.Warn("Load ProxyType from metadata failed", "specVersion", r.SpecVersion, "err", err)
+		}
 	}
 
-	r := v.ToRuntimeVersion()
-	if r == nil {
-		return nil, fmt.Errorf("runtime version nil")
-	}
-	s.RLock()
-	if decoder, exist := s.polkaMetaDecoderMap[r.SpecVersion]; exist {
-		s.RUnlock()
-		return decoder, nil
-	}
-	s.RUnlock()
+	return md, nil
+}
 
-	// check metadata need update, maybe  get ahead hash
-	if err := s.sendWsRequest(v, rpc.StateGetMetadata(wsId, blockHash)); err != nil {
-		return nil, err
-	}
-	metaRaw, err := v.ToString()
+// getLatestMetaDecoder returns the decoder of the finalized block's runtime
+func (s *GsrpcClient) getLatestMetaDecoder(ctx context.Context) (metaDecoder, error) {
+	finalized, err := s.GetFinalizedHeadContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	md := scale.MetadataDecoder{}
-	md.Init(utiles.HexToBytes(metaRaw))
-	if err := md.Process(); err != nil {
-		return nil, err
-	}
-	s.Lock()
-	s.polkaMetaDecoderMap[r.SpecVersion] = &md
-	s.Unlock()
-
-	if r.SpecVersion > s.currentSpecVersion {
-		s.currentSpecVersion = r.SpecVersion
-		s.metaDataVersion = md.Metadata.MetadataVersion
-	}
-
-	return &md, nil
-
+	return s.getMetaDecoder(ctx, finalized.Hex())
 }
 
 func (sc *GsrpcClient) regCustomTypes() error {
@@ -224,35 +251,19 @@ func (sc *GsrpcClient) regCustomTypes() error {
 		}
 	}
 
-	switch sc.chainType {
-	case ChainTypeStafi:
-		stafi_decoder.RuntimeType{}.Reg()
-		stafi_decoder.RegCustomTypes(stafi_decoder.LoadTypeRegistry(content))
-	case ChainTypePolkadot:
-		scaleTypes.RegCustomTypes(source.LoadTypeRegistry(content))
-	default:
-		return fmt.Errorf("chainType not supported")
-	}
-	return nil
+	// the decoder is picked per runtime by metadata version, both need the types
+	stafi_decoder.RuntimeType{}.Reg()
+	stafi_decoder.RegCustomTypes(stafi_decoder.LoadTypeRegistry(content))
+	scaleTypes.RegCustomTypes(source.LoadTypeRegistry(content))
+	return sc.rSymbols.LoadTypes(content)
 }
 
-func (sc *GsrpcClient) initial() (*websocket_pool.PoolConn, error) {
-	var err error
-	if sc.wsPool == nil {
-		factory := func() (*websocket_pool.PoolConn, error) {
-			SubscribeConn := &recws.RecConn{KeepAliveTimeout: 2 * time.Minute}
-			SubscribeConn.Dial(sc.endpoint, nil)
-			sc.log.Debug("conn factory create new conn", "endpoint", sc.endpoint)
-			return websocket_pool.WrapConn(SubscribeConn), err
-		}
-		if sc.wsPool, err = websocket_pool.NewWsPool(1, 100, factory); err != nil {
-			sc.log.Error("wbskt.NewChannelPool", "err", err)
-			return nil, err
-		}
-	}
-	if err != nil {
-		return nil, err
-	}
-	conn, err := sc.wsPool.Get()
-	return conn, err
+// RSymbols returns the RSymbol variants of the chain
+func (sc *GsrpcClient) RSymbols() *RSymbolRegistry {
+	return sc.rSymbols
+}
+
+// ProxyTypes returns the ProxyType variants of the chain
+func (sc *GsrpcClient) ProxyTypes() *ProxyTypeRegistry {
+	return sc.proxyTypes
 }
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// HeaderReader reads block headers and numbers
+type HeaderReader interface {
+	GetLatestBlockNumber() (uint64, error)
+	GetFinalizedBlockNumber() (uint64, error)
+	GetHeaderLatest() (*types.Header, error)
+	GetFinalizedHead() (types.Hash, error)
+	GetHeader(blockHash types.Hash) (*types.Header, error)
+	GetBlockNumber(blockHash types.Hash) (uint64, error)
+	GetBlockHash(blockNum uint64) (string, error)
+}
+
+// StorageReader reads storage entries and metadata constants. The storage of the latest block is read if no block
+// hash is given.
+type StorageReader interface {
+	QueryStorage(prefix, method string, arg1, arg2 []byte, result interface{}, blockHash ...types.Hash) (bool, error)
+	GetConst(prefix, name string, res interface{}, blockHash ...types.Hash) error
+}
+
+// EventReader reads the decoded events of a block
+type EventReader interface {
+	GetChainEvents(blockHash string) ([]*ChainEvent, error)
+	GetEvents(blockNum uint64) ([]*ChainEvent, error)
+}
+
+// TxSubmitter builds calls and submits them signed
+type TxSubmitter interface {
+	NewUnsignedExtrinsic(callMethod string, args ...interface{}) (interface{}, error)
+	SignAndSubmitTx(ext interface{}) error
+	// EstimateWeight returns the weight of an extrinsic from its payment info
+	EstimateWeight(ext interface{}) (uint64, error)
+}
+
+// StafiLedgerReader reads the RTokenLedger state of the Stafi chain: eras, pools, snapshots and unbonds, with the
+// account unbonds RTokenSeries keeps. Missing entries return ErrorValueNotExist.
+type StafiLedgerReader interface {
+	CurrentChainEra(sym RSymbol, blockHash ...types.Hash) (uint32, error)
+	ActiveChangeRateLimit(sym RSymbol, blockHash ...types.Hash) (uint32, error)
+	CurrentEraSnapshots(symbol RSymbol, blockHash ...types.Hash) ([]types.Hash, error)
+	GetReceiver(blockHash ...types.Hash) (*types.AccountID, error)
+	GetRFisReceiver(blockHash ...types.Hash) (*types.AccountID, error)
+	Snapshot(symbol RSymbol, shotId types.Hash, blockHash ...types.Hash) (*BondSnapshot, error)
+	BondedPools(symbol RSymbol, blockHash ...types.Hash) ([]types.Bytes, error)
+	PoolUnbonds(symbol RSymbol, pool []byte, era uint32, blockHash ...types.Hash) ([]Unbonding, error)
+	SubAccounts(symbol RSymbol, pool []byte, blockHash ...types.Hash) ([]types.Bytes, error)
+	MultiThreshold(symbol RSymbol, pool []byte, blockHash ...types.Hash) (uint16, error)
+	AccountUnbonds(symbol RSymbol, who types.AccountID, blockHash ...types.Hash) ([]UserUnlockChunk, error)
+	UnbondingDuration(symbol RSymbol, blockHash ...types.Hash) (uint32, error)
+}
+
+// StafiRateReader reads the era rates of RTokenRate and the rToken issuance. Missing entries return
+// ErrorValueNotExist.
+type StafiRateReader interface {
+	GetEraRate(symbol RSymbol, era uint32, blockHash ...types.Hash) (uint64, error)
+	RTokenTotalIssuance(sym RSymbol, blockHash ...types.Hash) (types.U128, error)
+}
+
+// StafiRClaimReader reads the mint reward acts and claims of RClaim. Missing entries return ErrorValueNotExist.
+type StafiRClaimReader interface {
+	ActLatestCycle(sym RSymbol, blockHash ...types.Hash) (uint32, error)
+	Act(sym RSymbol, cycle uint32, blockHash ...types.Hash) (*MintRewardAct, error)
+	REthActLatestCycle(blockHash ...types.Hash) (uint32, error)
+	GetREthCurrentCycle(blockHash ...types.Hash) (uint32, error)
+	RethAct(cycle uint32, blockHash ...types.Hash) (*MintRewardAct, error)
+	MintTxHashExist(txHash types.Bytes, blockHash ...types.Hash) (bool, error)
+	UserMintsCount(who types.AccountID, symbol RSymbol, cycle uint32, blockHash ...types.Hash) (uint64, error)
+	ClaimInfo(who types.AccountID, symbol RSymbol, cycle uint32, index uint64, blockHash ...types.Hash) (*ClaimInfo, error)
+}
+
+// StafiRDexReader reads the swap pools of RDexSwap. Missing entries return ErrorValueNotExist.
+type StafiRDexReader interface {
+	SwapPool(symbol RSymbol, blockHash ...types.Hash) (*SwapPool, error)
+	SwapLiquidityProvider(who types.AccountID, symbol RSymbol, blockHash ...types.Hash) (*SwapLiquidityProvider, error)
+}
+
+// StafiRTokenReader reads the rToken state of all Stafi pallets, code that needs one pallet takes its reader
+type StafiRTokenReader interface {
+	StafiLedgerReader
+	StafiRateReader
+	StafiRClaimReader
+	StafiRDexReader
+}
+
+// Chain is what services built on this package need of a chain. GsrpcClient is a Chain connected to an endpoint,
+// NewChain puts one together from separate pieces, like the fakes of package clientfake.
+type Chain interface {
+	HeaderReader
+	StorageReader
+	EventReader
+	TxSubmitter
+	StafiLedgerReader
+	StafiRateReader
+	StafiRClaimReader
+	StafiRDexReader
+}
+
+var _ Chain = (*GsrpcClient)(nil)
+
+type chain struct {
+	HeaderReader
+	StorageReader
+	EventReader
+	TxSubmitter
+	StafiRTokenReader
+}
+
+// NewChain returns a Chain served by the given pieces. A piece the caller does not use may be nil, calling into a
+// nil piece panics.
+func NewChain(headers HeaderReader, storage StorageReader, events EventReader, submitter TxSubmitter,
+	rTokens StafiRTokenReader) Chain {
+	return &chain{
+		HeaderReader:      headers,
+		StorageReader:     storage,
+		EventReader:       events,
+		TxSubmitter:       submitter,
+		StafiRTokenReader: rTokens,
+	}
+}
@@ -0,0 +1,51 @@
+package client_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// enumMetadataV14 is the start of V14 metadata whose type registry holds the given enums by name, enough to read the
+// enums but not to decode the rest of the metadata
+func enumMetadataV14(t *testing.T, enums map[string]map[uint8]string) []byte {
+	text := func(s string) []byte {
+		return mustEncode(t, types.Text(s))
+	}
+	names := make([]string, 0, len(enums))
+	for name := range enums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	raw := append([]byte("meta"), 14)
+	raw = append(raw, mustEncode(t, types.NewUCompactFromUInt(uint64(len(names))))...)
+	for i, name := range names {
+		// id, path of one segment, no type params, variant def
+		raw = append(raw, byte(i<<2), 1<<2)
+		raw = append(raw, text(name)...)
+		raw = append(raw, 0x00, 0x01, byte(len(enums[name])<<2))
+		for index, variant := range enums[name] {
+			// no fields, index, no docs
+			raw = append(append(raw, text(variant)...), 0x00, index, 0x00)
+		}
+		raw = append(raw, 0x00)
+	}
+	return raw
+}
+
+func TestNewGsrpcClient_RSymbolConflict(t *testing.T) {
+	meta := enumMetadataV14(t, map[string]map[uint8]string{"RSymbol": {0: "RFIS", 1: "RKSM", 2: "RDOT"}})
+	node, err := rpcmocksrv.NewNode(rpcmocksrv.NodeConfig{Metadata: types.HexEncodeToString(meta)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+
+	_, err = client.NewGsrpcClient(client.ChainTypeStafi, node.URL, "", client.AddressTypeAccountId, AliceKey, tlog)
+	assert.EqualError(t, err, "spec version 1: RSymbol variants contradict the fixed aliases: RDOT at 2, RKSM at 1")
+}
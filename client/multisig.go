@@ -0,0 +1,254 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	ErrMultisigThreshold = errors.New("multisig threshold must be between 2 and the number of signatories")
+	ErrNotSignatory      = errors.New("account is not a signatory")
+	ErrAlreadyApproved   = errors.New("account already approved the call")
+)
+
+// multisigPrefix is the prefix of the preimage of multisig account ids in pallet-multisig
+var multisigPrefix = []byte("modlpy/utilisuba")
+
+// MultisigAccount derives the account of the multisig of signatories with threshold, the blake2 256 hash of the
+// prefix, the sorted signatories and the threshold
+func MultisigAccount(signatories []types.AccountID, threshold uint16) (types.AccountID, error) {
+	sorted := sortAccounts(signatories)
+	sigBz, err := types.EncodeToBytes(sorted)
+	if err != nil {
+		return types.AccountID{}, err
+	}
+	thresholdBz, err := types.EncodeToBytes(threshold)
+	if err != nil {
+		return types.AccountID{}, err
+	}
+
+	preimage := append(append(append([]byte{}, multisigPrefix...), sigBz...), thresholdBz...)
+	id := blake2b.Sum256(preimage)
+	return types.NewAccountID(id[:]), nil
+}
+
+// OtherSignatories returns the signatories but self, sorted as the multisig calls expect them
+func OtherSignatories(signatories []types.AccountID, self types.AccountID) []types.AccountID {
+	others := make([]types.AccountID, 0, len(signatories))
+	for _, s := range sortAccounts(signatories) {
+		if s != self {
+			others = append(others, s)
+		}
+	}
+	return others
+}
+
+func sortAccounts(accounts []types.AccountID) []types.AccountID {
+	sorted := append([]types.AccountID{}, accounts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	return sorted
+}
+
+// AsMultiCall is Multisig.as_multi
+type AsMultiCall struct {
+	Threshold        uint16
+	OtherSignatories []types.AccountID
+	MaybeTimepoint   *OptionTimePoint
+	Call             types.Bytes
+	StoreCall        bool
+	MaxWeight        types.Weight
+}
+
+func (c AsMultiCall) Method() string { return config.MethodAsMulti }
+func (c AsMultiCall) Args() []interface{} {
+	return []interface{}{c.Threshold, c.OtherSignatories, c.MaybeTimepoint, c.Call, c.StoreCall, c.MaxWeight}
+}
+
+// ApproveAsMultiCall is Multisig.approve_as_multi
+type ApproveAsMultiCall struct {
+	Threshold        uint16
+	OtherSignatories []types.AccountID
+	MaybeTimepoint   *OptionTimePoint
+	CallHash         types.Hash
+	MaxWeight        types.Weight
+}
+
+func (c ApproveAsMultiCall) Method() string { return config.MethodApproveAsMulti }
+func (c ApproveAsMultiCall) Args() []interface{} {
+	return []interface{}{c.Threshold, c.OtherSignatories, c.MaybeTimepoint, c.CallHash, c.MaxWeight}
+}
+
+// MultisigTx is a call dispatched from the multisig account of signatories once threshold of them approved it.
+// The signatories must be distinct.
+type MultisigTx struct {
+	Signatories []types.AccountID
+	Threshold   uint16
+	Call        CallBuilder
+}
+
+// MultisigApproval is the approval of a multisig call by one signatory
+type MultisigApproval struct {
+	Account  types.AccountID
+	CallHash types.Hash
+	// TimePoint is the block and index of the first approval, none if this is the first one
+	TimePoint *OptionTimePoint
+	// Approvals are the signatories that approved before
+	Approvals []types.AccountID
+	// Final is set if the approval reaches the threshold, Call is as_multi then and dispatches the call
+	Final bool
+	Call  CallBuilder
+}
+
+// MultisigOf reads the pending approvals of callHash by the multisig account multi, ErrorValueNotExist if there are
+// none
+func MultisigOf(s StorageReader, multi types.AccountID, callHash types.Hash, blockHash ...types.Hash) (*Multisig, error) {
+	multiBz, err := types.EncodeToBytes(multi)
+	if err != nil {
+		return nil, err
+	}
+
+	ms := new(Multisig)
+	exists, err := s.QueryStorage(config.MultisigModuleId, config.StorageMultisigs, multiBz, callHash[:], ms, blockHash...)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrorValueNotExist
+	}
+	return ms, nil
+}
+
+// PrepareMultisig returns the approval of tx by self at the latest block. The call is approve_as_multi while the
+// approvals stay below the threshold and as_multi with the weight of tx as max weight for the last one.
+func PrepareMultisig(c Chain, tx *MultisigTx, self types.AccountID) (*MultisigApproval, error) {
+	if tx.Threshold < 2 || int(tx.Threshold) > len(tx.Signatories) {
+		return nil, ErrMultisigThreshold
+	}
+	others := OtherSignatories(tx.Signatories, self)
+	if len(others) == len(tx.Signatories) {
+		return nil, ErrNotSignatory
+	}
+	multi, err := MultisigAccount(tx.Signatories, tx.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	ext, err := NewCallExtrinsic(c, tx.Call)
+	if err != nil {
+		return nil, err
+	}
+	opaque, err := callOpaque(ext)
+	if err != nil {
+		return nil, err
+	}
+	callHash := blake2b.Sum256(opaque)
+	approval := &MultisigApproval{
+		Account:   multi,
+		CallHash:  types.NewHash(callHash[:]),
+		TimePoint: NewOptionTimePointEmpty(),
+		Approvals: []types.AccountID{},
+	}
+
+	ms, err := MultisigOf(c, multi, approval.CallHash)
+	if err != nil && err != ErrorValueNotExist {
+		return nil, fmt.Errorf("multisig: %s", err)
+	}
+	if err == nil {
+		for _, a := range ms.Approvals {
+			if a == self {
+				return nil, ErrAlreadyApproved
+			}
+		}
+		approval.TimePoint = NewOptionTimePoint(ms.When)
+		approval.Approvals = ms.Approvals
+	}
+
+	if len(approval.Approvals)+1 < int(tx.Threshold) {
+		approval.Call = ApproveAsMultiCall{
+			Threshold:        tx.Threshold,
+			OtherSignatories: others,
+			MaybeTimepoint:   approval.TimePoint,
+			CallHash:         approval.CallHash,
+		}
+		return approval, nil
+	}
+
+	weight, err := c.EstimateWeight(ext)
+	if err != nil {
+		return nil, fmt.Errorf("call weight: %s", err)
+	}
+	approval.Final = true
+	approval.Call = AsMultiCall{
+		Threshold:        tx.Threshold,
+		OtherSignatories: others,
+		MaybeTimepoint:   approval.TimePoint,
+		Call:             opaque,
+		MaxWeight:        types.NewWeight(weight),
+	}
+	return approval, nil
+}
+
+// SubmitMultisig prepares the approval of tx by self and submits it
+func SubmitMultisig(c Chain, tx *MultisigTx, self types.AccountID) (*MultisigApproval, error) {
+	approval, err := PrepareMultisig(c, tx, self)
+	if err != nil {
+		return nil, err
+	}
+	ext, err := NewCallExtrinsic(c, approval.Call)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.SignAndSubmitTx(ext); err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// FindMultisigExecuted looks for the MultisigExecuted event of callHash by the multisig account multi in the blocks
+// from fromBlock to toBlock, nil if the call was not executed in them
+func FindMultisigExecuted(c Chain, multi types.AccountID, callHash types.Hash, fromBlock, toBlock uint64) (*EventMultisigExecuted, error) {
+	for number := fromBlock; number <= toBlock; number++ {
+		events, err := c.GetEvents(number)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %s", number, err)
+		}
+		for _, evt := range events {
+			if evt.ModuleId != config.MultisigModuleId || evt.EventId != config.MultisigExecutedEventId {
+				continue
+			}
+			executed, err := EventMultisigExecutedData(evt)
+			if err != nil {
+				return nil, fmt.Errorf("block %d: %s", number, err)
+			}
+			if executed.ID == multi && executed.CallHash == callHash {
+				executed.CallHashStr = callHash.Hex()
+				return executed, nil
+			}
+		}
+		if number == toBlock {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// callOpaque returns the encoded call of ext. Extrinsics other than those of GsrpcClient, like fakes, are encoded
+// whole.
+func callOpaque(ext interface{}) ([]byte, error) {
+	switch e := ext.(type) {
+	case *types.Extrinsic:
+		return types.EncodeToBytes(e.Method)
+	case *types.ExtrinsicMulti:
+		return types.EncodeToBytes(e.Method)
+	default:
+		return types.EncodeToBytes(ext)
+	}
+}
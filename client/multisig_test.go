@@ -0,0 +1,115 @@
+package client_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client/clientfake"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	alice   = types.NewAccountID(types.MustHexDecodeString("0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"))
+	bob     = types.NewAccountID(types.MustHexDecodeString("0x8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48"))
+	charlie = types.NewAccountID(types.MustHexDecodeString("0x90b5ab205c6974c9ea841be688864633dc9ca8a357843eeacf2314649965fe22"))
+)
+
+func TestMultisigAccount(t *testing.T) {
+	// 5DjYJStmdZ2rcqXbXGX7TW85JsrW6uG4y9MUcLq2BoPMpRA7
+	expected := types.NewAccountID(types.MustHexDecodeString("0x49daa32c7287890f38b7e1a8cd2961723d36d20baa0bf3b82e0c4bdda93b1c0a"))
+
+	multi, err := client.MultisigAccount([]types.AccountID{charlie, alice, bob}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, multi)
+
+	multi, err = client.MultisigAccount([]types.AccountID{alice, bob, charlie}, 3)
+	assert.NoError(t, err)
+	assert.NotEqual(t, expected, multi)
+
+	assert.Equal(t, []types.AccountID{bob, charlie}, client.OtherSignatories([]types.AccountID{alice, charlie, bob}, alice))
+}
+
+func TestSubmitMultisig(t *testing.T) {
+	headers := clientfake.NewHeaders()
+	events := clientfake.NewEvents(headers)
+	storage := clientfake.NewStorage()
+	submitter := clientfake.NewSubmitter()
+	submitter.WeightOf = func(*clientfake.Call) uint64 { return 1000 }
+	c := client.NewChain(headers, storage, events, submitter, nil)
+
+	tx := &client.MultisigTx{
+		Signatories: []types.AccountID{alice, bob, charlie},
+		Threshold:   3,
+		Call:        client.ClaimREthRewardCall{Cycle: 1, Index: 2},
+	}
+
+	_, err := client.PrepareMultisig(c, &client.MultisigTx{Signatories: tx.Signatories, Threshold: 1}, alice)
+	assert.Equal(t, client.ErrMultisigThreshold, err)
+	_, err = client.PrepareMultisig(c, tx, types.NewAccountID([]byte{0x01}))
+	assert.Equal(t, client.ErrNotSignatory, err)
+
+	// first approval
+	approval, err := client.SubmitMultisig(c, tx, alice)
+	assert.NoError(t, err)
+	assert.False(t, approval.Final)
+	assert.True(t, approval.TimePoint.IsNone())
+	call, ok := approval.Call.(client.ApproveAsMultiCall)
+	assert.True(t, ok)
+	assert.Equal(t, []types.AccountID{bob, charlie}, call.OtherSignatories)
+	assert.Equal(t, approval.CallHash, call.CallHash)
+	assert.Equal(t, config.MethodApproveAsMulti, submitter.Submitted()[0].Method)
+
+	multiBz, err := types.EncodeToBytes(approval.Account)
+	assert.NoError(t, err)
+	when := types.TimePoint{Height: 5, Index: 1}
+	assert.NoError(t, storage.Set(config.MultisigModuleId, config.StorageMultisigs, multiBz, approval.CallHash[:],
+		&client.Multisig{When: when, Deposit: types.NewU128(*big.NewInt(0)), Depositor: alice, Approvals: []types.AccountID{alice}}))
+
+	_, err = client.PrepareMultisig(c, tx, alice)
+	assert.Equal(t, client.ErrAlreadyApproved, err)
+
+	// second approval, still below the threshold
+	approval, err = client.PrepareMultisig(c, tx, bob)
+	assert.NoError(t, err)
+	assert.False(t, approval.Final)
+	assert.Equal(t, client.NewOptionTimePoint(when), approval.TimePoint)
+
+	// last approval dispatches the call
+	assert.NoError(t, storage.Set(config.MultisigModuleId, config.StorageMultisigs, multiBz, approval.CallHash[:],
+		&client.Multisig{When: when, Deposit: types.NewU128(*big.NewInt(0)), Depositor: alice, Approvals: []types.AccountID{alice, bob}}))
+	approval, err = client.SubmitMultisig(c, tx, charlie)
+	assert.NoError(t, err)
+	assert.True(t, approval.Final)
+	asMulti, ok := approval.Call.(client.AsMultiCall)
+	assert.True(t, ok)
+	assert.Equal(t, types.NewWeight(1000), asMulti.MaxWeight)
+	assert.Equal(t, []types.AccountID{bob, alice}, asMulti.OtherSignatories)
+	assert.NotEmpty(t, asMulti.Call)
+	assert.Equal(t, config.MethodAsMulti, submitter.Submitted()[1].Method)
+
+	// completion
+	blockHash := headers.Push()
+	executed, err := client.FindMultisigExecuted(c, approval.Account, approval.CallHash, 0, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, executed)
+
+	events.Add(blockHash, &client.ChainEvent{
+		ModuleId: config.MultisigModuleId,
+		EventId:  config.MultisigExecutedEventId,
+		Params: params(
+			types.HexEncodeToString(charlie[:]),
+			map[string]interface{}{"height": 5, "index": 1},
+			types.HexEncodeToString(approval.Account[:]),
+			approval.CallHash.Hex(),
+			map[string]interface{}{"Ok": nil},
+		),
+	})
+	executed, err = client.FindMultisigExecuted(c, approval.Account, approval.CallHash, 0, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, executed)
+	assert.True(t, executed.Result)
+	assert.Equal(t, when, executed.TimePoint)
+}
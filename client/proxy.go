@@ -0,0 +1,284 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+// ProxyType is the index of a variant of the ProxyType enum of the runtime
+type ProxyType uint8
+
+// the variants the runtimes of Substrate and Polkadot share
+const (
+	ProxyAny         = ProxyType(0)
+	ProxyNonTransfer = ProxyType(1)
+	ProxyGovernance  = ProxyType(2)
+	ProxyStaking     = ProxyType(3)
+)
+
+// proxyTypeNames are the names of the shared variants
+var proxyTypeNames = map[ProxyType]string{
+	ProxyAny: "Any", ProxyNonTransfer: "NonTransfer", ProxyGovernance: "Governance", ProxyStaking: "Staking",
+}
+
+// String returns the name of a shared variant, "ProxyType(i)" for the others. The ProxyTypeRegistry of the chain
+// names them.
+func (t ProxyType) String() string {
+	if name, exist := proxyTypeNames[t]; exist {
+		return name
+	}
+	return fmt.Sprintf("ProxyType(%d)", uint8(t))
+}
+
+// ProxyTypeRegistry names the ProxyType variants of a chain, it starts with the shared variants and is replaced by
+// the variants of the metadata
+type ProxyTypeRegistry struct {
+	mu      sync.RWMutex
+	byIndex map[ProxyType]string
+	byName  map[string]ProxyType
+}
+
+// NewProxyTypeRegistry returns a registry with the shared variants
+func NewProxyTypeRegistry() *ProxyTypeRegistry {
+	reg := &ProxyTypeRegistry{}
+	reg.Register(proxyTypeNames)
+	return reg
+}
+
+// Register replaces the known variants with the given ones
+func (reg *ProxyTypeRegistry) Register(variants map[ProxyType]string) {
+	byIndex := make(map[ProxyType]string, len(variants))
+	byName := make(map[string]ProxyType, len(variants))
+	for i, name := range variants {
+		byIndex[i] = name
+		byName[name] = i
+	}
+
+	reg.mu.Lock()
+	reg.byIndex = byIndex
+	reg.byName = byName
+	reg.mu.Unlock()
+}
+
+// LoadMetadata registers the variants of the ProxyType enum in the type registry of V14+ metadata. Older metadata has
+// no type registry and leaves the known variants as they are.
+func (reg *ProxyTypeRegistry) LoadMetadata(raw []byte) error {
+	enum, err := types.FindMetadataEnum(raw, "ProxyType")
+	if err == types.ErrNoTypeRegistry {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	variants := make(map[ProxyType]string, len(enum))
+	for i, name := range enum {
+		variants[ProxyType(i)] = name
+	}
+	reg.Register(variants)
+	return nil
+}
+
+// ProxyType returns the variant named name
+func (reg *ProxyTypeRegistry) ProxyType(name string) (ProxyType, error) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	t, exist := reg.byName[name]
+	if !exist {
+		return 0, fmt.Errorf("ProxyType %s not supported", name)
+	}
+	return t, nil
+}
+
+// Name returns the name of the variant, "ProxyType(i)" if unknown
+func (reg *ProxyTypeRegistry) Name(t ProxyType) string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	if name, exist := reg.byIndex[t]; exist {
+		return name
+	}
+	return fmt.Sprintf("ProxyType(%d)", uint8(t))
+}
+
+// ProxyDefinition is a proxy of an account in Proxy.Proxies
+type ProxyDefinition struct {
+	Delegate  types.AccountID
+	ProxyType ProxyType
+	Delay     uint32
+}
+
+// ProxyDefinitions are the proxies of an account and the deposit held for them
+type ProxyDefinitions struct {
+	Definitions []ProxyDefinition
+	Deposit     types.U128
+}
+
+// ProxyAnnouncement is a call announced by a delegate in Proxy.Announcements
+type ProxyAnnouncement struct {
+	Real     types.AccountID
+	CallHash types.Hash
+	Height   uint32
+}
+
+// ProxyAnnouncements are the announcements of a delegate and the deposit held for them
+type ProxyAnnouncements struct {
+	Announcements []ProxyAnnouncement
+	Deposit       types.U128
+}
+
+// Proxies reads the proxies of real, none if it has no proxy
+func Proxies(s StorageReader, real types.AccountID, blockHash ...types.Hash) (*ProxyDefinitions, error) {
+	realBz, err := types.EncodeToBytes(real)
+	if err != nil {
+		return nil, err
+	}
+
+	proxies := &ProxyDefinitions{Definitions: []ProxyDefinition{}}
+	if _, err := s.QueryStorage(config.ProxyModuleId, config.StorageProxies, realBz, nil, proxies, blockHash...); err != nil {
+		return nil, err
+	}
+	return proxies, nil
+}
+
+// Announcements reads the calls announced by delegate, none if it announced nothing
+func Announcements(s StorageReader, delegate types.AccountID, blockHash ...types.Hash) (*ProxyAnnouncements, error) {
+	delegateBz, err := types.EncodeToBytes(delegate)
+	if err != nil {
+		return nil, err
+	}
+
+	announcements := &ProxyAnnouncements{Announcements: []ProxyAnnouncement{}}
+	if _, err := s.QueryStorage(config.ProxyModuleId, config.StorageAnnouncements, delegateBz, nil, announcements, blockHash...); err != nil {
+		return nil, err
+	}
+	return announcements, nil
+}
+
+// pureProxyPrefix is the prefix of the preimage of pure proxy account ids in pallet-proxy
+var pureProxyPrefix = []byte("modlpy/proxy____")
+
+// PureProxyAccount derives the account create_pure spawns, from the spawner, the proxy type and index of the call and
+// the block height and extrinsic index it was included at
+func PureProxyAccount(spawner types.AccountID, proxyType ProxyType, index uint16, height, extIndex uint32) (types.AccountID, error) {
+	bz, err := types.EncodeToBytes(struct {
+		Spawner   types.AccountID
+		Height    uint32
+		ExtIndex  uint32
+		ProxyType ProxyType
+		Index     uint16
+	}{spawner, height, extIndex, proxyType, index})
+	if err != nil {
+		return types.AccountID{}, err
+	}
+
+	id := blake2b.Sum256(append(append([]byte{}, pureProxyPrefix...), bz...))
+	return types.NewAccountID(id[:]), nil
+}
+
+// ProxyCall is Proxy.proxy
+type ProxyCall struct {
+	// Real is a types.AccountID on runtimes whose Proxy pallet takes account ids and the address of the chain,
+	// types.Address or types.MultiAddress, on those taking lookup sources. So are the addresses of the other calls.
+	Real           interface{}
+	ForceProxyType *ProxyType
+	// Call is the encoded call dispatched as Real
+	Call types.BytesBare
+}
+
+// NewProxyCall returns the proxy call dispatching call as real
+func NewProxyCall(tx TxSubmitter, real interface{}, forceProxyType *ProxyType, call CallBuilder) (ProxyCall, error) {
+	bz, err := encodeCall(tx, call)
+	if err != nil {
+		return ProxyCall{}, err
+	}
+	return ProxyCall{Real: real, ForceProxyType: forceProxyType, Call: bz}, nil
+}
+
+func (c ProxyCall) Method() string { return config.MethodProxy }
+func (c ProxyCall) Args() []interface{} {
+	return []interface{}{c.Real, optionProxyType(c.ForceProxyType), c.Call}
+}
+
+// ProxyAnnouncedCall is Proxy.proxy_announced
+type ProxyAnnouncedCall struct {
+	Delegate       interface{}
+	Real           interface{}
+	ForceProxyType *ProxyType
+	Call           types.BytesBare
+}
+
+// NewProxyAnnouncedCall returns the call dispatching call as real, as announced by delegate
+func NewProxyAnnouncedCall(tx TxSubmitter, delegate, real interface{}, forceProxyType *ProxyType, call CallBuilder) (ProxyAnnouncedCall, error) {
+	bz, err := encodeCall(tx, call)
+	if err != nil {
+		return ProxyAnnouncedCall{}, err
+	}
+	return ProxyAnnouncedCall{Delegate: delegate, Real: real, ForceProxyType: forceProxyType, Call: bz}, nil
+}
+
+func (c ProxyAnnouncedCall) Method() string { return config.MethodProxyAnnounced }
+func (c ProxyAnnouncedCall) Args() []interface{} {
+	return []interface{}{c.Delegate, c.Real, optionProxyType(c.ForceProxyType), c.Call}
+}
+
+// CallHash returns the hash the delegate announced
+func (c ProxyAnnouncedCall) CallHash() types.Hash {
+	h := blake2b.Sum256(c.Call)
+	return types.NewHash(h[:])
+}
+
+// AddProxyCall is Proxy.add_proxy
+type AddProxyCall struct {
+	Delegate  interface{}
+	ProxyType ProxyType
+	Delay     uint32
+}
+
+func (c AddProxyCall) Method() string { return config.MethodAddProxy }
+func (c AddProxyCall) Args() []interface{} {
+	return []interface{}{c.Delegate, c.ProxyType, c.Delay}
+}
+
+// RemoveProxyCall is Proxy.remove_proxy
+type RemoveProxyCall struct {
+	Delegate  interface{}
+	ProxyType ProxyType
+	Delay     uint32
+}
+
+func (c RemoveProxyCall) Method() string { return config.MethodRemoveProxy }
+func (c RemoveProxyCall) Args() []interface{} {
+	return []interface{}{c.Delegate, c.ProxyType, c.Delay}
+}
+
+// CreatePureCall is Proxy.create_pure, PureProxyAccount derives the account it spawns
+type CreatePureCall struct {
+	ProxyType ProxyType
+	Delay     uint32
+	Index     uint16
+}
+
+func (c CreatePureCall) Method() string { return config.MethodCreatePure }
+func (c CreatePureCall) Args() []interface{} {
+	return []interface{}{c.ProxyType, c.Delay, c.Index}
+}
+
+func optionProxyType(t *ProxyType) types.OptionU8 {
+	if t == nil {
+		return types.NewOptionU8Empty()
+	}
+	return types.NewOptionU8(types.U8(*t))
+}
+
+// encodeCall returns the encoding of call as the argument of another call
+func encodeCall(tx TxSubmitter, call CallBuilder) ([]byte, error) {
+	ext, err := NewCallExtrinsic(tx, call)
+	if err != nil {
+		return nil, err
+	}
+	return callOpaque(ext)
+}
@@ -0,0 +1,113 @@
+package client_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client/clientfake"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestProxyType(t *testing.T) {
+	assert.Equal(t, "Staking", client.ProxyStaking.String())
+	assert.Equal(t, "ProxyType(7)", client.ProxyType(7).String())
+
+	reg := client.NewProxyTypeRegistry()
+	assert.Equal(t, "Staking", reg.Name(client.ProxyStaking))
+	reg.Register(map[client.ProxyType]string{0: "Any", 7: "Auction"})
+	auction, err := reg.ProxyType("Auction")
+	assert.NoError(t, err)
+	assert.Equal(t, client.ProxyType(7), auction)
+	_, err = reg.ProxyType("Staking")
+	assert.EqualError(t, err, "ProxyType Staking not supported")
+
+	bz, err := types.EncodeToBytes(auction)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{7}, bz)
+
+	// registries of different chains are independent
+	other := client.NewProxyTypeRegistry()
+	assert.NoError(t, other.LoadMetadata(enumMetadataV14(t, map[string]map[uint8]string{"ProxyType": {0: "Any", 7: "CancelProxy"}})))
+	assert.Equal(t, "CancelProxy", other.Name(7))
+	assert.Equal(t, "Auction", reg.Name(7))
+	assert.Equal(t, "ProxyType(3)", other.Name(client.ProxyStaking))
+	assert.NoError(t, other.LoadMetadata(types.MustHexDecodeString(types.ExamplaryMetadataV13SubstrateString)))
+	assert.Equal(t, "CancelProxy", other.Name(7))
+}
+
+func TestPureProxyAccount(t *testing.T) {
+	// pallet-proxy hashes ("modlpy/proxy____", who, height, ext_index, proxy_type, index) with blake2_256, the value
+	// is that hash for Alice spawning an Any proxy of index 0 at extrinsic 1 of block 10. It is not taken from a chain.
+	pure, err := client.PureProxyAccount(alice, client.ProxyAny, 0, 10, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xcf531e0573c64ba680734e891c3fcd13f1547a9c7efe89eed17f203a29554577", types.HexEncodeToString(pure[:]))
+
+	other, err := client.PureProxyAccount(alice, client.ProxyAny, 1, 10, 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, pure, other)
+}
+
+func TestProxyQueries(t *testing.T) {
+	storage := clientfake.NewStorage()
+
+	proxies, err := client.Proxies(storage, alice)
+	assert.NoError(t, err)
+	assert.Empty(t, proxies.Definitions)
+
+	aliceBz, err := types.EncodeToBytes(alice)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.Set(config.ProxyModuleId, config.StorageProxies, aliceBz, nil, &client.ProxyDefinitions{
+		Definitions: []client.ProxyDefinition{{Delegate: bob, ProxyType: client.ProxyStaking, Delay: 5}},
+		Deposit:     types.NewU128(*big.NewInt(100)),
+	}))
+	proxies, err = client.Proxies(storage, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, []client.ProxyDefinition{{Delegate: bob, ProxyType: client.ProxyStaking, Delay: 5}}, proxies.Definitions)
+	assert.Equal(t, int64(100), proxies.Deposit.Int64())
+
+	bobBz, err := types.EncodeToBytes(bob)
+	assert.NoError(t, err)
+	callHash := types.NewHash([]byte{0x01})
+	assert.NoError(t, storage.Set(config.ProxyModuleId, config.StorageAnnouncements, bobBz, nil, &client.ProxyAnnouncements{
+		Announcements: []client.ProxyAnnouncement{{Real: alice, CallHash: callHash, Height: 8}},
+		Deposit:       types.NewU128(*big.NewInt(10)),
+	}))
+	announcements, err := client.Announcements(storage, bob)
+	assert.NoError(t, err)
+	assert.Equal(t, []client.ProxyAnnouncement{{Real: alice, CallHash: callHash, Height: 8}}, announcements.Announcements)
+}
+
+func TestProxyCalls(t *testing.T) {
+	submitter := clientfake.NewSubmitter()
+	inner := client.ClaimREthRewardCall{Cycle: 1, Index: 2}
+	innerExt, err := client.NewCallExtrinsic(submitter, inner)
+	assert.NoError(t, err)
+	innerBz, err := types.EncodeToBytes(innerExt)
+	assert.NoError(t, err)
+
+	staking := client.ProxyStaking
+	proxy, err := client.NewProxyCall(submitter, alice, &staking, inner)
+	assert.NoError(t, err)
+	assert.Equal(t, config.MethodProxy, proxy.Method())
+	assert.Equal(t, []interface{}{alice, types.NewOptionU8(types.U8(3)), types.BytesBare(innerBz)}, proxy.Args())
+
+	announced, err := client.NewProxyAnnouncedCall(submitter, bob, alice, nil, inner)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{bob, alice, types.NewOptionU8Empty(), types.BytesBare(innerBz)}, announced.Args())
+	assert.Equal(t, types.NewHash(blake2bSum(innerBz)), announced.CallHash())
+
+	add := client.AddProxyCall{Delegate: bob, ProxyType: client.ProxyAny, Delay: 0}
+	assert.Equal(t, config.MethodAddProxy, add.Method())
+	ext, err := client.NewCallExtrinsic(submitter, client.CreatePureCall{ProxyType: client.ProxyAny, Index: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, &clientfake.Call{Method: config.MethodCreatePure, Args: []interface{}{client.ProxyAny, uint32(0), uint16(1)}}, ext)
+}
+
+func blake2bSum(bz []byte) []byte {
+	h := blake2b.Sum256(bz)
+	return h[:]
+}
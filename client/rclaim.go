@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// ClaimInfo is a mint of a user in a reward act, in RClaim.ClaimInfos and RClaim.REthClaimInfos
+type ClaimInfo struct {
+	MintAmount         types.U128
+	NativeTokenAmount  types.U128
+	TotalReward        types.U128
+	TotalClaimed       types.U128
+	LatestClaimedBlock types.U32
+	MintBlock          types.U32
+}
+
+// MintClaim is the reward of one mint of a user
+type MintClaim struct {
+	Symbol RSymbol
+	Cycle  uint32
+	Index  uint64
+	Info   ClaimInfo
+	Act    *MintRewardAct
+	// Claimable can be claimed at the block the claim was computed for, Locked unlocks later
+	Claimable types.U128
+	Locked    types.U128
+	// Claimed is set once the whole reward was claimed
+	Claimed bool
+}
+
+// ClaimRTokenRewardCall is RClaim.claim_rtoken_reward
+type ClaimRTokenRewardCall struct {
+	Symbol RSymbol
+	Cycle  uint32
+	Index  uint64
+}
+
+func (c ClaimRTokenRewardCall) Method() string { return config.MethodClaimRTokenReward }
+func (c ClaimRTokenRewardCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.Cycle, c.Index}
+}
+
+// ClaimREthRewardCall is RClaim.claim_reth_reward
+type ClaimREthRewardCall struct {
+	Cycle uint32
+	Index uint64
+}
+
+func (c ClaimREthRewardCall) Method() string { return config.MethodClaimREthReward }
+func (c ClaimREthRewardCall) Args() []interface{} {
+	return []interface{}{c.Cycle, c.Index}
+}
+
+// ClaimCall returns the call claiming the reward, nil if nothing is claimable
+func (m *MintClaim) ClaimCall() CallBuilder {
+	if m.Claimable.Int == nil || m.Claimable.Sign() <= 0 {
+		return nil
+	}
+	if m.Symbol == RETH {
+		return ClaimREthRewardCall{Cycle: m.Cycle, Index: m.Index}
+	}
+	return ClaimRTokenRewardCall{Symbol: m.Symbol, Cycle: m.Cycle, Index: m.Index}
+}
+
+// ClaimAmounts splits the reward of a mint at block into what can be claimed and what is locked, the way
+// claim_rtoken_reward computes it. Once the locked blocks of the act have passed since the mint block the rest of the
+// reward is claimable, before that the reward unlocks by total*(block-LatestClaimedBlock)/LockedBlocks.
+func ClaimAmounts(act *MintRewardAct, info *ClaimInfo, block uint64) (claimable, locked types.U128) {
+	total, claimed := bigOf(info.TotalReward), bigOf(info.TotalClaimed)
+	rest := new(big.Int).Sub(total, claimed)
+	if rest.Sign() < 0 {
+		rest.SetInt64(0)
+	}
+
+	c := new(big.Int).Set(rest)
+	lockedBlocks, latestClaimed := uint64(act.LockedBlocks), uint64(info.LatestClaimedBlock)
+	if block < uint64(info.MintBlock)+lockedBlocks {
+		passed := uint64(0)
+		if block > latestClaimed {
+			passed = block - latestClaimed
+		}
+		c.Mul(total, new(big.Int).SetUint64(passed))
+		c.Quo(c, new(big.Int).SetUint64(lockedBlocks))
+		if c.Cmp(rest) > 0 {
+			c.Set(rest)
+		}
+	}
+	return types.NewU128(*c), types.NewU128(*new(big.Int).Sub(rest, c))
+}
+
+// MintClaims lists the rewards of who for minting symbol in all acts, computed at block. rETH reads the REth acts
+// and claim infos.
+func MintClaims(c Chain, who types.AccountID, symbol RSymbol, block uint64) ([]*MintClaim, error) {
+	var latest uint32
+	var err error
+	if symbol == RETH {
+		latest, err = c.REthActLatestCycle()
+	} else {
+		latest, err = c.ActLatestCycle(symbol)
+	}
+	if err == ErrorValueNotExist {
+		return []*MintClaim{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claims := make([]*MintClaim, 0)
+	for cycle := uint32(1); cycle <= latest; cycle++ {
+		count, err := c.UserMintsCount(who, symbol, cycle)
+		if err != nil {
+			return nil, fmt.Errorf("cycle %d: %s", cycle, err)
+		}
+		if count == 0 {
+			continue
+		}
+
+		var act *MintRewardAct
+		if symbol == RETH {
+			act, err = c.RethAct(cycle)
+		} else {
+			act, err = c.Act(symbol, cycle)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cycle %d: act: %s", cycle, err)
+		}
+
+		for index := uint64(0); index < count; index++ {
+			info, err := c.ClaimInfo(who, symbol, cycle, index)
+			if err != nil {
+				return nil, fmt.Errorf("cycle %d index %d: %s", cycle, index, err)
+			}
+			claimable, locked := ClaimAmounts(act, info, block)
+			claims = append(claims, &MintClaim{
+				Symbol:    symbol,
+				Cycle:     cycle,
+				Index:     index,
+				Info:      *info,
+				Act:       act,
+				Claimable: claimable,
+				Locked:    locked,
+				Claimed:   bigOf(info.TotalClaimed).Cmp(bigOf(info.TotalReward)) >= 0,
+			})
+		}
+	}
+	return claims, nil
+}
+
+func (sc *GsrpcClient) UserMintsCount(who types.AccountID, symbol RSymbol, cycle uint32, blockHash ...types.Hash) (uint64, error) {
+	return sc.UserMintsCountContext(context.Background(), who, symbol, cycle, blockHash...)
+}
+
+func (sc *GsrpcClient) UserMintsCountContext(ctx context.Context, who types.AccountID, symbol RSymbol, cycle uint32, blockHash ...types.Hash) (uint64, error) {
+	var key interface{} = struct {
+		Who    types.AccountID
+		Symbol RSymbol
+		Cycle  uint32
+	}{who, symbol, cycle}
+	method := config.StorageUserMintsCount
+	if symbol == RETH {
+		key = struct {
+			Who   types.AccountID
+			Cycle uint32
+		}{who, cycle}
+		method = config.StorageREthUserMintsCount
+	}
+	keyBz, err := types.EncodeToBytes(key)
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	exists, err := sc.QueryStorageContext(ctx, config.RClaimModuleId, method, keyBz, nil, &count, blockHash...)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+	return count, nil
+}
+
+func (sc *GsrpcClient) ClaimInfo(who types.AccountID, symbol RSymbol, cycle uint32, index uint64, blockHash ...types.Hash) (*ClaimInfo, error) {
+	return sc.ClaimInfoContext(context.Background(), who, symbol, cycle, index, blockHash...)
+}
+
+func (sc *GsrpcClient) ClaimInfoContext(ctx context.Context, who types.AccountID, symbol RSymbol, cycle uint32, index uint64, blockHash ...types.Hash) (*ClaimInfo, error) {
+	var key interface{} = struct {
+		Who    types.AccountID
+		Symbol RSymbol
+		Cycle  uint32
+		Index  uint64
+	}{who, symbol, cycle, index}
+	method := config.StorageClaimInfos
+	if symbol == RETH {
+		key = struct {
+			Who   types.AccountID
+			Cycle uint32
+			Index uint64
+		}{who, cycle, index}
+		method = config.StorageREthClaimInfos
+	}
+	keyBz, err := types.EncodeToBytes(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info := new(ClaimInfo)
+	exists, err := sc.QueryStorageContext(ctx, config.RClaimModuleId, method, keyBz, nil, info, blockHash...)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrorValueNotExist
+	}
+	return info, nil
+}
@@ -0,0 +1,93 @@
+package client_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client/clientfake"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMintClaims(t *testing.T) {
+	rTokens := clientfake.NewRTokens()
+	c := client.NewChain(nil, nil, nil, nil, rTokens)
+	who := types.NewAccountID([]byte{0x01})
+	whoHex := types.HexEncodeToString(who[:])
+	u128 := func(v int64) types.U128 { return types.NewU128(*big.NewInt(v)) }
+
+	claims, err := client.MintClaims(c, who, client.RDOT, 100)
+	assert.NoError(t, err)
+	assert.Empty(t, claims)
+
+	rTokens.ActLatestCycles[client.RDOT] = 2
+	rTokens.Acts[clientfake.ActKey{Symbol: client.RDOT, Cycle: 2}] = &client.MintRewardAct{Cycle: 2, LockedBlocks: 100}
+	rTokens.ClaimInfos[clientfake.MintKey{Account: whoHex, Symbol: client.RDOT, Cycle: 2}] = []*client.ClaimInfo{
+		{TotalReward: u128(1000), TotalClaimed: u128(100), MintBlock: 50, LatestClaimedBlock: 60},
+		{TotalReward: u128(200), TotalClaimed: u128(200), MintBlock: 0},
+	}
+
+	claims, err = client.MintClaims(c, who, client.RDOT, 100)
+	assert.NoError(t, err)
+	assert.Len(t, claims, 2)
+	assert.Equal(t, uint32(2), claims[0].Cycle)
+	assert.Equal(t, u128(400), claims[0].Claimable)
+	assert.Equal(t, u128(500), claims[0].Locked)
+	assert.False(t, claims[0].Claimed)
+	call := claims[0].ClaimCall()
+	assert.Equal(t, config.MethodClaimRTokenReward, call.Method())
+	assert.Equal(t, []interface{}{client.RDOT, uint32(2), uint64(0)}, call.Args())
+
+	assert.Equal(t, uint64(1), claims[1].Index)
+	assert.True(t, claims[1].Claimed)
+	assert.Zero(t, claims[1].Claimable.Sign())
+	assert.Nil(t, claims[1].ClaimCall())
+
+	// after the locked blocks everything is claimable
+	claimable, locked := client.ClaimAmounts(claims[0].Act, &claims[0].Info, 150)
+	assert.Equal(t, u128(900), claimable)
+	assert.Zero(t, locked.Sign())
+
+	// a claim before the last one unlocks nothing
+	claimable, locked = client.ClaimAmounts(claims[0].Act, &claims[0].Info, 55)
+	assert.Zero(t, claimable.Sign())
+	assert.Equal(t, u128(900), locked)
+
+	rTokens.REthLatestCycle = 1
+	rTokens.REthActs[1] = &client.MintRewardAct{Cycle: 1}
+	rTokens.ClaimInfos[clientfake.MintKey{Account: whoHex, Symbol: client.RETH, Cycle: 1}] = []*client.ClaimInfo{
+		{TotalReward: u128(10)},
+	}
+	claims, err = client.MintClaims(c, who, client.RETH, 1)
+	assert.NoError(t, err)
+	assert.Len(t, claims, 1)
+	assert.Equal(t, []interface{}{uint32(1), uint64(0)}, claims[0].ClaimCall().Args())
+}
+
+// TestClaimAmounts_Claims claims a reward several times, updating the claim info like claim_rtoken_reward does. Each
+// claim unlocks from the block of the claim before, with its own rounding, and the last one takes the rest.
+func TestClaimAmounts_Claims(t *testing.T) {
+	act := &client.MintRewardAct{LockedBlocks: 7}
+	info := &client.ClaimInfo{
+		TotalReward:        types.NewU128(*big.NewInt(1000)),
+		TotalClaimed:       types.NewU128(*big.NewInt(0)),
+		MintBlock:          10,
+		LatestClaimedBlock: 10,
+	}
+
+	var amounts []int64
+	for _, block := range []uint64{11, 13, 16, 20} {
+		claimable, locked := client.ClaimAmounts(act, info, block)
+		assert.Equal(t, int64(1000), info.TotalClaimed.Int64()+claimable.Int64()+locked.Int64())
+		amounts = append(amounts, claimable.Int64())
+		info.TotalClaimed = types.NewU128(*new(big.Int).Add(info.TotalClaimed.Int, claimable.Int))
+		info.LatestClaimedBlock = types.U32(block)
+	}
+	assert.Equal(t, []int64{142, 285, 428, 145}, amounts)
+
+	claimable, locked := client.ClaimAmounts(act, info, 21)
+	assert.Zero(t, claimable.Sign())
+	assert.Zero(t, locked.Sign())
+}
@@ -0,0 +1,360 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+var (
+	ErrPoolEmpty       = errors.New("swap pool is empty")
+	ErrAmountZero      = errors.New("amount is zero")
+	ErrUnitExceeded    = errors.New("unit exceeds pool or provider unit")
+	ErrSlippageTooHigh = errors.New("slippage exceeds 100%")
+)
+
+// SwapPool is a pool of RDexSwap.SwapPools, FIS against the rToken of Symbol
+type SwapPool struct {
+	Symbol        RSymbol
+	FisBalance    types.U128
+	RTokenBalance types.U128
+	TotalUnit     types.U128
+}
+
+// SwapLiquidityProvider is the share of an account in a pool, in RDexSwap.SwapLiquidityProviders
+type SwapLiquidityProvider struct {
+	Account          types.AccountID
+	Unit             types.U128
+	LastAddHeight    uint32
+	LastRemoveHeight uint32
+	FisAddValue      types.U128
+	RTokenAddValue   types.U128
+}
+
+func (sc *GsrpcClient) SwapPool(symbol RSymbol, blockHash ...types.Hash) (*SwapPool, error) {
+	return sc.SwapPoolContext(context.Background(), symbol, blockHash...)
+}
+
+func (sc *GsrpcClient) SwapPoolContext(ctx context.Context, symbol RSymbol, blockHash ...types.Hash) (*SwapPool, error) {
+	symBz, err := types.EncodeToBytes(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := new(SwapPool)
+	exists, err := sc.QueryStorageContext(ctx, config.RDexSwapModuleId, config.StorageSwapPools, symBz, nil, pool, blockHash...)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrorValueNotExist
+	}
+	return pool, nil
+}
+
+func (sc *GsrpcClient) SwapLiquidityProvider(who types.AccountID, symbol RSymbol, blockHash ...types.Hash) (*SwapLiquidityProvider, error) {
+	return sc.SwapLiquidityProviderContext(context.Background(), who, symbol, blockHash...)
+}
+
+func (sc *GsrpcClient) SwapLiquidityProviderContext(ctx context.Context, who types.AccountID, symbol RSymbol, blockHash ...types.Hash) (*SwapLiquidityProvider, error) {
+	whoBz, err := types.EncodeToBytes(who)
+	if err != nil {
+		return nil, err
+	}
+	symBz, err := types.EncodeToBytes(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	lp := new(SwapLiquidityProvider)
+	exists, err := sc.QueryStorageContext(ctx, config.RDexSwapModuleId, config.StorageSwapLiquidityProviders, whoBz, symBz, lp, blockHash...)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrorValueNotExist
+	}
+	return lp, nil
+}
+
+// The quotes follow the continuous liquidity pool math of RDexSwap, with integer division rounding down like the
+// runtime does:
+//
+//	swap x of X for Y:    out = x*X*Y / (x+X)^2, fee = x*x*Y / (x+X)^2
+//	add r FIS, a rToken:  unit = T*(R*a + r*A) / (2*R*A) * slip, slip = 1 - |R*a - r*A| / ((2r+R)*(a+A)),
+//	                      rounded once at the end, unit = r for an empty pool
+//	remove u of T units:  R*u/T FIS and A*u/T rToken, the part swapUnit/u of one side is swapped to the other
+
+// SwapQuote is the result of a swap, the same numbers the Swap event reports
+type SwapQuote struct {
+	InputAmount   types.U128
+	OutputAmount  types.U128
+	FeeAmount     types.U128
+	InputIsFis    bool
+	FisBalance    types.U128
+	RTokenBalance types.U128
+}
+
+// AddLiquidityQuote is the result of adding liquidity, the same numbers the AddLiquidity event reports
+type AddLiquidityQuote struct {
+	FisAmount     types.U128
+	RTokenAmount  types.U128
+	NewTotalUnit  types.U128
+	AddUnit       types.U128
+	FisBalance    types.U128
+	RTokenBalance types.U128
+}
+
+// RemoveLiquidityQuote is the result of removing liquidity, the same numbers the RemoveLiquidity event reports
+type RemoveLiquidityQuote struct {
+	RemoveUnit         types.U128
+	SwapUnit           types.U128
+	RemoveFisAmount    types.U128
+	RemoveRTokenAmount types.U128
+	InputIsFis         bool
+	FisBalance         types.U128
+	RTokenBalance      types.U128
+	NewTotalUnit       types.U128
+}
+
+// QuoteSwap prices swapping amount of FIS for rToken if inputIsFis, of rToken for FIS otherwise
+func (p *SwapPool) QuoteSwap(amount types.U128, inputIsFis bool) (*SwapQuote, error) {
+	x := bigOf(amount)
+	if x.Sign() <= 0 {
+		return nil, ErrAmountZero
+	}
+	fis, rToken := bigOf(p.FisBalance), bigOf(p.RTokenBalance)
+	if fis.Sign() <= 0 || rToken.Sign() <= 0 {
+		return nil, ErrPoolEmpty
+	}
+
+	inBalance, outBalance := fis, rToken
+	if !inputIsFis {
+		inBalance, outBalance = rToken, fis
+	}
+	out, fee := swapResult(x, inBalance, outBalance)
+
+	newIn := new(big.Int).Add(inBalance, x)
+	newOut := new(big.Int).Sub(outBalance, out)
+	q := &SwapQuote{
+		InputAmount:  amount,
+		OutputAmount: types.NewU128(*out),
+		FeeAmount:    types.NewU128(*fee),
+		InputIsFis:   inputIsFis,
+	}
+	if inputIsFis {
+		q.FisBalance, q.RTokenBalance = types.NewU128(*newIn), types.NewU128(*newOut)
+	} else {
+		q.FisBalance, q.RTokenBalance = types.NewU128(*newOut), types.NewU128(*newIn)
+	}
+	return q, nil
+}
+
+// QuoteAddLiquidity prices adding fisAmount FIS and rTokenAmount rToken
+func (p *SwapPool) QuoteAddLiquidity(fisAmount, rTokenAmount types.U128) (*AddLiquidityQuote, error) {
+	r, a := bigOf(fisAmount), bigOf(rTokenAmount)
+	if r.Sign() <= 0 && a.Sign() <= 0 {
+		return nil, ErrAmountZero
+	}
+	R, A, T := bigOf(p.FisBalance), bigOf(p.RTokenBalance), bigOf(p.TotalUnit)
+
+	var unit *big.Int
+	if T.Sign() == 0 || R.Sign() == 0 || A.Sign() == 0 {
+		unit = new(big.Int).Set(r)
+	} else {
+		Ra, rA := new(big.Int).Mul(R, a), new(big.Int).Mul(r, A)
+		// the slip adjustment (D-N)/D lowers the units of one sided or unbalanced adds
+		slipDen := new(big.Int).Lsh(r, 1)
+		slipDen.Add(slipDen, R).Mul(slipDen, new(big.Int).Add(a, A))
+		slipNum := new(big.Int).Sub(slipDen, new(big.Int).Abs(new(big.Int).Sub(Ra, rA)))
+
+		num := new(big.Int).Add(Ra, rA)
+		num.Mul(num, T).Mul(num, slipNum)
+		den := new(big.Int).Mul(R, A)
+		den.Lsh(den, 1).Mul(den, slipDen)
+		unit = num.Quo(num, den)
+	}
+	if unit.Sign() == 0 {
+		return nil, ErrAmountZero
+	}
+
+	return &AddLiquidityQuote{
+		FisAmount:     fisAmount,
+		RTokenAmount:  rTokenAmount,
+		NewTotalUnit:  types.NewU128(*new(big.Int).Add(T, unit)),
+		AddUnit:       types.NewU128(*unit),
+		FisBalance:    types.NewU128(*new(big.Int).Add(R, r)),
+		RTokenBalance: types.NewU128(*new(big.Int).Add(A, a)),
+	}, nil
+}
+
+// QuoteRemoveLiquidity prices removing removeUnit units, of which swapUnit are taken in one token only: in rToken if
+// inputIsFis, the FIS part of them being swapped, in FIS otherwise
+func (p *SwapPool) QuoteRemoveLiquidity(removeUnit, swapUnit types.U128, inputIsFis bool) (*RemoveLiquidityQuote, error) {
+	u, s := bigOf(removeUnit), bigOf(swapUnit)
+	R, A, T := bigOf(p.FisBalance), bigOf(p.RTokenBalance), bigOf(p.TotalUnit)
+	if u.Sign() <= 0 {
+		return nil, ErrAmountZero
+	}
+	if T.Sign() <= 0 {
+		return nil, ErrPoolEmpty
+	}
+	if u.Cmp(T) > 0 || s.Cmp(u) > 0 {
+		return nil, ErrUnitExceeded
+	}
+
+	rmFis := new(big.Int).Quo(new(big.Int).Mul(R, u), T)
+	rmRToken := new(big.Int).Quo(new(big.Int).Mul(A, u), T)
+	fis := new(big.Int).Sub(R, rmFis)
+	rToken := new(big.Int).Sub(A, rmRToken)
+
+	if s.Sign() > 0 {
+		if inputIsFis {
+			swapFis := new(big.Int).Quo(new(big.Int).Mul(rmFis, s), u)
+			if swapFis.Sign() > 0 && fis.Sign() > 0 && rToken.Sign() > 0 {
+				out, _ := swapResult(swapFis, fis, rToken)
+				rmFis.Sub(rmFis, swapFis)
+				fis.Add(fis, swapFis)
+				rmRToken.Add(rmRToken, out)
+				rToken.Sub(rToken, out)
+			}
+		} else {
+			swapRToken := new(big.Int).Quo(new(big.Int).Mul(rmRToken, s), u)
+			if swapRToken.Sign() > 0 && fis.Sign() > 0 && rToken.Sign() > 0 {
+				out, _ := swapResult(swapRToken, rToken, fis)
+				rmRToken.Sub(rmRToken, swapRToken)
+				rToken.Add(rToken, swapRToken)
+				rmFis.Add(rmFis, out)
+				fis.Sub(fis, out)
+			}
+		}
+	}
+
+	return &RemoveLiquidityQuote{
+		RemoveUnit:         removeUnit,
+		SwapUnit:           swapUnit,
+		RemoveFisAmount:    types.NewU128(*rmFis),
+		RemoveRTokenAmount: types.NewU128(*rmRToken),
+		InputIsFis:         inputIsFis,
+		FisBalance:         types.NewU128(*fis),
+		RTokenBalance:      types.NewU128(*rToken),
+		NewTotalUnit:       types.NewU128(*new(big.Int).Sub(T, u)),
+	}, nil
+}
+
+// Apply returns the pool after the swap
+func (q *SwapQuote) Apply(p *SwapPool) *SwapPool {
+	return &SwapPool{Symbol: p.Symbol, FisBalance: q.FisBalance, RTokenBalance: q.RTokenBalance, TotalUnit: p.TotalUnit}
+}
+
+// Apply returns the pool after adding the liquidity
+func (q *AddLiquidityQuote) Apply(p *SwapPool) *SwapPool {
+	return &SwapPool{Symbol: p.Symbol, FisBalance: q.FisBalance, RTokenBalance: q.RTokenBalance, TotalUnit: q.NewTotalUnit}
+}
+
+// Apply returns the pool after removing the liquidity
+func (q *RemoveLiquidityQuote) Apply(p *SwapPool) *SwapPool {
+	return &SwapPool{Symbol: p.Symbol, FisBalance: q.FisBalance, RTokenBalance: q.RTokenBalance, TotalUnit: q.NewTotalUnit}
+}
+
+// MinAmountOut returns amount less slippageBps basis points, the bound to pass as minimum output of a call
+func MinAmountOut(amount types.U128, slippageBps uint32) (types.U128, error) {
+	if slippageBps > 10000 {
+		return types.U128{}, ErrSlippageTooHigh
+	}
+	min := new(big.Int).Mul(bigOf(amount), big.NewInt(int64(10000-slippageBps)))
+	return types.NewU128(*min.Quo(min, big.NewInt(10000))), nil
+}
+
+// SwapCall is RDexSwap.swap
+type SwapCall struct {
+	Symbol       RSymbol
+	InputAmount  types.U128
+	MinOutAmount types.U128
+	InputIsFis   bool
+}
+
+func (c SwapCall) Method() string { return config.MethodRDexSwap }
+func (c SwapCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.InputAmount, c.MinOutAmount, c.InputIsFis}
+}
+
+// SwapCall returns the call of the swap quoted, with a minimum output slippageBps basis points below the quote
+func (q *SwapQuote) SwapCall(symbol RSymbol, slippageBps uint32) (*SwapCall, error) {
+	min, err := MinAmountOut(q.OutputAmount, slippageBps)
+	if err != nil {
+		return nil, err
+	}
+	return &SwapCall{Symbol: symbol, InputAmount: q.InputAmount, MinOutAmount: min, InputIsFis: q.InputIsFis}, nil
+}
+
+// AddLiquidityCall is RDexSwap.add_liquidity
+type AddLiquidityCall struct {
+	Symbol       RSymbol
+	RTokenAmount types.U128
+	FisAmount    types.U128
+}
+
+func (c AddLiquidityCall) Method() string { return config.MethodRDexAddLiquidity }
+func (c AddLiquidityCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.RTokenAmount, c.FisAmount}
+}
+
+// RemoveLiquidityCall is RDexSwap.remove_liquidity
+type RemoveLiquidityCall struct {
+	Symbol             RSymbol
+	RemoveUnit         types.U128
+	SwapUnit           types.U128
+	MinFisOutAmount    types.U128
+	MinRTokenOutAmount types.U128
+	InputIsFis         bool
+}
+
+func (c RemoveLiquidityCall) Method() string { return config.MethodRDexRemoveLiquidity }
+func (c RemoveLiquidityCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.RemoveUnit, c.SwapUnit, c.MinFisOutAmount, c.MinRTokenOutAmount, c.InputIsFis}
+}
+
+// RemoveLiquidityCall returns the call of the removal quoted, with minimum outputs slippageBps basis points below
+// the quote
+func (q *RemoveLiquidityQuote) RemoveLiquidityCall(symbol RSymbol, slippageBps uint32) (*RemoveLiquidityCall, error) {
+	minFis, err := MinAmountOut(q.RemoveFisAmount, slippageBps)
+	if err != nil {
+		return nil, err
+	}
+	minRToken, err := MinAmountOut(q.RemoveRTokenAmount, slippageBps)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoveLiquidityCall{
+		Symbol:             symbol,
+		RemoveUnit:         q.RemoveUnit,
+		SwapUnit:           q.SwapUnit,
+		MinFisOutAmount:    minFis,
+		MinRTokenOutAmount: minRToken,
+		InputIsFis:         q.InputIsFis,
+	}, nil
+}
+
+// swapResult returns the output and fee of swapping x into a pool with inBalance and outBalance
+func swapResult(x, inBalance, outBalance *big.Int) (*big.Int, *big.Int) {
+	sum := new(big.Int).Add(x, inBalance)
+	den := new(big.Int).Mul(sum, sum)
+	xy := new(big.Int).Mul(x, outBalance)
+	out := new(big.Int).Mul(xy, inBalance)
+	out.Quo(out, den)
+	fee := new(big.Int).Mul(xy, x)
+	fee.Quo(fee, den)
+	return out, fee
+}
+
+// bigOf returns the value of v, 0 for the zero U128
+func bigOf(v types.U128) *big.Int {
+	if v.Int == nil {
+		return new(big.Int)
+	}
+	return v.Int
+}
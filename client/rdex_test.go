@@ -0,0 +1,64 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func u128(v int64) types.U128 {
+	return types.NewU128(*big.NewInt(v))
+}
+
+func TestSwapPool_Quote(t *testing.T) {
+	pool := &SwapPool{Symbol: RDOT, FisBalance: u128(1000), RTokenBalance: u128(2000), TotalUnit: u128(1000)}
+
+	swap, err := pool.QuoteSwap(u128(100), true)
+	assert.NoError(t, err)
+	assert.Equal(t, &SwapQuote{InputAmount: u128(100), OutputAmount: u128(165), FeeAmount: u128(16), InputIsFis: true,
+		FisBalance: u128(1100), RTokenBalance: u128(1835)}, swap)
+	assert.Equal(t, u128(1835), swap.Apply(pool).RTokenBalance)
+
+	call, err := swap.SwapCall(RDOT, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, config.MethodRDexSwap, call.Method())
+	assert.Equal(t, []interface{}{RDOT, u128(100), u128(163), true}, call.Args())
+
+	// a one sided add is slip adjusted: 1000*200000/4000000 * (2400000-200000)/2400000 = 45.8
+	add, err := pool.QuoteAddLiquidity(u128(100), types.U128{})
+	assert.NoError(t, err)
+	assert.Equal(t, u128(45), add.AddUnit)
+	assert.Equal(t, u128(1045), add.NewTotalUnit)
+	// a balanced add has no slip
+	add, err = pool.QuoteAddLiquidity(u128(100), u128(200))
+	assert.NoError(t, err)
+	assert.Equal(t, u128(100), add.AddUnit)
+	// the first liquidity of a pool gets as many units as FIS
+	add, err = (&SwapPool{Symbol: RDOT}).QuoteAddLiquidity(u128(100), u128(200))
+	assert.NoError(t, err)
+	assert.Equal(t, u128(100), add.AddUnit)
+	_, err = (&SwapPool{Symbol: RDOT}).QuoteAddLiquidity(types.U128{}, u128(200))
+	assert.Equal(t, ErrAmountZero, err)
+
+	remove, err := pool.QuoteRemoveLiquidity(u128(100), u128(100), true)
+	assert.NoError(t, err)
+	assert.Zero(t, remove.RemoveFisAmount.Sign())
+	assert.Equal(t, u128(362), remove.RemoveRTokenAmount)
+	assert.Equal(t, u128(1000), remove.FisBalance)
+	assert.Equal(t, u128(1638), remove.RTokenBalance)
+	assert.Equal(t, u128(900), remove.NewTotalUnit)
+	remove, err = pool.QuoteRemoveLiquidity(u128(100), u128(0), false)
+	assert.NoError(t, err)
+	assert.Equal(t, u128(100), remove.RemoveFisAmount)
+	assert.Equal(t, u128(200), remove.RemoveRTokenAmount)
+
+	_, err = pool.QuoteRemoveLiquidity(u128(1001), u128(0), false)
+	assert.Equal(t, ErrUnitExceeded, err)
+	_, err = (&SwapPool{Symbol: RDOT}).QuoteSwap(u128(1), false)
+	assert.Equal(t, ErrPoolEmpty, err)
+	_, err = MinAmountOut(u128(1), 10001)
+	assert.Equal(t, ErrSlippageTooHigh, err)
+}
@@ -0,0 +1,380 @@
+package client_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+// replayRuntime executes balance transfers, utility batches and multisig calls of the extrinsics included by a
+// rpcmocksrv.Node, like the runtime of a dev node would, so that fixtures recorded against the node carry real events
+// and balances. Fees are not charged.
+type replayRuntime struct {
+	meta *types.Metadata
+
+	block   uint64
+	index   uint32
+	records [][]byte
+	// state is the storage written in the block, nil for deleted entries
+	state map[string][]byte
+}
+
+// newReplayNode starts a node running replayRuntime with funded accounts and produces a block whenever extrinsics
+// are pooled
+func newReplayNode(t *testing.T, funded ...types.AccountID) *rpcmocksrv.Node {
+	meta := types.NewMetadataV13()
+	if err := types.DecodeFromBytes(types.MustHexDecodeString(types.ExamplaryMetadataV13SubstrateString), meta); err != nil {
+		t.Fatal(err)
+	}
+	r := &replayRuntime{meta: meta}
+
+	eventsKey, err := types.CreateStorageKey(meta, "System", "Events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := map[string][]byte{eventsKey.Hex(): {0x00}}
+	for _, who := range funded {
+		info := newAccountInfo()
+		info.Data.Free = types.NewU128(*new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+		bz, err := types.EncodeToBytes(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		genesis[r.accountKey(who).Hex()] = bz
+	}
+
+	node, err := rpcmocksrv.NewNode(rpcmocksrv.NodeConfig{
+		Genesis: genesis,
+		OnExtrinsic: func(n *rpcmocksrv.Node, xt []byte) {
+			if err := r.apply(n, xt); err != nil {
+				t.Errorf("replay runtime: %s", err)
+			}
+		},
+		PaymentInfo: func(xt []byte) (uint64, uint64) { return uint64(len(xt)) * 1000, 0 },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-time.After(10 * time.Millisecond):
+				if len(node.Pending()) > 0 {
+					node.ProduceBlock()
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		node.Close()
+	})
+	return node
+}
+
+// apply executes the signed extrinsic xt and writes the storage changes and System.Events of the block
+func (r *replayRuntime) apply(n *rpcmocksrv.Node, xt []byte) error {
+	if block := n.BestNumber() + 1; block != r.block {
+		r.block, r.index, r.records, r.state = block, 0, nil, make(map[string][]byte)
+	}
+	defer func() { r.index++ }()
+
+	ext := types.Extrinsic{}
+	if err := types.DecodeFromBytes(xt, &ext); err != nil {
+		return err
+	}
+	if !ext.IsSigned() {
+		return fmt.Errorf("unsigned extrinsic %d", r.index)
+	}
+	origin := ext.Signature.Signer.AsAccountID
+	info, err := r.account(n, origin)
+	if err != nil {
+		return err
+	}
+	info.Nonce++
+	if err := r.setAccount(n, origin, info); err != nil {
+		return err
+	}
+
+	call, err := types.EncodeToBytes(ext.Method)
+	if err != nil {
+		return err
+	}
+	events, err := r.dispatch(n, scale.NewDecoder(bytes.NewReader(call)), origin)
+	if err != nil {
+		return err
+	}
+	// DispatchInfo of weight 0, normal class, paying fee
+	events = append(events, r.event("System", "ExtrinsicSuccess", types.Weight(0), uint8(0), uint8(0)))
+
+	for _, evt := range events {
+		record := bytes.Buffer{}
+		record.WriteByte(0x00)
+		if err := scale.NewEncoder(&record).Encode(r.index); err != nil {
+			return err
+		}
+		record.Write(evt)
+		record.WriteByte(0x00)
+		r.records = append(r.records, record.Bytes())
+	}
+	raw, err := types.EncodeToBytes(types.NewUCompactFromUInt(uint64(len(r.records))))
+	if err != nil {
+		return err
+	}
+	raw = append(raw, bytes.Join(r.records, nil)...)
+	eventsKey, err := types.CreateStorageKey(r.meta, "System", "Events", nil)
+	if err != nil {
+		return err
+	}
+	n.SetStorage(eventsKey, raw)
+	return nil
+}
+
+// dispatch executes the call read from d for origin and returns its encoded events
+func (r *replayRuntime) dispatch(n *rpcmocksrv.Node, d *scale.Decoder, origin types.AccountID) ([][]byte, error) {
+	var ci types.CallIndex
+	if err := d.Decode(&ci); err != nil {
+		return nil, err
+	}
+
+	switch ci {
+	case r.call("Balances.transfer"), r.call("Balances.transfer_keep_alive"):
+		var dest types.Address
+		var value types.UCompact
+		if err := decodeAll(d, &dest, &value); err != nil {
+			return nil, err
+		}
+		amount := big.Int(value)
+		if err := r.transfer(n, origin, dest.AsAccountID, &amount); err != nil {
+			return nil, err
+		}
+		return [][]byte{r.event("Balances", "Transfer", origin, dest.AsAccountID, types.NewU128(amount))}, nil
+
+	case r.call("Utility.batch"):
+		var count types.UCompact
+		if err := d.Decode(&count); err != nil {
+			return nil, err
+		}
+		events := make([][]byte, 0)
+		for i := int64(0); i < (*big.Int)(&count).Int64(); i++ {
+			evts, err := r.dispatch(n, d, origin)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, evts...)
+		}
+		return append(events, r.event("Utility", "BatchCompleted")), nil
+
+	case r.call("Multisig.approve_as_multi"):
+		var (
+			threshold uint16
+			others    []types.AccountID
+			timepoint client.OptionTimePoint
+			callHash  types.Hash
+			maxWeight types.Weight
+		)
+		if err := decodeAll(d, &threshold, &others, &timepoint, &callHash, &maxWeight); err != nil {
+			return nil, err
+		}
+		multi, err := client.MultisigAccount(append(others, origin), threshold)
+		if err != nil {
+			return nil, err
+		}
+		ms, exist, err := r.multisig(n, multi, callHash)
+		if err != nil {
+			return nil, err
+		}
+		if exist {
+			ms.Approvals = append(ms.Approvals, origin)
+			return [][]byte{r.event("Multisig", "MultisigApproval", origin, ms.When, multi, callHash)},
+				r.setMultisig(n, multi, callHash, ms)
+		}
+		ms = &client.Multisig{
+			When:      types.TimePoint{Height: types.U32(r.block), Index: types.U32(r.index)},
+			Deposit:   types.NewU128(*big.NewInt(0)),
+			Depositor: origin,
+			Approvals: []types.AccountID{origin},
+		}
+		return [][]byte{r.event("Multisig", "NewMultisig", origin, multi, callHash)}, r.setMultisig(n, multi, callHash, ms)
+
+	case r.call("Multisig.as_multi"):
+		var (
+			threshold uint16
+			others    []types.AccountID
+			timepoint client.OptionTimePoint
+			call      types.Bytes
+			storeCall bool
+			maxWeight types.Weight
+		)
+		if err := decodeAll(d, &threshold, &others, &timepoint, &call, &storeCall, &maxWeight); err != nil {
+			return nil, err
+		}
+		multi, err := client.MultisigAccount(append(others, origin), threshold)
+		if err != nil {
+			return nil, err
+		}
+		callHash := types.Hash(blake2b.Sum256(call))
+		ms, exist, err := r.multisig(n, multi, callHash)
+		if err != nil {
+			return nil, err
+		}
+		if !exist || len(ms.Approvals)+1 < int(threshold) {
+			return nil, fmt.Errorf("multisig call %s is not approved", callHash.Hex())
+		}
+		events, err := r.dispatch(n, scale.NewDecoder(bytes.NewReader(call)), multi)
+		if err != nil {
+			return nil, err
+		}
+		key := r.multisigKey(multi, callHash)
+		r.state[key.Hex()] = nil
+		n.DeleteStorage(key)
+		// DispatchResult Ok
+		return append(events, r.event("Multisig", "MultisigExecuted", origin, ms.When, multi, callHash, uint8(0))), nil
+	}
+	return nil, fmt.Errorf("call %v is not supported", ci)
+}
+
+func decodeAll(d *scale.Decoder, targets ...interface{}) error {
+	for _, target := range targets {
+		if err := d.Decode(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *replayRuntime) call(name string) types.CallIndex {
+	ci, err := r.meta.FindCallIndex(name)
+	if err != nil {
+		panic(err)
+	}
+	return ci
+}
+
+// event encodes the event of module with args, without phase and topics
+func (r *replayRuntime) event(module, name string, args ...interface{}) []byte {
+	for _, m := range r.meta.AsMetadataV13.Modules {
+		if string(m.Name) != module {
+			continue
+		}
+		for i, e := range m.Events {
+			if string(e.Name) != name {
+				continue
+			}
+			buf := bytes.Buffer{}
+			buf.Write([]byte{m.Index, uint8(i)})
+			for _, arg := range args {
+				if err := scale.NewEncoder(&buf).Encode(arg); err != nil {
+					panic(err)
+				}
+			}
+			return buf.Bytes()
+		}
+	}
+	panic(fmt.Sprintf("event %s.%s not in metadata", module, name))
+}
+
+// get reads key as of the extrinsic being applied
+func (r *replayRuntime) get(n *rpcmocksrv.Node, key types.StorageKey) ([]byte, bool) {
+	if v, ok := r.state[key.Hex()]; ok {
+		return v, v != nil
+	}
+	return n.GetStorage(key)
+}
+
+func (r *replayRuntime) set(n *rpcmocksrv.Node, key types.StorageKey, value interface{}) error {
+	bz, err := types.EncodeToBytes(value)
+	if err != nil {
+		return err
+	}
+	r.state[key.Hex()] = bz
+	n.SetStorage(key, bz)
+	return nil
+}
+
+func (r *replayRuntime) accountKey(who types.AccountID) types.StorageKey {
+	key, err := types.CreateStorageKey(r.meta, "System", "Account", who[:])
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func (r *replayRuntime) account(n *rpcmocksrv.Node, who types.AccountID) (*types.AccountInfo, error) {
+	info := newAccountInfo()
+	if bz, ok := r.get(n, r.accountKey(who)); ok {
+		if err := types.DecodeFromBytes(bz, info); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+func (r *replayRuntime) setAccount(n *rpcmocksrv.Node, who types.AccountID, info *types.AccountInfo) error {
+	return r.set(n, r.accountKey(who), info)
+}
+
+func (r *replayRuntime) transfer(n *rpcmocksrv.Node, from, to types.AccountID, amount *big.Int) error {
+	src, err := r.account(n, from)
+	if err != nil {
+		return err
+	}
+	if src.Data.Free.Cmp(amount) < 0 {
+		return fmt.Errorf("%x can not pay %s", from, amount)
+	}
+	src.Data.Free = types.NewU128(*new(big.Int).Sub(src.Data.Free.Int, amount))
+	if err := r.setAccount(n, from, src); err != nil {
+		return err
+	}
+	dest, err := r.account(n, to)
+	if err != nil {
+		return err
+	}
+	dest.Data.Free = types.NewU128(*new(big.Int).Add(dest.Data.Free.Int, amount))
+	return r.setAccount(n, to, dest)
+}
+
+func (r *replayRuntime) multisigKey(multi types.AccountID, callHash types.Hash) types.StorageKey {
+	key, err := types.CreateStorageKey(r.meta, "Multisig", "Multisigs", multi[:], callHash[:])
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func (r *replayRuntime) multisig(n *rpcmocksrv.Node, multi types.AccountID, callHash types.Hash) (*client.Multisig, bool, error) {
+	bz, ok := r.get(n, r.multisigKey(multi, callHash))
+	if !ok {
+		return nil, false, nil
+	}
+	ms := &client.Multisig{}
+	if err := types.DecodeFromBytes(bz, ms); err != nil {
+		return nil, false, err
+	}
+	return ms, true, nil
+}
+
+func (r *replayRuntime) setMultisig(n *rpcmocksrv.Node, multi types.AccountID, callHash types.Hash, ms *client.Multisig) error {
+	return r.set(n, r.multisigKey(multi, callHash), ms)
+}
+
+func newAccountInfo() *types.AccountInfo {
+	info := &types.AccountInfo{}
+	info.Data.Free = types.NewU128(*big.NewInt(0))
+	info.Data.Reserved = types.NewU128(*big.NewInt(0))
+	info.Data.MiscFrozen = types.NewU128(*big.NewInt(0))
+	info.Data.FreeFrozen = types.NewU128(*big.NewInt(0))
+	return info
+}
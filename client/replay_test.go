@@ -0,0 +1,177 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stafiprotocol/chainbridge/utils/keystore"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/utils"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// replayEndpoint returns the endpoint a test talks to. With GSRPC_RECORD=1 the traffic with the live endpoint is
+// recorded to testdata/<name>.json when the test ends, with GSRPC_RECORD=sim the traffic with a node running
+// replayRuntime, which funds the accounts funded. Otherwise that fixture is replayed, the test is skipped if there
+// is none.
+func replayEndpoint(t *testing.T, name, live string, funded ...types.AccountID) string {
+	path := filepath.Join("testdata", name+".json")
+
+	switch os.Getenv("GSRPC_RECORD") {
+	case "sim":
+		live = newReplayNode(t, funded...).URL
+		fallthrough
+	case "1":
+		rec, err := rpcmocksrv.NewRecorder(live)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			if err := os.MkdirAll("testdata", 0755); err != nil {
+				t.Error(err)
+			}
+			if err := rec.Save(path); err != nil {
+				t.Error(err)
+			}
+			rec.Close()
+		})
+		return rec.URL
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		t.Skipf("no fixture %s, record it with GSRPC_RECORD=1", path)
+	}
+	replay, err := rpcmocksrv.NewReplayFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if misses := replay.Misses(); len(misses) > 0 {
+			t.Errorf("requests not in fixture %s: %v", path, misses)
+		}
+		replay.Close()
+	})
+	return replay.URL
+}
+
+// transferCall is a Balances.transfer_keep_alive of value to dest
+type transferCall struct {
+	dest  types.Address
+	value types.UCompact
+}
+
+func (c transferCall) Method() string      { return config.MethodTransferKeepAlive }
+func (c transferCall) Args() []interface{} { return []interface{}{c.dest, c.value} }
+
+// assertFree checks the free balance of who at the latest block
+func assertFree(t *testing.T, sc *client.GsrpcClient, who types.AccountID, expected *big.Int) {
+	free, err := sc.FreeBalance(who[:])
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), free.String())
+}
+
+// The fixtures were recorded with GSRPC_RECORD=sim, a dev node at the live endpoint with funded Alice and Bob records
+// equivalent ones
+func TestReplay_BatchTransfer(t *testing.T) {
+	endpoint := replayEndpoint(t, "batch_transfer", "ws://127.0.0.1:9944", types.NewAccountID(AliceKey.PublicKey))
+
+	sc, err := client.NewGsrpcClient(client.ChainTypeStafi, endpoint, "", client.AddressTypeAccountId, AliceKey, tlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	less := types.NewAccountID(types.MustHexDecodeString("0x3673009bdb664a3f3b6d9f69c9dd37fc0473551a249aa48542408b016ec62b2e"))
+	jun := types.NewAccountID(types.MustHexDecodeString("0x765f3681fcc33aba624a09833455a3fd971d6791a8f2c57440626cd119530860"))
+
+	amount, _ := utils.StringToBigint("3000" + "000000000000")
+	value := types.NewUCompact(amount)
+
+	calls := make([]types.Call, 0)
+	for _, to := range []types.AccountID{less, jun} {
+		ext, err := client.NewCallExtrinsic(sc, transferCall{dest: types.NewAddressFromAccountID(to[:]), value: value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		calls = append(calls, ext.(*types.Extrinsic).Method)
+	}
+
+	ext, err := sc.NewUnsignedExtrinsic(config.MethodBatch, calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockHash, err := sc.SignAndSubmitTxInBlock(context.Background(), ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := sc.GetChainEvents(blockHash.Hex())
+	assert.NoError(t, err)
+	names := make([]string, 0, len(events))
+	for _, evt := range events {
+		names = append(names, evt.ModuleId+"."+evt.EventId)
+	}
+	assert.Equal(t, []string{"Balances.Transfer", "Balances.Transfer", "Utility.BatchCompleted", "System.ExtrinsicSuccess"}, names)
+	assertFree(t, sc, less, amount)
+	assertFree(t, sc, jun, amount)
+}
+
+func TestReplay_Multisig(t *testing.T) {
+	bobKey := keystore.TestKeyRing.SubstrateKeys[keystore.BobKey].AsKeyringPair()
+	alice, bob := types.NewAccountID(AliceKey.PublicKey), types.NewAccountID(bobKey.PublicKey)
+	signatories := []types.AccountID{alice, bob}
+	multi, err := client.MultisigAccount(signatories, 2)
+	assert.NoError(t, err)
+	endpoint := replayEndpoint(t, "multisig", "ws://127.0.0.1:9944", alice, bob, multi)
+
+	aliceSc, err := client.NewGsrpcClient(client.ChainTypeStafi, endpoint, "", client.AddressTypeAccountId, AliceKey, tlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobSc, err := client.NewGsrpcClient(client.ChainTypeStafi, endpoint, "", client.AddressTypeAccountId, bobKey, tlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	charlie := types.NewAccountID(types.MustHexDecodeString("0x90b5ab205c6974c9ea841be688864633dc9ca8a357843eeacf2314649965fe22"))
+	amount := big.NewInt(5000000000000)
+	tx := &client.MultisigTx{
+		Signatories: signatories,
+		Threshold:   2,
+		Call:        transferCall{dest: types.NewAddressFromAccountID(charlie[:]), value: types.NewUCompact(amount)},
+	}
+
+	first, err := client.SubmitMultisig(aliceSc, tx, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, first.Final)
+	assert.Equal(t, multi, first.Account)
+	pending, err := client.MultisigOf(bobSc, multi, first.CallHash)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.AccountID{alice}, pending.Approvals)
+
+	last, err := client.SubmitMultisig(bobSc, tx, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, last.Final)
+	assert.Equal(t, client.NewOptionTimePoint(pending.When), last.TimePoint)
+
+	latest, err := bobSc.GetLatestBlockNumber()
+	assert.NoError(t, err)
+	executed, err := client.FindMultisigExecuted(bobSc, multi, first.CallHash, uint64(pending.When.Height), latest)
+	assert.NoError(t, err)
+	if assert.NotNil(t, executed) {
+		assert.True(t, executed.Result)
+		assert.Equal(t, pending.When, executed.TimePoint)
+	}
+	_, err = client.MultisigOf(bobSc, multi, first.CallHash)
+	assert.Equal(t, client.ErrorValueNotExist, err)
+	assertFree(t, bobSc, charlie, amount)
+}
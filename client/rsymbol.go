@@ -1,13 +1,19 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 type RSymbol string
 
+// the fixed aliases of the on-chain RSymbol variants, their indexes never change
 const (
 	RFIS   = RSymbol("RFIS")
 	RDOT   = RSymbol("RDOT")
@@ -19,83 +25,186 @@ const (
 	RETH   = RSymbol("RETH")
 )
 
-func (r *RSymbol) Decode(decoder scale.Decoder) error {
-	b, err := decoder.ReadOneByte()
+// rSymbolAliases are the fixed aliases by index
+var rSymbolAliases = [...]RSymbol{RFIS, RDOT, RKSM, RATOM, RSOL, RMATIC, RBNB, RETH}
+
+// RSymbolByIndex returns the fixed alias at index i. Other indexes are kept as "RSymbol(i)", which encodes back to i,
+// the RSymbolRegistry of the chain names them.
+func RSymbolByIndex(i uint8) RSymbol {
+	if int(i) < len(rSymbolAliases) {
+		return rSymbolAliases[i]
+	}
+	return RSymbol(fmt.Sprintf("RSymbol(%d)", i))
+}
+
+// Index returns the index of the variant in the on-chain enum, r is a fixed alias or "RSymbol(i)"
+func (r RSymbol) Index() (uint8, error) {
+	for i, alias := range rSymbolAliases {
+		if r == alias {
+			return uint8(i), nil
+		}
+	}
+
+	var raw uint8
+	if _, err := fmt.Sscanf(string(r), "RSymbol(%d)", &raw); err == nil && RSymbol(fmt.Sprintf("RSymbol(%d)", raw)) == r {
+		return raw, nil
+	}
+	return 0, fmt.Errorf("RSymbol %s not supported", r)
+}
+
+// RSymbolRegistry holds the RSymbol variants of a chain, the fixed aliases merged with the variants of its types file
+// and metadata
+type RSymbolRegistry struct {
+	mu      sync.RWMutex
+	byIndex map[uint8]RSymbol
+	byName  map[RSymbol]uint8
+}
+
+// NewRSymbolRegistry returns a registry with the fixed aliases only
+func NewRSymbolRegistry() *RSymbolRegistry {
+	reg := &RSymbolRegistry{byIndex: make(map[uint8]RSymbol), byName: make(map[RSymbol]uint8)}
+	for i, alias := range rSymbolAliases {
+		reg.byIndex[uint8(i)] = alias
+		reg.byName[alias] = uint8(i)
+	}
+	return reg
+}
+
+// Merge adds the variants by index, a variant replaces the one merged before at its index. Variants that contradict
+// the fixed aliases are left out and returned as an error, the others are merged anyway.
+func (reg *RSymbolRegistry) Merge(variants map[uint8]RSymbol) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	conflicts := make([]string, 0)
+	for i, name := range variants {
+		if int(i) < len(rSymbolAliases) || name.isAlias() {
+			if int(i) >= len(rSymbolAliases) || rSymbolAliases[i] != name {
+				conflicts = append(conflicts, fmt.Sprintf("%s at %d", name, i))
+			}
+			continue
+		}
+		if old, exist := reg.byIndex[i]; exist {
+			delete(reg.byName, old)
+		}
+		reg.byIndex[i] = name
+		reg.byName[name] = i
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("RSymbol variants contradict the fixed aliases: %s", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// LoadTypes merges the value_list of the RSymbol enum of a types file, the file is left alone if it does not define
+// RSymbol as an enum
+func (reg *RSymbolRegistry) LoadTypes(content []byte) error {
+	var defs map[string]json.RawMessage
+	if err := json.Unmarshal(content, &defs); err != nil {
+		return err
+	}
+	raw, exist := defs["RSymbol"]
+	if !exist {
+		return nil
+	}
+	var def struct {
+		Type      string   `json:"type"`
+		ValueList []string `json:"value_list"`
+	}
+	if err := json.Unmarshal(raw, &def); err != nil || def.Type != "enum" || len(def.ValueList) == 0 {
+		return nil
+	}
+	if len(def.ValueList) > 256 {
+		return fmt.Errorf("RSymbol has %d variants", len(def.ValueList))
+	}
+
+	variants := make(map[uint8]RSymbol, len(def.ValueList))
+	for i, name := range def.ValueList {
+		variants[uint8(i)] = RSymbol(name)
+	}
+	return reg.Merge(variants)
+}
+
+// LoadMetadata merges the variants of the RSymbol enum in the type registry of V14+ metadata. Older metadata has no
+// type registry and leaves the registry as it is.
+func (reg *RSymbolRegistry) LoadMetadata(raw []byte) error {
+	enum, err := types.FindMetadataEnum(raw, "RSymbol")
+	if err == types.ErrNoTypeRegistry {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
-	switch b {
-	case 0:
-		*r = RFIS
-		// return fmt.Errorf("RSymbol decode error: %d", b)
-	case 1:
-		*r = RDOT
-	case 2:
-		*r = RKSM
-	case 3:
-		*r = RATOM
-	case 4:
-		*r = RSOL
-	case 5:
-		*r = RMATIC
-	case 6:
-		*r = RBNB
-	case 7:
-		*r = RETH
-	default:
-		return fmt.Errorf("RSymbol decode error: %d", b)
+	variants := make(map[uint8]RSymbol, len(enum))
+	for i, name := range enum {
+		variants[i] = RSymbol(name)
+	}
+	return reg.Merge(variants)
+}
+
+// Symbol returns the RSymbol of the variant called name, its fixed alias or "RSymbol(i)"
+func (reg *RSymbolRegistry) Symbol(name string) (RSymbol, error) {
+	reg.mu.RLock()
+	i, exist := reg.byName[RSymbol(name)]
+	reg.mu.RUnlock()
+	if !exist {
+		return "", fmt.Errorf("RSymbol %s not supported", name)
+	}
+	return RSymbolByIndex(i), nil
+}
+
+// Name returns the name of r on the chain, r itself if the chain has no variant at its index
+func (reg *RSymbolRegistry) Name(r RSymbol) RSymbol {
+	i, err := r.Index()
+	if err != nil {
+		return r
+	}
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	if name, exist := reg.byIndex[i]; exist {
+		return name
+	}
+	return r
+}
+
+func (r RSymbol) isAlias() bool {
+	for _, alias := range rSymbolAliases {
+		if r == alias {
+			return true
+		}
 	}
+	return false
+}
 
+func (r *RSymbol) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return err
+	}
+	*r = RSymbolByIndex(b)
 	return nil
 }
 
 func (r RSymbol) Encode(encoder scale.Encoder) error {
-	switch r {
-	case RFIS:
-		return encoder.PushByte(0)
-		// return fmt.Errorf("RFIS not supported")
-	case RDOT:
-		return encoder.PushByte(1)
-	case RKSM:
-		return encoder.PushByte(2)
-	case RATOM:
-		return encoder.PushByte(3)
-	case RSOL:
-		return encoder.PushByte(4)
-	case RMATIC:
-		return encoder.PushByte(5)
-	case RBNB:
-		return encoder.PushByte(6)
-	case RETH:
-		return encoder.PushByte(7)
-	default:
-		return fmt.Errorf("RSymbol %s not supported", r)
+	i, err := r.Index()
+	if err != nil {
+		return err
 	}
+	return encoder.PushByte(i)
 }
 
 // used in db of rtoken-info
 func (r RSymbol) ToRtokenType() int8 {
-	switch r {
-	case RFIS:
-		return (0)
-	case RDOT:
-		return (1)
-	case RKSM:
-		return (2)
-	case RATOM:
-		return (3)
-	case RSOL:
-		return (4)
-	case RMATIC:
-		return (5)
-	case RBNB:
-		return (6)
-	case RETH:
-		return (-1)
-	default:
+	if r == RETH {
+		return -1
+	}
+	if !r.isAlias() {
 		return -2
 	}
+	i, _ := r.Index()
+	return int8(i)
 }
 
 func (r RSymbol) ToString() string {
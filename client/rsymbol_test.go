@@ -0,0 +1,62 @@
+package client
+
+import (
+	"testing"
+
+	stafi_decoder "github.com/stafiprotocol/go-substrate-rpc-client/pkg/stafidecoder"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRSymbol(t *testing.T) {
+	for i, r := range []RSymbol{RFIS, RDOT, RKSM, RATOM, RSOL, RMATIC, RBNB, RETH} {
+		bz, err := types.EncodeToBytes(r)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{byte(i)}, bz)
+	}
+	assert.Equal(t, int8(-1), RETH.ToRtokenType())
+	assert.Equal(t, int8(2), RKSM.ToRtokenType())
+
+	// unknown variants keep their index
+	var r RSymbol
+	assert.NoError(t, types.DecodeFromBytes([]byte{9}, &r))
+	assert.Equal(t, RSymbol("RSymbol(9)"), r)
+	bz, err := types.EncodeToBytes(r)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{9}, bz)
+	assert.Equal(t, int8(-2), r.ToRtokenType())
+
+	_, err = types.EncodeToBytes(RSymbol("RNEW"))
+	assert.EqualError(t, err, "RSymbol RNEW not supported")
+}
+
+func TestRSymbolRegistry(t *testing.T) {
+	reg := NewRSymbolRegistry()
+	assert.NoError(t, reg.LoadTypes([]byte(stafi_decoder.DefaultStafiCustumTypes)))
+	assert.NoError(t, reg.Merge(map[uint8]RSymbol{0: RFIS, 8: "RNEW"}))
+
+	r, err := reg.Symbol("RNEW")
+	assert.NoError(t, err)
+	assert.Equal(t, RSymbol("RSymbol(8)"), r)
+	assert.Equal(t, RSymbol("RNEW"), reg.Name(r))
+	bz, err := types.EncodeToBytes(r)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{8}, bz)
+	r, err = reg.Symbol("RKSM")
+	assert.NoError(t, err)
+	assert.Equal(t, RKSM, r)
+	assert.Equal(t, RSymbol("RSymbol(9)"), reg.Name("RSymbol(9)"))
+
+	// the fixed aliases are kept, the other variants are merged
+	err = reg.LoadTypes([]byte(`{"RSymbol": {"type": "enum", "value_list": ["RFIS", "RDOT", "RNEW", "RATOM", "RSOL", "RMATIC", "RBNB", "RETH", "RKSM", "RNEWER"]}}`))
+	assert.EqualError(t, err, "RSymbol variants contradict the fixed aliases: RKSM at 8, RNEW at 2")
+	bz, err = types.EncodeToBytes(RKSM)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{2}, bz)
+	assert.Equal(t, RSymbol("RNEWER"), reg.Name("RSymbol(9)"))
+	assert.Equal(t, RSymbol("RNEW"), reg.Name("RSymbol(8)"))
+
+	// registries of different chains are independent
+	_, err = NewRSymbolRegistry().Symbol("RNEW")
+	assert.EqualError(t, err, "RSymbol RNEW not supported")
+}
@@ -0,0 +1,439 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/scale"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// PoolBondState is where the snapshot of a pool is in the era cycle of RTokenLedger
+type PoolBondState uint8
+
+const (
+	BondStateEraUpdated PoolBondState = iota
+	BondStateBondReported
+	BondStateActiveReported
+	BondStateWithdrawSkipped
+	BondStateWithdrawReported
+	BondStateTransferReported
+)
+
+var poolBondStateNames = []string{"EraUpdated", "BondReported", "ActiveReported", "WithdrawSkipped",
+	"WithdrawReported", "TransferReported"}
+
+func (s PoolBondState) String() string {
+	if int(s) < len(poolBondStateNames) {
+		return poolBondStateNames[s]
+	}
+	return fmt.Sprintf("PoolBondState(%d)", uint8(s))
+}
+
+func (s *PoolBondState) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return err
+	}
+	if int(b) >= len(poolBondStateNames) {
+		return fmt.Errorf("PoolBondState decode error: %d", b)
+	}
+	*s = PoolBondState(b)
+	return nil
+}
+
+func (s PoolBondState) Encode(encoder scale.Encoder) error {
+	return encoder.PushByte(byte(s))
+}
+
+// BondAction tells RTokenLedger what the relayers did with the bond and unbond of a snapshot
+type BondAction uint8
+
+const (
+	BondOnly BondAction = iota
+	UnbondOnly
+	BothBondUnbond
+	EitherBondUnbond
+	InterDeduct
+)
+
+var bondActionNames = []string{"BondOnly", "UnbondOnly", "BothBondUnbond", "EitherBondUnbond", "InterDeduct"}
+
+func (a BondAction) String() string {
+	if int(a) < len(bondActionNames) {
+		return bondActionNames[a]
+	}
+	return fmt.Sprintf("BondAction(%d)", uint8(a))
+}
+
+func (a *BondAction) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return err
+	}
+	if int(b) >= len(bondActionNames) {
+		return fmt.Errorf("BondAction decode error: %d", b)
+	}
+	*a = BondAction(b)
+	return nil
+}
+
+func (a BondAction) Encode(encoder scale.Encoder) error {
+	if int(a) >= len(bondActionNames) {
+		return fmt.Errorf("BondAction %d not supported", uint8(a))
+	}
+	return encoder.PushByte(byte(a))
+}
+
+// BondSnapshot is the state of a pool for one era, stored in RTokenLedger.Snapshots by shot id
+type BondSnapshot struct {
+	Symbol    RSymbol
+	Era       uint32
+	Pool      types.Bytes
+	Bond      types.U128
+	Unbond    types.U128
+	Active    types.U128
+	LastVoter types.AccountID
+	BondState PoolBondState
+}
+
+// Unbonding is an unbond of a user waiting in RTokenLedger.PoolUnbonds
+type Unbonding struct {
+	Who       types.AccountID
+	Value     types.U128
+	Recipient types.Bytes
+}
+
+// PoolUnbondKey is the second key of RTokenLedger.PoolUnbonds
+type PoolUnbondKey struct {
+	Pool types.Bytes
+	Era  uint32
+}
+
+// BondReportCall is RTokenLedger.bond_report
+type BondReportCall struct {
+	Symbol RSymbol
+	ShotId types.Hash
+	Action BondAction
+}
+
+func (c BondReportCall) Method() string { return config.MethodBondReport }
+func (c BondReportCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.ShotId, c.Action}
+}
+
+// NewBondReportCall is RTokenLedger.new_bond_report
+type NewBondReportCall struct {
+	Symbol RSymbol
+	ShotId types.Hash
+	Action BondAction
+}
+
+func (c NewBondReportCall) Method() string { return config.MethodNewBondReport }
+func (c NewBondReportCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.ShotId, c.Action}
+}
+
+// ActiveReportCall is RTokenLedger.active_report
+type ActiveReportCall struct {
+	Symbol RSymbol
+	ShotId types.Hash
+	Active types.U128
+}
+
+func (c ActiveReportCall) Method() string { return config.MethodActiveReport }
+func (c ActiveReportCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.ShotId, c.Active}
+}
+
+// NewActiveReportCall is RTokenLedger.new_active_report, which follows new_bond_report
+type NewActiveReportCall struct {
+	Symbol  RSymbol
+	ShotId  types.Hash
+	Active  types.U128
+	Unstake types.U128
+}
+
+func (c NewActiveReportCall) Method() string { return config.MethodNewActiveReport }
+func (c NewActiveReportCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.ShotId, c.Active, c.Unstake}
+}
+
+// BondAndReportActiveCall is RTokenLedger.bond_and_report_active, the bond and active reports in one call
+type BondAndReportActiveCall struct {
+	Symbol  RSymbol
+	ShotId  types.Hash
+	Action  BondAction
+	Active  types.U128
+	Unstake types.U128
+}
+
+func (c BondAndReportActiveCall) Method() string { return config.MethodBondAndReportActive }
+func (c BondAndReportActiveCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.ShotId, c.Action, c.Active, c.Unstake}
+}
+
+// BondAndReportActiveWithPendingValueCall is RTokenLedger.bond_and_report_active_with_pending_value, which also
+// reports the stake and reward still pending on the pool
+type BondAndReportActiveWithPendingValueCall struct {
+	Symbol        RSymbol
+	ShotId        types.Hash
+	Action        BondAction
+	Active        types.U128
+	Unstake       types.U128
+	PendingStake  types.U128
+	PendingReward types.U128
+}
+
+func (c BondAndReportActiveWithPendingValueCall) Method() string {
+	return config.MethodBondAndReportActiveWithPendingValue
+}
+func (c BondAndReportActiveWithPendingValueCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.ShotId, c.Action, c.Active, c.Unstake, c.PendingStake, c.PendingReward}
+}
+
+// WithdrawReportCall is RTokenLedger.withdraw_report
+type WithdrawReportCall struct {
+	Symbol RSymbol
+	ShotId types.Hash
+}
+
+func (c WithdrawReportCall) Method() string { return config.MethodWithdrawReport }
+func (c WithdrawReportCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.ShotId}
+}
+
+// TransferReportCall is RTokenLedger.transfer_report
+type TransferReportCall struct {
+	Symbol RSymbol
+	ShotId types.Hash
+}
+
+func (c TransferReportCall) Method() string { return config.MethodTransferReport }
+func (c TransferReportCall) Args() []interface{} {
+	return []interface{}{c.Symbol, c.ShotId}
+}
+
+// ReportKind is a step of the era cycle of a snapshot
+type ReportKind int
+
+const (
+	// ReportNone means the snapshot is done for its era
+	ReportNone ReportKind = iota
+	ReportBond
+	ReportActive
+	ReportWithdraw
+	ReportTransfer
+)
+
+func (k ReportKind) String() string {
+	switch k {
+	case ReportNone:
+		return "none"
+	case ReportBond:
+		return "bond"
+	case ReportActive:
+		return "active"
+	case ReportWithdraw:
+		return "withdraw"
+	case ReportTransfer:
+		return "transfer"
+	default:
+		return fmt.Sprintf("ReportKind(%d)", int(k))
+	}
+}
+
+// NextReport returns the report due for a snapshot in the state it is. The cycle is EraUpdated -> bond ->
+// BondReported -> active -> ActiveReported -> withdraw -> WithdrawReported -> transfer -> TransferReported, an
+// active report without unbond ends the cycle with WithdrawSkipped.
+func (s *BondSnapshot) NextReport() ReportKind {
+	switch s.BondState {
+	case BondStateEraUpdated:
+		return ReportBond
+	case BondStateBondReported:
+		return ReportActive
+	case BondStateActiveReported:
+		return ReportWithdraw
+	case BondStateWithdrawReported:
+		return ReportTransfer
+	default:
+		return ReportNone
+	}
+}
+
+// ReportFlow is the set of report calls a runtime takes for the bond and active steps
+type ReportFlow int
+
+const (
+	// ReportFlowOld reports with bond_report and active_report
+	ReportFlowOld ReportFlow = iota
+	// ReportFlowNew reports with new_bond_report and new_active_report
+	ReportFlowNew
+	// ReportFlowBondAndActive reports the bond and active steps at once with bond_and_report_active
+	ReportFlowBondAndActive
+	// ReportFlowBondAndActiveWithPendingValue is ReportFlowBondAndActive with
+	// bond_and_report_active_with_pending_value
+	ReportFlowBondAndActiveWithPendingValue
+)
+
+// ReportValues are what the relayers observed on the pool, each report takes the ones it needs
+type ReportValues struct {
+	Action        BondAction
+	Active        types.U128
+	Unstake       types.U128
+	PendingStake  types.U128
+	PendingReward types.U128
+}
+
+// Report returns the call of the report due for the snapshot shotId in the given flow, nil if none is. The flows
+// that report bond and active at once use new_active_report if the bond was reported on its own.
+func (s *BondSnapshot) Report(shotId types.Hash, flow ReportFlow, v ReportValues) CallBuilder {
+	switch s.NextReport() {
+	case ReportBond:
+		switch flow {
+		case ReportFlowNew:
+			return NewBondReportCall{Symbol: s.Symbol, ShotId: shotId, Action: v.Action}
+		case ReportFlowBondAndActive:
+			return BondAndReportActiveCall{Symbol: s.Symbol, ShotId: shotId, Action: v.Action, Active: v.Active,
+				Unstake: v.Unstake}
+		case ReportFlowBondAndActiveWithPendingValue:
+			return BondAndReportActiveWithPendingValueCall{Symbol: s.Symbol, ShotId: shotId, Action: v.Action,
+				Active: v.Active, Unstake: v.Unstake, PendingStake: v.PendingStake, PendingReward: v.PendingReward}
+		default:
+			return BondReportCall{Symbol: s.Symbol, ShotId: shotId, Action: v.Action}
+		}
+	case ReportActive:
+		if flow == ReportFlowOld {
+			return ActiveReportCall{Symbol: s.Symbol, ShotId: shotId, Active: v.Active}
+		}
+		return NewActiveReportCall{Symbol: s.Symbol, ShotId: shotId, Active: v.Active, Unstake: v.Unstake}
+	case ReportWithdraw:
+		return WithdrawReportCall{Symbol: s.Symbol, ShotId: shotId}
+	case ReportTransfer:
+		return TransferReportCall{Symbol: s.Symbol, ShotId: shotId}
+	default:
+		return nil
+	}
+}
+
+func (sc *GsrpcClient) Snapshot(symbol RSymbol, shotId types.Hash, blockHash ...types.Hash) (*BondSnapshot, error) {
+	return sc.SnapshotContext(context.Background(), symbol, shotId, blockHash...)
+}
+
+func (sc *GsrpcClient) SnapshotContext(ctx context.Context, symbol RSymbol, shotId types.Hash, blockHash ...types.Hash) (*BondSnapshot, error) {
+	symBz, err := types.EncodeToBytes(symbol)
+	if err != nil {
+		return nil, err
+	}
+	shotIdBz, err := types.EncodeToBytes(shotId)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := new(BondSnapshot)
+	exists, err := sc.QueryStorageContext(ctx, config.RTokenLedgerModuleId, config.StorageSnapshots, symBz, shotIdBz, snap, blockHash...)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrorValueNotExist
+	}
+	return snap, nil
+}
+
+func (sc *GsrpcClient) BondedPools(symbol RSymbol, blockHash ...types.Hash) ([]types.Bytes, error) {
+	return sc.BondedPoolsContext(context.Background(), symbol, blockHash...)
+}
+
+func (sc *GsrpcClient) BondedPoolsContext(ctx context.Context, symbol RSymbol, blockHash ...types.Hash) ([]types.Bytes, error) {
+	symBz, err := types.EncodeToBytes(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make([]types.Bytes, 0)
+	exists, err := sc.QueryStorageContext(ctx, config.RTokenLedgerModuleId, config.StorageBondedPools, symBz, nil, &pools, blockHash...)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrorValueNotExist
+	}
+	return pools, nil
+}
+
+func (sc *GsrpcClient) PoolUnbonds(symbol RSymbol, pool []byte, era uint32, blockHash ...types.Hash) ([]Unbonding, error) {
+	return sc.PoolUnbondsContext(context.Background(), symbol, pool, era, blockHash...)
+}
+
+func (sc *GsrpcClient) PoolUnbondsContext(ctx context.Context, symbol RSymbol, pool []byte, era uint32, blockHash ...types.Hash) ([]Unbonding, error) {
+	symBz, err := types.EncodeToBytes(symbol)
+	if err != nil {
+		return nil, err
+	}
+	keyBz, err := types.EncodeToBytes(PoolUnbondKey{Pool: pool, Era: era})
+	if err != nil {
+		return nil, err
+	}
+
+	unbonds := make([]Unbonding, 0)
+	exists, err := sc.QueryStorageContext(ctx, config.RTokenLedgerModuleId, config.StoragePoolUnbonds, symBz, keyBz, &unbonds, blockHash...)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrorValueNotExist
+	}
+	return unbonds, nil
+}
+
+func (sc *GsrpcClient) SubAccounts(symbol RSymbol, pool []byte, blockHash ...types.Hash) ([]types.Bytes, error) {
+	return sc.SubAccountsContext(context.Background(), symbol, pool, blockHash...)
+}
+
+func (sc *GsrpcClient) SubAccountsContext(ctx context.Context, symbol RSymbol, pool []byte, blockHash ...types.Hash) ([]types.Bytes, error) {
+	symBz, err := types.EncodeToBytes(symbol)
+	if err != nil {
+		return nil, err
+	}
+	poolBz, err := types.EncodeToBytes(types.NewBytes(pool))
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]types.Bytes, 0)
+	exists, err := sc.QueryStorageContext(ctx, config.RTokenLedgerModuleId, config.StorageSubAccounts, symBz, poolBz, &subs, blockHash...)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrorValueNotExist
+	}
+	return subs, nil
+}
+
+func (sc *GsrpcClient) MultiThreshold(symbol RSymbol, pool []byte, blockHash ...types.Hash) (uint16, error) {
+	return sc.MultiThresholdContext(context.Background(), symbol, pool, blockHash...)
+}
+
+func (sc *GsrpcClient) MultiThresholdContext(ctx context.Context, symbol RSymbol, pool []byte, blockHash ...types.Hash) (uint16, error) {
+	symBz, err := types.EncodeToBytes(symbol)
+	if err != nil {
+		return 0, err
+	}
+	poolBz, err := types.EncodeToBytes(types.NewBytes(pool))
+	if err != nil {
+		return 0, err
+	}
+
+	var threshold uint16
+	exists, err := sc.QueryStorageContext(ctx, config.RTokenLedgerModuleId, config.StorageMultiThresholds, symBz, poolBz, &threshold, blockHash...)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, ErrorValueNotExist
+	}
+	return threshold, nil
+}
@@ -0,0 +1,185 @@
+package client_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stafiprotocol/go-substrate-rpc-client/xxhash"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+)
+
+// ledgerStorage is an RTokenLedger storage item, maps hash their first key with Twox64Concat and their second
+// key with Blake2_128Concat
+func ledgerStorage(name string, keys ...string) types.StorageFunctionMetadataV13 {
+	item := types.StorageFunctionMetadataV13{
+		Name:     types.Text(name),
+		Modifier: types.StorageFunctionModifierV0{IsOptional: true},
+		Fallback: types.Bytes{0x00},
+	}
+	switch len(keys) {
+	case 1:
+		item.Type = types.StorageFunctionTypeV13{IsMap: true, AsMap: types.MapTypeV10{
+			Hasher: types.StorageHasherV10{IsTwox64Concat: true}, Key: types.Type(keys[0]), Value: "Vec<u8>"}}
+	case 2:
+		item.Type = types.StorageFunctionTypeV13{IsDoubleMap: true, AsDoubleMap: types.DoubleMapTypeV10{
+			Hasher: types.StorageHasherV10{IsTwox64Concat: true}, Key1: types.Type(keys[0]), Key2: types.Type(keys[1]),
+			Value: "Vec<u8>", Key2Hasher: types.StorageHasherV10{IsBlake2_128Concat: true}}}
+	}
+	return item
+}
+
+// ledgerKey builds the key of an RTokenLedger item by hand to check the keys the client derives
+func ledgerKey(method string, key1, key2 []byte) string {
+	key := append(xxhash.New128([]byte(config.RTokenLedgerModuleId)).Sum(nil), xxhash.New128([]byte(method)).Sum(nil)...)
+	key = append(append(key, xxhash.New64(key1).Sum(nil)...), key1...)
+	if key2 != nil {
+		h, _ := blake2b.New(16, nil)
+		h.Write(key2)
+		key = append(append(key, h.Sum(nil)...), key2...)
+	}
+	return types.HexEncodeToString(key)
+}
+
+// seriesKey builds the key of an RTokenSeries double map, keyed by account with Blake2_128Concat and by symbol with
+// Twox64Concat
+func seriesKey(method string, who, sym []byte) string {
+	key := append(xxhash.New128([]byte(config.RTokenSeriesModuleId)).Sum(nil), xxhash.New128([]byte(method)).Sum(nil)...)
+	h, _ := blake2b.New(16, nil)
+	h.Write(who)
+	key = append(append(key, h.Sum(nil)...), who...)
+	return types.HexEncodeToString(append(append(key, xxhash.New64(sym).Sum(nil)...), sym...))
+}
+
+func TestLedgerQueries(t *testing.T) {
+	meta := types.NewMetadataV13()
+	assert.NoError(t, types.DecodeFromBytes(types.MustHexDecodeString(types.ExamplaryMetadataV13SubstrateString), meta))
+	meta.AsMetadataV13.Modules = append(meta.AsMetadataV13.Modules, types.ModuleMetadataV13{
+		Name:       config.RTokenLedgerModuleId,
+		HasStorage: true,
+		Storage: types.StorageMetadataV13{Prefix: config.RTokenLedgerModuleId, Items: []types.StorageFunctionMetadataV13{
+			ledgerStorage(config.StorageSnapshots, "RSymbol", "Hash"),
+			ledgerStorage(config.StorageBondedPools, "RSymbol"),
+			ledgerStorage(config.StoragePoolUnbonds, "RSymbol", "(Vec<u8>, u32)"),
+			ledgerStorage(config.StorageSubAccounts, "RSymbol", "Vec<u8>"),
+			ledgerStorage(config.StorageMultiThresholds, "RSymbol", "Vec<u8>"),
+			ledgerStorage(config.StorageChainBondingDuration, "RSymbol"),
+		}},
+		Index: 50,
+	}, types.ModuleMetadataV13{
+		Name:       config.RTokenSeriesModuleId,
+		HasStorage: true,
+		Storage: types.StorageMetadataV13{Prefix: config.RTokenSeriesModuleId, Items: []types.StorageFunctionMetadataV13{{
+			Name:     config.StorageAccountUnbonds,
+			Modifier: types.StorageFunctionModifierV0{IsOptional: true},
+			Fallback: types.Bytes{0x00},
+			Type: types.StorageFunctionTypeV13{IsDoubleMap: true, AsDoubleMap: types.DoubleMapTypeV10{
+				Hasher: types.StorageHasherV10{IsBlake2_128Concat: true}, Key1: "AccountId", Key2: "RSymbol",
+				Value: "Vec<UserUnlockChunk>", Key2Hasher: types.StorageHasherV10{IsTwox64Concat: true}}},
+		}}},
+		Index: 51,
+	})
+	metaBz, err := types.EncodeToBytes(meta)
+	assert.NoError(t, err)
+
+	pool := []byte{0xaa, 0xbb}
+	shotId := types.NewHash([]byte{0x01, 0x02})
+	snap := client.BondSnapshot{
+		Symbol:    client.RKSM,
+		Era:       7,
+		Pool:      pool,
+		Bond:      types.NewU128(*big.NewInt(100)),
+		Unbond:    types.NewU128(*big.NewInt(20)),
+		Active:    types.NewU128(*big.NewInt(1000)),
+		LastVoter: types.NewAccountID(AliceKey.PublicKey),
+		BondState: client.BondStateBondReported,
+	}
+	unbonds := []client.Unbonding{{
+		Who:       types.NewAccountID(AliceKey.PublicKey),
+		Value:     types.NewU128(*big.NewInt(5)),
+		Recipient: types.Bytes{0x0c},
+	}}
+	subs := []types.Bytes{{0x01}, {0x02}}
+	alice := types.NewAccountID(AliceKey.PublicKey)
+	chunks := []client.UserUnlockChunk{{
+		Pool:      pool,
+		UnlockEra: 12,
+		Value:     types.NewU128(*big.NewInt(30)),
+		Recipient: types.Bytes{0x0d},
+	}}
+
+	// RKSM is variant 2, pools are Vec<u8> and the pool unbond key is the tuple (pool, era)
+	sym := []byte{0x02}
+	bzPool := append([]byte{0x08}, pool...)
+	genesis := map[string][]byte{
+		ledgerKey(config.StorageSnapshots, sym, shotId[:]):                    mustEncode(t, snap),
+		ledgerKey(config.StorageBondedPools, sym, nil):                        mustEncode(t, []types.Bytes{pool}),
+		ledgerKey(config.StoragePoolUnbonds, sym, append(bzPool, 9, 0, 0, 0)): mustEncode(t, unbonds),
+		ledgerKey(config.StorageSubAccounts, sym, bzPool):                     mustEncode(t, subs),
+		ledgerKey(config.StorageMultiThresholds, sym, bzPool):                 mustEncode(t, uint16(2)),
+		ledgerKey(config.StorageChainBondingDuration, sym, nil):               mustEncode(t, uint32(28)),
+		seriesKey(config.StorageAccountUnbonds, alice[:], sym):                mustEncode(t, chunks),
+	}
+	node, err := rpcmocksrv.NewNode(rpcmocksrv.NodeConfig{Genesis: genesis, Metadata: types.HexEncodeToString(metaBz)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+	sc, err := client.NewGsrpcClient(client.ChainTypeStafi, node.URL, "", client.AddressTypeAccountId, AliceKey, tlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotSnap, err := sc.Snapshot(client.RKSM, shotId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, snap, *gotSnap)
+	}
+	_, err = sc.Snapshot(client.RKSM, types.NewHash([]byte{0x03}))
+	assert.Equal(t, client.ErrorValueNotExist, err)
+
+	pools, err := sc.BondedPools(client.RKSM)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Bytes{pool}, pools)
+	_, err = sc.BondedPools(client.RATOM)
+	assert.Equal(t, client.ErrorValueNotExist, err)
+
+	gotUnbonds, err := sc.PoolUnbonds(client.RKSM, pool, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, unbonds, gotUnbonds)
+	_, err = sc.PoolUnbonds(client.RKSM, pool, 11)
+	assert.Equal(t, client.ErrorValueNotExist, err)
+
+	gotSubs, err := sc.SubAccounts(client.RKSM, pool)
+	assert.NoError(t, err)
+	assert.Equal(t, subs, gotSubs)
+
+	threshold, err := sc.MultiThreshold(client.RKSM, pool)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(2), threshold)
+	_, err = sc.MultiThreshold(client.RKSM, []byte{0xcc})
+	assert.Equal(t, client.ErrorValueNotExist, err)
+
+	gotChunks, err := sc.AccountUnbonds(client.RKSM, alice)
+	assert.NoError(t, err)
+	assert.Equal(t, chunks, gotChunks)
+	_, err = sc.AccountUnbonds(client.RDOT, alice)
+	assert.Equal(t, client.ErrorValueNotExist, err)
+
+	duration, err := sc.UnbondingDuration(client.RKSM)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(28), duration)
+	_, err = sc.UnbondingDuration(client.RATOM)
+	assert.Equal(t, client.ErrorValueNotExist, err)
+}
+
+func mustEncode(t *testing.T, v interface{}) []byte {
+	bz, err := types.EncodeToBytes(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bz
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBondSnapshot_Decode(t *testing.T) {
+	snap := BondSnapshot{
+		Symbol:    RDOT,
+		Era:       123,
+		Pool:      types.Bytes{0x01, 0x02},
+		Bond:      types.NewU128(*big.NewInt(1000)),
+		Unbond:    types.NewU128(*big.NewInt(10)),
+		Active:    types.NewU128(*big.NewInt(5000)),
+		LastVoter: types.NewAccountID([]byte{0x03}),
+		BondState: BondStateActiveReported,
+	}
+	bz, err := types.EncodeToBytes(snap)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(BondStateActiveReported), bz[len(bz)-1])
+
+	var decoded BondSnapshot
+	assert.NoError(t, types.DecodeFromBytes(bz, &decoded))
+	assert.Equal(t, snap, decoded)
+
+	bz[len(bz)-1] = 6
+	assert.ErrorContains(t, types.DecodeFromBytes(bz, &decoded), "PoolBondState decode error: 6")
+}
+
+func TestBondSnapshot_NextReport(t *testing.T) {
+	shotId := types.NewHash([]byte{0x01})
+	active := types.NewU128(*big.NewInt(42))
+	v := ReportValues{Action: InterDeduct, Active: active}
+
+	for _, tc := range []struct {
+		state  PoolBondState
+		kind   ReportKind
+		method string
+		args   []interface{}
+	}{
+		{BondStateEraUpdated, ReportBond, config.MethodBondReport, []interface{}{RKSM, shotId, InterDeduct}},
+		{BondStateBondReported, ReportActive, config.MethodActiveReport, []interface{}{RKSM, shotId, active}},
+		{BondStateActiveReported, ReportWithdraw, config.MethodWithdrawReport, []interface{}{RKSM, shotId}},
+		{BondStateWithdrawReported, ReportTransfer, config.MethodTransferReport, []interface{}{RKSM, shotId}},
+		{BondStateWithdrawSkipped, ReportNone, "", nil},
+		{BondStateTransferReported, ReportNone, "", nil},
+	} {
+		snap := &BondSnapshot{Symbol: RKSM, BondState: tc.state}
+		assert.Equal(t, tc.kind, snap.NextReport(), tc.state.String())
+
+		report := snap.Report(shotId, ReportFlowOld, v)
+		if tc.method == "" {
+			assert.Nil(t, report)
+			continue
+		}
+		assert.Equal(t, tc.method, report.Method())
+		assert.Equal(t, tc.args, report.Args())
+	}
+}
+
+func TestBondSnapshot_ReportFlow(t *testing.T) {
+	shotId := types.NewHash([]byte{0x01})
+	v := ReportValues{
+		Action:        BondOnly,
+		Active:        types.NewU128(*big.NewInt(42)),
+		Unstake:       types.NewU128(*big.NewInt(3)),
+		PendingStake:  types.NewU128(*big.NewInt(7)),
+		PendingReward: types.NewU128(*big.NewInt(1)),
+	}
+	eraUpdated := &BondSnapshot{Symbol: RKSM, BondState: BondStateEraUpdated}
+	bondReported := &BondSnapshot{Symbol: RKSM, BondState: BondStateBondReported}
+
+	for _, tc := range []struct {
+		flow   ReportFlow
+		snap   *BondSnapshot
+		method string
+		args   []interface{}
+	}{
+		{ReportFlowNew, eraUpdated, config.MethodNewBondReport, []interface{}{RKSM, shotId, BondOnly}},
+		// new_bond_report is followed by new_active_report, which also takes the unstake
+		{ReportFlowNew, bondReported, config.MethodNewActiveReport, []interface{}{RKSM, shotId, v.Active, v.Unstake}},
+		{ReportFlowBondAndActive, eraUpdated, config.MethodBondAndReportActive,
+			[]interface{}{RKSM, shotId, BondOnly, v.Active, v.Unstake}},
+		{ReportFlowBondAndActive, bondReported, config.MethodNewActiveReport,
+			[]interface{}{RKSM, shotId, v.Active, v.Unstake}},
+		{ReportFlowBondAndActiveWithPendingValue, eraUpdated, config.MethodBondAndReportActiveWithPendingValue,
+			[]interface{}{RKSM, shotId, BondOnly, v.Active, v.Unstake, v.PendingStake, v.PendingReward}},
+	} {
+		report := tc.snap.Report(shotId, tc.flow, v)
+		assert.Equal(t, tc.method, report.Method())
+		assert.Equal(t, tc.args, report.Args())
+	}
+}
@@ -181,9 +181,12 @@ type Era struct {
 }
 
 type ChainEvent struct {
-	ModuleId       string             `json:"module_id" `
-	EventId        string             `json:"event_id" `
-	EventIndex     int                `json:"event_idx"`
+	ModuleId   string `json:"module_id" `
+	EventId    string `json:"event_id" `
+	EventIndex int    `json:"event_idx"`
+	// Phase is 0 for events emitted while applying the extrinsic at ExtrinsicIndex, 1 for finalization and 2 for
+	// initialization
+	Phase          int                `json:"phase"`
 	ExtrinsicIndex int                `json:"extrinsic_idx"`
 	Params         []scale.EventParam `json:"params"`
 }
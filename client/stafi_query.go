@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -8,14 +9,18 @@ import (
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
-func (c *GsrpcClient) CurrentChainEra(sym RSymbol) (uint32, error) {
+func (c *GsrpcClient) CurrentChainEra(sym RSymbol, blockHash ...types.Hash) (uint32, error) {
+	return c.CurrentChainEraContext(context.Background(), sym, blockHash...)
+}
+
+func (c *GsrpcClient) CurrentChainEraContext(ctx context.Context, sym RSymbol, blockHash ...types.Hash) (uint32, error) {
 	symBz, err := types.EncodeToBytes(sym)
 	if err != nil {
 		return 0, err
 	}
 
 	var era uint32
-	exists, err := c.QueryStorage(config.RTokenLedgerModuleId, config.StorageChainEras, symBz, nil, &era)
+	exists, err := c.QueryStorageContext(ctx, config.RTokenLedgerModuleId, config.StorageChainEras, symBz, nil, &era, blockHash...)
 	if err != nil {
 		return 0, err
 	}
@@ -27,14 +32,18 @@ func (c *GsrpcClient) CurrentChainEra(sym RSymbol) (uint32, error) {
 	return era, nil
 }
 
-func (c *GsrpcClient) ActiveChangeRateLimit(sym RSymbol) (uint32, error) {
+func (c *GsrpcClient) ActiveChangeRateLimit(sym RSymbol, blockHash ...types.Hash) (uint32, error) {
+	return c.ActiveChangeRateLimitContext(context.Background(), sym, blockHash...)
+}
+
+func (c *GsrpcClient) ActiveChangeRateLimitContext(ctx context.Context, sym RSymbol, blockHash ...types.Hash) (uint32, error) {
 	symBz, err := types.EncodeToBytes(sym)
 	if err != nil {
 		return 0, err
 	}
 
 	var PerBill types.U32
-	exists, err := c.QueryStorage(config.RTokenLedgerModuleId, config.StorageActiveChangeRateLimit, symBz, nil, &PerBill)
+	exists, err := c.QueryStorageContext(ctx, config.RTokenLedgerModuleId, config.StorageActiveChangeRateLimit, symBz, nil, &PerBill, blockHash...)
 	if err != nil {
 		return 0, err
 	}
@@ -46,14 +55,18 @@ func (c *GsrpcClient) ActiveChangeRateLimit(sym RSymbol) (uint32, error) {
 	return uint32(PerBill), nil
 }
 
-func (c *GsrpcClient) RTokenTotalIssuance(sym RSymbol) (types.U128, error) {
+func (c *GsrpcClient) RTokenTotalIssuance(sym RSymbol, blockHash ...types.Hash) (types.U128, error) {
+	return c.RTokenTotalIssuanceContext(context.Background(), sym, blockHash...)
+}
+
+func (c *GsrpcClient) RTokenTotalIssuanceContext(ctx context.Context, sym RSymbol, blockHash ...types.Hash) (types.U128, error) {
 	symBz, err := types.EncodeToBytes(sym)
 	if err != nil {
 		return types.U128{}, err
 	}
 
 	var issuance types.U128
-	exists, err := c.QueryStorage(config.RTokenBalanceModuleId, config.StorageTotalIssuance, symBz, nil, &issuance)
+	exists, err := c.QueryStorageContext(ctx, config.RTokenBalanceModuleId, config.StorageTotalIssuance, symBz, nil, &issuance, blockHash...)
 	if err != nil {
 		return types.U128{}, err
 	}
@@ -65,14 +78,18 @@ func (c *GsrpcClient) RTokenTotalIssuance(sym RSymbol) (types.U128, error) {
 	return issuance, nil
 }
 
-func (c *GsrpcClient) CurrentEraSnapshots(symbol RSymbol) ([]types.Hash, error) {
+func (c *GsrpcClient) CurrentEraSnapshots(symbol RSymbol, blockHash ...types.Hash) ([]types.Hash, error) {
+	return c.CurrentEraSnapshotsContext(context.Background(), symbol, blockHash...)
+}
+
+func (c *GsrpcClient) CurrentEraSnapshotsContext(ctx context.Context, symbol RSymbol, blockHash ...types.Hash) ([]types.Hash, error) {
 	symBz, err := types.EncodeToBytes(symbol)
 	if err != nil {
 		return nil, err
 	}
 
 	ids := make([]types.Hash, 0)
-	exists, err := c.QueryStorage(config.RTokenLedgerModuleId, config.StorageCurrentEraSnapShots, symBz, nil, &ids)
+	exists, err := c.QueryStorageContext(ctx, config.RTokenLedgerModuleId, config.StorageCurrentEraSnapShots, symBz, nil, &ids, blockHash...)
 	if err != nil {
 		return nil, err
 	}
@@ -82,14 +99,18 @@ func (c *GsrpcClient) CurrentEraSnapshots(symbol RSymbol) ([]types.Hash, error)
 	return ids, nil
 }
 
-func (c *GsrpcClient) ActLatestCycle(sym RSymbol) (uint32, error) {
+func (c *GsrpcClient) ActLatestCycle(sym RSymbol, blockHash ...types.Hash) (uint32, error) {
+	return c.ActLatestCycleContext(context.Background(), sym, blockHash...)
+}
+
+func (c *GsrpcClient) ActLatestCycleContext(ctx context.Context, sym RSymbol, blockHash ...types.Hash) (uint32, error) {
 	symBz, err := types.EncodeToBytes(sym)
 	if err != nil {
 		return 0, err
 	}
 
 	var cycle uint32
-	exists, err := c.QueryStorage(config.RClaimModuleId, config.StorageActLatestCycle, symBz, nil, &cycle)
+	exists, err := c.QueryStorageContext(ctx, config.RClaimModuleId, config.StorageActLatestCycle, symBz, nil, &cycle, blockHash...)
 	if err != nil {
 		return 0, err
 	}
@@ -101,10 +122,14 @@ func (c *GsrpcClient) ActLatestCycle(sym RSymbol) (uint32, error) {
 	return cycle, nil
 }
 
-func (c *GsrpcClient) REthActLatestCycle() (uint32, error) {
+func (c *GsrpcClient) REthActLatestCycle(blockHash ...types.Hash) (uint32, error) {
+	return c.REthActLatestCycleContext(context.Background(), blockHash...)
+}
+
+func (c *GsrpcClient) REthActLatestCycleContext(ctx context.Context, blockHash ...types.Hash) (uint32, error) {
 
 	var cycle uint32
-	exists, err := c.QueryStorage(config.RClaimModuleId, config.StorageREthActLatestCycle, nil, nil, &cycle)
+	exists, err := c.QueryStorageContext(ctx, config.RClaimModuleId, config.StorageREthActLatestCycle, nil, nil, &cycle, blockHash...)
 	if err != nil {
 		return 0, err
 	}
@@ -116,7 +141,11 @@ func (c *GsrpcClient) REthActLatestCycle() (uint32, error) {
 	return cycle, nil
 }
 
-func (c *GsrpcClient) Act(sym RSymbol, cycle uint32) (*MintRewardAct, error) {
+func (c *GsrpcClient) Act(sym RSymbol, cycle uint32, blockHash ...types.Hash) (*MintRewardAct, error) {
+	return c.ActContext(context.Background(), sym, cycle, blockHash...)
+}
+
+func (c *GsrpcClient) ActContext(ctx context.Context, sym RSymbol, cycle uint32, blockHash ...types.Hash) (*MintRewardAct, error) {
 	key := struct {
 		Symbol RSymbol
 		Cycle  uint32
@@ -131,7 +160,7 @@ func (c *GsrpcClient) Act(sym RSymbol, cycle uint32) (*MintRewardAct, error) {
 
 	act := new(MintRewardAct)
 
-	exists, err := c.QueryStorage(config.RClaimModuleId, config.StorageActs, keyBz, nil, act)
+	exists, err := c.QueryStorageContext(ctx, config.RClaimModuleId, config.StorageActs, keyBz, nil, act, blockHash...)
 	if err != nil {
 		return nil, err
 	}
@@ -142,7 +171,11 @@ func (c *GsrpcClient) Act(sym RSymbol, cycle uint32) (*MintRewardAct, error) {
 
 	return act, nil
 }
-func (c *GsrpcClient) RethAct(cycle uint32) (*MintRewardAct, error) {
+func (c *GsrpcClient) RethAct(cycle uint32, blockHash ...types.Hash) (*MintRewardAct, error) {
+	return c.RethActContext(context.Background(), cycle, blockHash...)
+}
+
+func (c *GsrpcClient) RethActContext(ctx context.Context, cycle uint32, blockHash ...types.Hash) (*MintRewardAct, error) {
 	cycleBz, err := types.EncodeToBytes(cycle)
 	if err != nil {
 		return nil, err
@@ -150,7 +183,7 @@ func (c *GsrpcClient) RethAct(cycle uint32) (*MintRewardAct, error) {
 
 	act := new(MintRewardAct)
 
-	exists, err := c.QueryStorage(config.RClaimModuleId, config.StorageREthActs, cycleBz, nil, act)
+	exists, err := c.QueryStorageContext(ctx, config.RClaimModuleId, config.StorageREthActs, cycleBz, nil, act, blockHash...)
 	if err != nil {
 		return nil, err
 	}
@@ -162,7 +195,11 @@ func (c *GsrpcClient) RethAct(cycle uint32) (*MintRewardAct, error) {
 	return act, nil
 }
 
-func (sc *GsrpcClient) GetEraRate(symbol RSymbol, era uint32) (rate uint64, err error) {
+func (sc *GsrpcClient) GetEraRate(symbol RSymbol, era uint32, blockHash ...types.Hash) (rate uint64, err error) {
+	return sc.GetEraRateContext(context.Background(), symbol, era, blockHash...)
+}
+
+func (sc *GsrpcClient) GetEraRateContext(ctx context.Context, symbol RSymbol, era uint32, blockHash ...types.Hash) (rate uint64, err error) {
 	symBz, err := types.EncodeToBytes(symbol)
 	if err != nil {
 		return 0, err
@@ -171,7 +208,7 @@ func (sc *GsrpcClient) GetEraRate(symbol RSymbol, era uint32) (rate uint64, err
 	if err != nil {
 		return 0, err
 	}
-	exists, err := sc.QueryStorage(config.RTokenRateModuleId, config.StorageEraRate, symBz, eraIndex, &rate)
+	exists, err := sc.QueryStorageContext(ctx, config.RTokenRateModuleId, config.StorageEraRate, symBz, eraIndex, &rate, blockHash...)
 	if err != nil {
 		return 0, err
 	}
@@ -181,9 +218,13 @@ func (sc *GsrpcClient) GetEraRate(symbol RSymbol, era uint32) (rate uint64, err
 	return rate, nil
 }
 
-func (sc *GsrpcClient) GetReceiver() (*types.AccountID, error) {
+func (sc *GsrpcClient) GetReceiver(blockHash ...types.Hash) (*types.AccountID, error) {
+	return sc.GetReceiverContext(context.Background(), blockHash...)
+}
+
+func (sc *GsrpcClient) GetReceiverContext(ctx context.Context, blockHash ...types.Hash) (*types.AccountID, error) {
 	ac := new(types.AccountID)
-	exists, err := sc.QueryStorage(config.RTokenLedgerModuleId, config.StorageReceiver, nil, nil, ac)
+	exists, err := sc.QueryStorageContext(ctx, config.RTokenLedgerModuleId, config.StorageReceiver, nil, nil, ac, blockHash...)
 	if err != nil {
 		return nil, err
 	}
@@ -193,9 +234,13 @@ func (sc *GsrpcClient) GetReceiver() (*types.AccountID, error) {
 	return ac, nil
 }
 
-func (sc *GsrpcClient) GetRFisReceiver() (*types.AccountID, error) {
+func (sc *GsrpcClient) GetRFisReceiver(blockHash ...types.Hash) (*types.AccountID, error) {
+	return sc.GetRFisReceiverContext(context.Background(), blockHash...)
+}
+
+func (sc *GsrpcClient) GetRFisReceiverContext(ctx context.Context, blockHash ...types.Hash) (*types.AccountID, error) {
 	ac := new(types.AccountID)
-	exists, err := sc.QueryStorage(config.RFisModuleId, config.StorageReceiver, nil, nil, ac)
+	exists, err := sc.QueryStorageContext(ctx, config.RFisModuleId, config.StorageReceiver, nil, nil, ac, blockHash...)
 	if err != nil {
 		return nil, err
 	}
@@ -205,9 +250,13 @@ func (sc *GsrpcClient) GetRFisReceiver() (*types.AccountID, error) {
 	return ac, nil
 }
 
-func (gc *GsrpcClient) GetREthCurrentCycle() (uint32, error) {
+func (gc *GsrpcClient) GetREthCurrentCycle(blockHash ...types.Hash) (uint32, error) {
+	return gc.GetREthCurrentCycleContext(context.Background(), blockHash...)
+}
+
+func (gc *GsrpcClient) GetREthCurrentCycleContext(ctx context.Context, blockHash ...types.Hash) (uint32, error) {
 	var cycle uint32
-	exists, err := gc.QueryStorage(config.RClaimModuleId, config.StorageREthActCurrentCycle, nil, nil, &cycle)
+	exists, err := gc.QueryStorageContext(ctx, config.RClaimModuleId, config.StorageREthActCurrentCycle, nil, nil, &cycle, blockHash...)
 	if err != nil {
 		return 0, err
 	}
@@ -218,13 +267,17 @@ func (gc *GsrpcClient) GetREthCurrentCycle() (uint32, error) {
 	return cycle, nil
 }
 
-func (gc *GsrpcClient) MintTxHashExist(txHash types.Bytes) (bool, error) {
+func (gc *GsrpcClient) MintTxHashExist(txHash types.Bytes, blockHash ...types.Hash) (bool, error) {
+	return gc.MintTxHashExistContext(context.Background(), txHash, blockHash...)
+}
+
+func (gc *GsrpcClient) MintTxHashExistContext(ctx context.Context, txHash types.Bytes, blockHash ...types.Hash) (bool, error) {
 	txHashBytes, err := types.EncodeToBytes(txHash)
 	if err != nil {
 		return false, err
 	}
 	var txExists bool
-	exists, err := gc.QueryStorage(config.RClaimModuleId, config.StorageMintTxHashExist, txHashBytes, nil, &txExists)
+	exists, err := gc.QueryStorageContext(ctx, config.RClaimModuleId, config.StorageMintTxHashExist, txHashBytes, nil, &txExists, blockHash...)
 	if err != nil {
 		return false, err
 	}
@@ -242,9 +295,13 @@ func (gc *GsrpcClient) MintTxHashExist(txHash types.Bytes) (bool, error) {
 // or
 // 2  current+x cycle begin < now < current+x cycle end
 func (gc *GsrpcClient) CurrentRethNeedSeed() (bool, error) {
+	return gc.CurrentRethNeedSeedContext(context.Background())
+}
+
+func (gc *GsrpcClient) CurrentRethNeedSeedContext(ctx context.Context) (bool, error) {
 
 	currentCycleExist := false
-	currentCycle, err := gc.GetREthCurrentCycle()
+	currentCycle, err := gc.GetREthCurrentCycleContext(ctx)
 	if err != nil {
 		if err != ErrorValueNotExist {
 			return false, err
@@ -253,11 +310,11 @@ func (gc *GsrpcClient) CurrentRethNeedSeed() (bool, error) {
 		currentCycleExist = true
 	}
 
-	blockNumber, err := gc.GetLatestBlockNumber()
+	blockNumber, err := gc.GetLatestBlockNumberContext(ctx)
 	if err != nil {
 		return false, err
 	}
-	latestCycle, err := gc.REthActLatestCycle()
+	latestCycle, err := gc.REthActLatestCycleContext(ctx)
 	if err != nil {
 		if err == ErrorValueNotExist {
 			return false, nil
@@ -270,7 +327,7 @@ func (gc *GsrpcClient) CurrentRethNeedSeed() (bool, error) {
 	}
 
 	if currentCycleExist && currentCycle > 0 {
-		currentAct, err := gc.RethAct(currentCycle)
+		currentAct, err := gc.RethActContext(ctx, currentCycle)
 		if err != nil {
 			return false, err
 		}
@@ -286,7 +343,7 @@ func (gc *GsrpcClient) CurrentRethNeedSeed() (bool, error) {
 	}
 
 	for i := beginCycle; i <= int(latestCycle); i++ {
-		act, err := gc.RethAct(uint32(i))
+		act, err := gc.RethActContext(ctx, uint32(i))
 		if err != nil {
 			if err == ErrorValueNotExist {
 
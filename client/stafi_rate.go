@@ -0,0 +1,279 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// RateDecimals is the fixed-point precision of the exchange rate of rTokens, a rate of 1e12 is 1 native token per
+// rToken
+const RateDecimals = 12
+
+// year is the period APRs are annualized to
+const year = 365 * 24 * time.Hour
+
+// RatePoint is the exchange rate of an rToken in one era
+type RatePoint struct {
+	Symbol RSymbol
+	Era    uint32
+	// Rate is fixed-point with RateDecimals
+	Rate uint64
+	// Block and Timestamp (unix ms) are of the block the rate was set in
+	Block     uint64
+	Timestamp uint64
+}
+
+// Value returns the rate as native tokens per rToken
+func (p *RatePoint) Value() decimal.Decimal {
+	return RateValue(p.Rate)
+}
+
+// Time returns the time the rate was set, zero for points made without a timestamp
+func (p *RatePoint) Time() time.Time {
+	if p.Timestamp == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(p.Timestamp))
+}
+
+// RateValue converts a fixed-point rate to native tokens per rToken
+func RateValue(rate uint64) decimal.Decimal {
+	return decimal.NewFromBigInt(new(big.Int).SetUint64(rate), -RateDecimals)
+}
+
+// EraRateHistory reads the rates of the eras from fromEra to toEra from RTokenRate.EraRate of the latest block, eras
+// without a rate are skipped. Storage does not tell when a rate was set, the block of a point is the first block with
+// the rate, found by bisecting the blocks up to the latest one. Each era costs some log2(latest) storage queries.
+func EraRateHistory(c Chain, symbol RSymbol, fromEra, toEra uint32) ([]*RatePoint, error) {
+	latest, err := c.GetLatestBlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	latestHash, err := blockHashOf(c, latest)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]*RatePoint, 0)
+	// rates are set in era order, the rate of an era is not set before the rate of the era before
+	low := uint64(0)
+	for era := fromEra; era <= toEra; era++ {
+		rate, err := c.GetEraRate(symbol, era, latestHash)
+		if err != nil {
+			if err == ErrorValueNotExist {
+				continue
+			}
+			return nil, fmt.Errorf("era %d: %s", era, err)
+		}
+
+		era := era
+		number, err := firstBlock(c, low, latest, func(blockHash types.Hash) (bool, error) {
+			_, err := c.GetEraRate(symbol, era, blockHash)
+			if err == ErrorValueNotExist {
+				return false, nil
+			}
+			return err == nil, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("era %d: %s", era, err)
+		}
+		low = number
+
+		blockHash, err := blockHashOf(c, number)
+		if err != nil {
+			return nil, fmt.Errorf("era %d: %s", era, err)
+		}
+		now, err := blockTimestamp(c, blockHash)
+		if err != nil {
+			return nil, fmt.Errorf("era %d: block %d: %s", era, number, err)
+		}
+		points = append(points, &RatePoint{Symbol: symbol, Era: era, Rate: rate, Block: number, Timestamp: now})
+		if era == toEra {
+			break
+		}
+	}
+	return points, nil
+}
+
+// RateSetHistory collects the RateSet events of symbol in the blocks where the chain era of symbol is from fromEra to
+// toEra. The bounds of the blocks are found by bisecting the chain era up to the latest block, the events of every
+// block in between are read. The era of a point is the chain era at its block, its timestamp is Timestamp.Now of the
+// block.
+func RateSetHistory(c Chain, symbol RSymbol, fromEra, toEra uint32) ([]*RatePoint, error) {
+	if fromEra > toEra {
+		return nil, fmt.Errorf("era range %d to %d is empty", fromEra, toEra)
+	}
+	latest, err := c.GetLatestBlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	eraFrom := func(era uint32) func(types.Hash) (bool, error) {
+		return func(blockHash types.Hash) (bool, error) {
+			current, err := c.CurrentChainEra(symbol, blockHash)
+			if err == ErrorValueNotExist {
+				return false, nil
+			}
+			return err == nil && current >= era, err
+		}
+	}
+
+	points := make([]*RatePoint, 0)
+	fromBlock, err := firstBlock(c, 0, latest, eraFrom(fromEra))
+	if err == errNoBlock {
+		return points, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("era %d: %s", fromEra, err)
+	}
+	toBlock := latest
+	if toEra < math.MaxUint32 {
+		next, err := firstBlock(c, fromBlock, latest, eraFrom(toEra+1))
+		switch {
+		case err == nil:
+			toBlock = next - 1
+		case err != errNoBlock:
+			return nil, fmt.Errorf("era %d: %s", toEra+1, err)
+		}
+	}
+
+	for number := fromBlock; number <= toBlock; number++ {
+		events, err := c.GetEvents(number)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %s", number, err)
+		}
+		for _, evt := range events {
+			if evt.EventId != config.RTokenRateSetEventId ||
+				(evt.ModuleId != config.RTokenRateModuleId && evt.ModuleId != config.RFisModuleId) {
+				continue
+			}
+			rateSet, err := EventRateSetData(evt)
+			if err != nil {
+				return nil, fmt.Errorf("block %d: %s", number, err)
+			}
+			if rateSet.Symbol != symbol {
+				continue
+			}
+
+			point, err := ratePointAt(c, symbol, number)
+			if err != nil {
+				return nil, fmt.Errorf("block %d: %s", number, err)
+			}
+			point.Rate = uint64(rateSet.Rate)
+			points = append(points, point)
+		}
+		if number == toBlock {
+			break
+		}
+	}
+	return points, nil
+}
+
+var errNoBlock = errors.New("no block found")
+
+// firstBlock bisects the blocks from low to high for the first one where has holds, has must hold for all blocks after
+// it. It returns errNoBlock if has does not hold at high.
+func firstBlock(c HeaderReader, low, high uint64, has func(blockHash types.Hash) (bool, error)) (uint64, error) {
+	at := func(number uint64) (bool, error) {
+		blockHash, err := blockHashOf(c, number)
+		if err != nil {
+			return false, err
+		}
+		return has(blockHash)
+	}
+
+	ok, err := at(high)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, errNoBlock
+	}
+	for low < high {
+		mid := low + (high-low)/2
+		ok, err := at(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return high, nil
+}
+
+func ratePointAt(c Chain, symbol RSymbol, number uint64) (*RatePoint, error) {
+	blockHash, err := blockHashOf(c, number)
+	if err != nil {
+		return nil, err
+	}
+
+	era, err := c.CurrentChainEra(symbol, blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("chain era: %s", err)
+	}
+	now, err := blockTimestamp(c, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return &RatePoint{Symbol: symbol, Era: era, Block: number, Timestamp: now}, nil
+}
+
+func blockTimestamp(c StorageReader, blockHash types.Hash) (uint64, error) {
+	var now types.U64
+	exists, err := c.QueryStorage(config.TimestampModuleId, config.StorageNow, nil, nil, &now, blockHash)
+	if err != nil {
+		return 0, fmt.Errorf("timestamp: %s", err)
+	}
+	if !exists {
+		return 0, fmt.Errorf("timestamp: %s", ErrorValueNotExist)
+	}
+	return uint64(now), nil
+}
+
+// APR annualizes the change of rate from the first to the last point, over the time between their timestamps. The
+// return is not compounded, 0.05 is 5%.
+func APR(points []*RatePoint) (decimal.Decimal, error) {
+	if len(points) < 2 {
+		return decimal.Zero, fmt.Errorf("need at least 2 rate points, got %d", len(points))
+	}
+	first, last := points[0], points[len(points)-1]
+	if first.Timestamp == 0 || last.Timestamp == 0 {
+		return decimal.Zero, fmt.Errorf("rate points have no timestamp")
+	}
+	if last.Timestamp <= first.Timestamp {
+		return decimal.Zero, fmt.Errorf("rate points not in time order")
+	}
+	return annualize(first.Rate, last.Rate, time.Duration(last.Timestamp-first.Timestamp)*time.Millisecond)
+}
+
+// EraAPR is APR over eras of eraDuration, for points of chains whose eras last a fixed time
+func EraAPR(points []*RatePoint, eraDuration time.Duration) (decimal.Decimal, error) {
+	if len(points) < 2 {
+		return decimal.Zero, fmt.Errorf("need at least 2 rate points, got %d", len(points))
+	}
+	first, last := points[0], points[len(points)-1]
+	if last.Era <= first.Era {
+		return decimal.Zero, fmt.Errorf("rate points not in era order")
+	}
+	return annualize(first.Rate, last.Rate, time.Duration(last.Era-first.Era)*eraDuration)
+}
+
+func annualize(from, to uint64, elapsed time.Duration) (decimal.Decimal, error) {
+	if from == 0 {
+		return decimal.Zero, fmt.Errorf("rate is 0")
+	}
+	if elapsed <= 0 {
+		return decimal.Zero, fmt.Errorf("elapsed time is %s", elapsed)
+	}
+	change := RateValue(to).Sub(RateValue(from)).DivRound(RateValue(from), 18)
+	return change.Mul(decimal.NewFromInt(int64(year))).DivRound(decimal.NewFromInt(int64(elapsed)), 18), nil
+}
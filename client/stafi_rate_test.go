@@ -0,0 +1,115 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	scale "github.com/itering/scale.go"
+	"github.com/shopspring/decimal"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client/clientfake"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// rateChain is a chain whose rToken state changes with the block, the fakes ignore block hashes. The era of block n
+// is 10+n/10, the rate of an era is set 3 blocks into the era and a block is 6s after the block before.
+type rateChain struct {
+	client.Chain
+	headers *clientfake.Headers
+	// unset eras never get a rate
+	unset   map[uint32]bool
+	queries int
+}
+
+func (c *rateChain) number(blockHash []types.Hash) uint64 {
+	if len(blockHash) == 0 {
+		number, _ := c.headers.GetLatestBlockNumber()
+		return number
+	}
+	number, _ := c.headers.GetBlockNumber(blockHash[0])
+	return number
+}
+
+func (c *rateChain) CurrentChainEra(_ client.RSymbol, blockHash ...types.Hash) (uint32, error) {
+	c.queries++
+	return 10 + uint32(c.number(blockHash)/10), nil
+}
+
+func (c *rateChain) GetEraRate(_ client.RSymbol, era uint32, blockHash ...types.Hash) (uint64, error) {
+	c.queries++
+	if era < 10 || c.unset[era] || c.number(blockHash) < uint64(era-10)*10+3 {
+		return 0, client.ErrorValueNotExist
+	}
+	return 1_000_000_000_000 + uint64(era-10)*1_000_000_000, nil
+}
+
+func (c *rateChain) QueryStorage(prefix, method string, _, _ []byte, result interface{}, blockHash ...types.Hash) (bool, error) {
+	c.queries++
+	if prefix != config.TimestampModuleId || method != config.StorageNow {
+		return false, nil
+	}
+	*result.(*types.U64) = types.U64(1_600_000_000_000 + c.number(blockHash)*6000)
+	return true, nil
+}
+
+func TestRateHistory(t *testing.T) {
+	headers := clientfake.NewHeaders()
+	events := clientfake.NewEvents(headers)
+	c := &rateChain{Chain: client.NewChain(headers, nil, events, nil, nil), headers: headers, unset: map[uint32]bool{12: true}}
+	for i := 0; i < 50; i++ {
+		headers.Push()
+	}
+
+	points, err := client.EraRateHistory(c, client.RDOT, 9, 16)
+	assert.NoError(t, err)
+	assert.Equal(t, []*client.RatePoint{
+		{Symbol: client.RDOT, Era: 10, Rate: 1_000_000_000_000, Block: 3, Timestamp: 1_600_000_018_000},
+		{Symbol: client.RDOT, Era: 11, Rate: 1_001_000_000_000, Block: 13, Timestamp: 1_600_000_078_000},
+		{Symbol: client.RDOT, Era: 13, Rate: 1_003_000_000_000, Block: 33, Timestamp: 1_600_000_198_000},
+		{Symbol: client.RDOT, Era: 14, Rate: 1_004_000_000_000, Block: 43, Timestamp: 1_600_000_258_000},
+	}, points)
+	assert.Equal(t, "1.001", points[1].Value().String())
+	// bisecting 50 blocks takes some 6 queries per era
+	assert.Less(t, c.queries, 8*8+4*8)
+
+	// 0.4% in 4 eras of 60s
+	apr, err := client.EraAPR(points, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "525.6", apr.String())
+	apr, err = client.APR(points)
+	assert.NoError(t, err)
+	assert.Equal(t, "525.6", apr.String())
+
+	rateSet := func(symbol string, rate float64) *client.ChainEvent {
+		return &client.ChainEvent{ModuleId: config.RTokenRateModuleId, EventId: config.RTokenRateSetEventId,
+			Params: []scale.EventParam{{Value: symbol}, {Value: rate}}}
+	}
+	events.Add(headers.Hash(5), rateSet("RDOT", 1_000_000_000_000))
+	events.Add(headers.Hash(13), rateSet("RKSM", 1_000_000_000_000), rateSet("RDOT", 1_001_000_000_000))
+	events.Add(headers.Hash(29), rateSet("RDOT", 1_002_000_000_000))
+	events.Add(headers.Hash(30), rateSet("RDOT", 1_003_000_000_000))
+
+	points, err = client.RateSetHistory(c, client.RDOT, 11, 12)
+	assert.NoError(t, err)
+	assert.Equal(t, []*client.RatePoint{
+		{Symbol: client.RDOT, Era: 11, Rate: 1_001_000_000_000, Block: 13, Timestamp: 1_600_000_078_000},
+		{Symbol: client.RDOT, Era: 12, Rate: 1_002_000_000_000, Block: 29, Timestamp: 1_600_000_174_000},
+	}, points)
+
+	// eras after the latest block have no events
+	points, err = client.RateSetHistory(c, client.RDOT, 15, 20)
+	assert.NoError(t, err)
+	assert.Empty(t, points)
+
+	_, err = client.APR(points)
+	assert.EqualError(t, err, "need at least 2 rate points, got 0")
+	points = []*client.RatePoint{
+		{Rate: 1_000_000_000_000, Timestamp: 1_600_000_000_000},
+		{Rate: 1_002_000_000_000, Timestamp: 1_600_000_000_000 + 73*24*3600*1000},
+	}
+	apr, err = client.APR(points)
+	assert.NoError(t, err)
+	assert.True(t, decimal.RequireFromString("0.01").Equal(apr), apr.String())
+}
@@ -1,14 +1,175 @@
 package client
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/config"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 func (gc *GsrpcClient) UpdateRethClaimInfo(txHashs, pubkeys [][]byte, mintValues, nativeValues []types.U128) error {
-	ext, err := gc.NewUnsignedExtrinsic(config.MethodUpdateRethClaimInfo, txHashs, pubkeys, mintValues, nativeValues)
+	return gc.UpdateRethClaimInfoContext(context.Background(), txHashs, pubkeys, mintValues, nativeValues)
+}
+
+func (gc *GsrpcClient) UpdateRethClaimInfoContext(ctx context.Context, txHashs, pubkeys [][]byte, mintValues, nativeValues []types.U128) error {
+	ext, err := gc.NewUnsignedExtrinsicContext(ctx, config.MethodUpdateRethClaimInfo, txHashs, pubkeys, mintValues, nativeValues)
 	if err != nil {
 		return err
 	}
-	return gc.SignAndSubmitTx(ext)
+	return gc.SignAndSubmitTxContext(ctx, ext)
+}
+
+// RethClaimInfo is one mint of RClaim.update_reth_claim_info
+type RethClaimInfo struct {
+	TxHash      []byte
+	Pubkey      []byte
+	MintValue   types.U128
+	NativeValue types.U128
+}
+
+// RethClaimProgress is reported after each chunk of UpdateRethClaimInfoChunked
+type RethClaimProgress struct {
+	// Chunk counts the chunks of this run from 1
+	Chunk int
+	// From and Next bound the infos the chunk covered, a run resumes at Next
+	From, Next int
+	Submitted  int
+	// Skipped infos have their tx hash recorded already
+	Skipped int
+	Total   int
+}
+
+// UpdateRethClaimInfoOption tunes UpdateRethClaimInfoChunked
+type UpdateRethClaimInfoOption struct {
+	// Limits default to the normal block limits of the chain
+	Limits *BlockLimits
+	// Start is the index of the first info to submit, Next of the last progress of an interrupted run
+	Start int
+	// OnProgress is called after each chunk, an error stops the run
+	OnProgress func(p RethClaimProgress) error
+}
+
+// updateRethClaimInfoBaseLength is the length of an update_reth_claim_info extrinsic without infos: extrinsic length,
+// version, call index and the lengths of the four vectors
+const updateRethClaimInfoBaseLength = 5 + 1 + 2 + 4*5 + signedExtrinsicOverhead
+
+// UpdateRethClaimInfoChunked submits infos with RClaim.update_reth_claim_info in as many extrinsics as the block
+// length and weight limits need. Infos whose tx hash is recorded already are skipped.
+func UpdateRethClaimInfoChunked(c Chain, infos []RethClaimInfo, opt UpdateRethClaimInfoOption) error {
+	limits := opt.Limits
+	if limits == nil {
+		var err error
+		if limits, err = NormalBlockLimits(c); err != nil {
+			return err
+		}
+	}
+
+	for chunk, from := 1, opt.Start; from < len(infos); chunk++ {
+		batch, skipped, next, err := nextRethClaimChunk(c, infos, from, limits.MaxLength)
+		if err != nil {
+			return err
+		}
+
+		var ext interface{}
+		for len(batch) > 0 {
+			if ext, err = newUpdateRethClaimInfoExtrinsic(c, infos, batch); err != nil {
+				return err
+			}
+			weight, err := c.EstimateWeight(ext)
+			if err != nil {
+				return err
+			}
+			if weight <= limits.MaxWeight {
+				break
+			}
+			if len(batch) == 1 {
+				return fmt.Errorf("info %d: weight %d exceeds max weight %d", batch[0], weight, limits.MaxWeight)
+			}
+			n := int(uint64(len(batch)) * limits.MaxWeight / weight)
+			if n >= len(batch) {
+				n = len(batch) - 1
+			}
+			if n < 1 {
+				n = 1
+			}
+			batch, next = batch[:n], batch[n-1]+1
+		}
+
+		if len(batch) > 0 {
+			if err := c.SignAndSubmitTx(ext); err != nil {
+				return fmt.Errorf("chunk from info %d: %s", from, err)
+			}
+		}
+		if opt.OnProgress != nil {
+			p := RethClaimProgress{Chunk: chunk, From: from, Next: next, Submitted: len(batch), Total: len(infos)}
+			for _, i := range skipped {
+				if i < next {
+					p.Skipped++
+				}
+			}
+			if err := opt.OnProgress(p); err != nil {
+				return err
+			}
+		}
+		from = next
+	}
+	return nil
+}
+
+// nextRethClaimChunk collects the infos from index from that fit maxLength, skipping recorded ones. It returns the
+// indexes of the infos to submit and of the skipped ones, and where the next chunk starts.
+func nextRethClaimChunk(c Chain, infos []RethClaimInfo, from int, maxLength uint32) (batch, skipped []int, next int, err error) {
+	length := updateRethClaimInfoBaseLength
+	for next = from; next < len(infos); next++ {
+		info := infos[next]
+		exist, err := c.MintTxHashExist(info.TxHash)
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("info %d: %s", next, err)
+		}
+		if exist {
+			skipped = append(skipped, next)
+			continue
+		}
+
+		l, err := rethClaimInfoLength(info)
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("info %d: %s", next, err)
+		}
+		if uint32(length+l) > maxLength {
+			if len(batch) == 0 {
+				return nil, nil, 0, fmt.Errorf("info %d: length %d exceeds max length %d", next, length+l, maxLength)
+			}
+			break
+		}
+		length += l
+		batch = append(batch, next)
+	}
+	return batch, skipped, next, nil
+}
+
+func rethClaimInfoLength(info RethClaimInfo) (int, error) {
+	length := 0
+	for _, v := range []interface{}{info.TxHash, info.Pubkey, info.MintValue, info.NativeValue} {
+		bz, err := types.EncodeToBytes(v)
+		if err != nil {
+			return 0, err
+		}
+		length += len(bz)
+	}
+	return length, nil
+}
+
+func newUpdateRethClaimInfoExtrinsic(tx TxSubmitter, infos []RethClaimInfo, batch []int) (interface{}, error) {
+	txHashs := make([][]byte, 0, len(batch))
+	pubkeys := make([][]byte, 0, len(batch))
+	mintValues := make([]types.U128, 0, len(batch))
+	nativeValues := make([]types.U128, 0, len(batch))
+	for _, i := range batch {
+		txHashs = append(txHashs, infos[i].TxHash)
+		pubkeys = append(pubkeys, infos[i].Pubkey)
+		mintValues = append(mintValues, infos[i].MintValue)
+		nativeValues = append(nativeValues, infos[i].NativeValue)
+	}
+	return tx.NewUnsignedExtrinsic(config.MethodUpdateRethClaimInfo, txHashs, pubkeys, mintValues, nativeValues)
 }
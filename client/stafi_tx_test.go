@@ -0,0 +1,75 @@
+package client_test
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client/clientfake"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateRethClaimInfoChunked(t *testing.T) {
+	submitter := clientfake.NewSubmitter()
+	submitter.WeightOf = func(call *clientfake.Call) uint64 {
+		return 100 * uint64(len(call.Args[0].([][]byte)))
+	}
+	rTokens := clientfake.NewRTokens()
+	c := client.NewChain(nil, nil, nil, submitter, rTokens)
+
+	infos := make([]client.RethClaimInfo, 10)
+	for i := range infos {
+		infos[i] = client.RethClaimInfo{
+			TxHash:      bytes.Repeat([]byte{byte(i)}, 32),
+			Pubkey:      make([]byte, 48),
+			MintValue:   types.NewU128(*big.NewInt(int64(i))),
+			NativeValue: types.NewU128(*big.NewInt(int64(i))),
+		}
+	}
+	rTokens.MintTxHashes[types.HexEncodeToString(infos[1].TxHash)] = true
+
+	// 4 infos fit the length, 3 the weight
+	limits := &client.BlockLimits{MaxLength: 5 + 1 + 2 + 20 + 128 + 4*114, MaxWeight: 300}
+	stop := errors.New("stop")
+	var progress []client.RethClaimProgress
+	err := client.UpdateRethClaimInfoChunked(c, infos, client.UpdateRethClaimInfoOption{
+		Limits: limits,
+		OnProgress: func(p client.RethClaimProgress) error {
+			progress = append(progress, p)
+			return stop
+		},
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, []client.RethClaimProgress{{Chunk: 1, From: 0, Next: 4, Submitted: 3, Skipped: 1, Total: 10}}, progress)
+
+	err = client.UpdateRethClaimInfoChunked(c, infos, client.UpdateRethClaimInfoOption{
+		Limits: limits,
+		Start:  progress[0].Next,
+		OnProgress: func(p client.RethClaimProgress) error {
+			progress = append(progress, p)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, progress, 3)
+	assert.Equal(t, 7, progress[1].Next)
+	assert.Equal(t, 10, progress[2].Next)
+
+	submitted := submitter.Submitted()
+	assert.Len(t, submitted, 3)
+	hashes := make([][]byte, 0)
+	for _, call := range submitted {
+		assert.Equal(t, config.MethodUpdateRethClaimInfo, call.Method)
+		hashes = append(hashes, call.Args[0].([][]byte)...)
+	}
+	assert.Len(t, hashes, 9)
+	assert.NotContains(t, hashes, infos[1].TxHash)
+
+	err = client.UpdateRethClaimInfoChunked(c, infos, client.UpdateRethClaimInfoOption{
+		Limits: &client.BlockLimits{MaxLength: 100, MaxWeight: 300}})
+	assert.EqualError(t, err, "info 0: length 270 exceeds max length 100")
+}
@@ -0,0 +1,321 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// UserUnlockChunk is an unbond of a user in RTokenSeries.AccountUnbonds
+type UserUnlockChunk struct {
+	Pool      types.Bytes
+	UnlockEra uint32
+	Value     types.U128
+	Recipient types.Bytes
+}
+
+// UnbondChunk is a pending unbond of a user
+type UnbondChunk struct {
+	UserUnlockChunk
+	// Block is the block of the unbond, 0 if read from storage
+	Block    uint64
+	Unlocked bool
+	// Withdrawable is set for unlocked chunks known not to be withdrawn
+	Withdrawable bool
+}
+
+// WithdrawSchedule is what a user has unbonding of an rToken and when it unlocks
+type WithdrawSchedule struct {
+	Symbol     RSymbol
+	Account    types.AccountID
+	CurrentEra uint32
+	// BondingDuration is 0 if the chain has none for the symbol
+	BondingDuration uint32
+	// Chunks are in unbond order
+	Chunks       []*UnbondChunk
+	Withdrawable types.U128
+	Pending      types.U128
+	// Unlocked is the value of the unlocked chunks that may be withdrawn already, set if WithdrawalsKnown is false
+	Unlocked types.U128
+	// FromEvents is set if the chunks were collected from events
+	FromEvents bool
+	// WithdrawalsKnown is false for chunks collected from events of rTokens other than rFIS, whose withdrawals have
+	// no events
+	WithdrawalsKnown bool
+}
+
+// NextUnlockEra returns the earliest unlock era of the chunks still locked
+func (s *WithdrawSchedule) NextUnlockEra() (uint32, bool) {
+	var next uint32
+	found := false
+	for _, c := range s.Chunks {
+		if !c.Unlocked && (!found || c.UnlockEra < next) {
+			next, found = c.UnlockEra, true
+		}
+	}
+	return next, found
+}
+
+func (s *WithdrawSchedule) sum() {
+	withdrawable, pending, unlocked := new(big.Int), new(big.Int), new(big.Int)
+	for _, c := range s.Chunks {
+		c.Unlocked = c.UnlockEra <= s.CurrentEra
+		c.Withdrawable = c.Unlocked && s.WithdrawalsKnown
+		switch {
+		case c.Withdrawable:
+			withdrawable.Add(withdrawable, c.Value.Int)
+		case c.Unlocked:
+			unlocked.Add(unlocked, c.Value.Int)
+		default:
+			pending.Add(pending, c.Value.Int)
+		}
+	}
+	s.Withdrawable = types.NewU128(*withdrawable)
+	s.Pending = types.NewU128(*pending)
+	s.Unlocked = types.NewU128(*unlocked)
+}
+
+func (sc *GsrpcClient) AccountUnbonds(symbol RSymbol, who types.AccountID, blockHash ...types.Hash) ([]UserUnlockChunk, error) {
+	return sc.AccountUnbondsContext(context.Background(), symbol, who, blockHash...)
+}
+
+func (sc *GsrpcClient) AccountUnbondsContext(ctx context.Context, symbol RSymbol, who types.AccountID, blockHash ...types.Hash) ([]UserUnlockChunk, error) {
+	symBz, err := types.EncodeToBytes(symbol)
+	if err != nil {
+		return nil, err
+	}
+	whoBz, err := types.EncodeToBytes(who)
+	if err != nil {
+		return nil, err
+	}
+
+	// the unbonds are kept by RTokenSeries, which emits LiquidityUnBond, keyed by account first
+	chunks := make([]UserUnlockChunk, 0)
+	exists, err := sc.QueryStorageContext(ctx, config.RTokenSeriesModuleId, config.StorageAccountUnbonds, whoBz, symBz, &chunks, blockHash...)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrorValueNotExist
+	}
+	return chunks, nil
+}
+
+// UnbondingDuration is the bonding duration in eras of the chain of symbol, RTokenLedger.ChainBondingDuration
+func (sc *GsrpcClient) UnbondingDuration(symbol RSymbol, blockHash ...types.Hash) (uint32, error) {
+	return sc.UnbondingDurationContext(context.Background(), symbol, blockHash...)
+}
+
+func (sc *GsrpcClient) UnbondingDurationContext(ctx context.Context, symbol RSymbol, blockHash ...types.Hash) (uint32, error) {
+	symBz, err := types.EncodeToBytes(symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	var duration uint32
+	exists, err := sc.QueryStorageContext(ctx, config.RTokenLedgerModuleId, config.StorageChainBondingDuration, symBz, nil, &duration, blockHash...)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, ErrorValueNotExist
+	}
+	return duration, nil
+}
+
+// MaxUnbondScanBlocks is the most blocks UnbondSchedule scans for events, wider ranges go through
+// UnbondScheduleFromEvents
+const MaxUnbondScanBlocks = 14400
+
+// UnbondSchedule reads the withdraw schedule of who from RTokenSeries.AccountUnbonds. Only if the runtime has no
+// AccountUnbonds storage, the schedule is collected from the events of the blocks from fromBlock to the latest block,
+// at most MaxUnbondScanBlocks of them.
+func UnbondSchedule(c Chain, symbol RSymbol, who types.AccountID, fromBlock uint64) (*WithdrawSchedule, error) {
+	schedule, err := UnbondScheduleFromStorage(c, symbol, who)
+	if !errors.Is(err, ErrorStorageNotFound) {
+		return schedule, err
+	}
+
+	latest, err := c.GetLatestBlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	if fromBlock > latest {
+		return nil, fmt.Errorf("from block %d beyond latest block %d", fromBlock, latest)
+	}
+	if latest-fromBlock >= MaxUnbondScanBlocks {
+		return nil, fmt.Errorf("blocks %d to %d are more than %d to scan for unbonds", fromBlock, latest, MaxUnbondScanBlocks)
+	}
+	return UnbondScheduleFromEvents(c, symbol, who, fromBlock, latest)
+}
+
+// UnbondScheduleFromStorage reads the withdraw schedule of who at the latest block, the schedule is empty if the
+// account has no unbonds
+func UnbondScheduleFromStorage(c Chain, symbol RSymbol, who types.AccountID) (*WithdrawSchedule, error) {
+	chunks, err := c.AccountUnbonds(symbol, who)
+	if err != nil && err != ErrorValueNotExist {
+		return nil, err
+	}
+	era, err := c.CurrentChainEra(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("chain era: %s", err)
+	}
+	duration, err := c.UnbondingDuration(symbol)
+	if err != nil && err != ErrorValueNotExist {
+		return nil, fmt.Errorf("unbonding duration: %s", err)
+	}
+
+	schedule := &WithdrawSchedule{
+		Symbol:           symbol,
+		Account:          who,
+		CurrentEra:       era,
+		BondingDuration:  duration,
+		Chunks:           make([]*UnbondChunk, 0, len(chunks)),
+		WithdrawalsKnown: true,
+	}
+	for _, chunk := range chunks {
+		schedule.Chunks = append(schedule.Chunks, &UnbondChunk{UserUnlockChunk: chunk})
+	}
+	schedule.sum()
+	return schedule, nil
+}
+
+// UnbondScheduleFromEvents collects the withdraw schedule of who from the LiquidityUnBond events in the blocks from
+// fromBlock to toBlock. The unlock era of a chunk is the chain era at its block plus the bonding duration then, which
+// follows the BondingDurationUpdated events. Only rFIS withdrawals have events, LiquidityWithdrawUnBond removes
+// withdrawable rFIS chunks. Unlocked chunks of other rTokens may be withdrawn already and count as Unlocked, not
+// Withdrawable.
+func UnbondScheduleFromEvents(c Chain, symbol RSymbol, who types.AccountID, fromBlock, toBlock uint64) (*WithdrawSchedule, error) {
+	fromHash, err := blockHashOf(c, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := c.UnbondingDuration(symbol, fromHash)
+	durationKnown := err == nil
+	if err != nil && err != ErrorValueNotExist {
+		return nil, fmt.Errorf("unbonding duration: %s", err)
+	}
+
+	schedule := &WithdrawSchedule{
+		Symbol:           symbol,
+		Account:          who,
+		Chunks:           make([]*UnbondChunk, 0),
+		FromEvents:       true,
+		WithdrawalsKnown: symbol == RFIS,
+	}
+	for number := fromBlock; number <= toBlock; number++ {
+		events, err := c.GetEvents(number)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %s", number, err)
+		}
+		for _, evt := range events {
+			var chunk *UserUnlockChunk
+			switch {
+			case evt.ModuleId == config.RTokenLedgerModuleId && evt.EventId == config.BondingDurationEventId:
+				d, err := EventBondingDurationData(evt)
+				if err != nil {
+					return nil, fmt.Errorf("block %d: %s", number, err)
+				}
+				if d.Symbol == symbol {
+					duration, durationKnown = uint32(d.NewDuration), true
+				}
+			case evt.ModuleId == config.RTokenSeriesModuleId && evt.EventId == config.RTokenUnbondEventId:
+				unbond, err := EventUnbondData(evt)
+				if err != nil {
+					return nil, fmt.Errorf("block %d: %s", number, err)
+				}
+				if unbond.Symbol == symbol && unbond.From == who {
+					chunk = &UserUnlockChunk{Pool: unbond.Pool, Value: unbond.Value, Recipient: unbond.Recipient}
+				}
+			case symbol == RFIS && evt.ModuleId == config.RFisModuleId && evt.EventId == config.RFisUnbondEventId:
+				unbond, err := EventRFisUnbondData(evt)
+				if err != nil {
+					return nil, fmt.Errorf("block %d: %s", number, err)
+				}
+				if unbond.From == who {
+					chunk = &UserUnlockChunk{Pool: unbond.Pool, Value: unbond.Value}
+				}
+			case symbol == RFIS && evt.ModuleId == config.RFisModuleId && evt.EventId == config.RFisWithdrawUnbondEventId:
+				withdraw, err := EventWithdrawUnbondData(evt)
+				if err != nil {
+					return nil, fmt.Errorf("block %d: %s", number, err)
+				}
+				if withdraw.From == who {
+					if err := schedule.removeWithdrawn(c, number, withdraw.Value); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if chunk == nil {
+				continue
+			}
+
+			if !durationKnown {
+				return nil, fmt.Errorf("block %d: bonding duration of %s unknown", number, symbol)
+			}
+			era, err := eraAt(c, symbol, number)
+			if err != nil {
+				return nil, err
+			}
+			chunk.UnlockEra = era + duration
+			schedule.Chunks = append(schedule.Chunks, &UnbondChunk{UserUnlockChunk: *chunk, Block: number})
+		}
+		if number == toBlock {
+			break
+		}
+	}
+
+	toHash, err := blockHashOf(c, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.CurrentEra, err = c.CurrentChainEra(symbol, toHash); err != nil {
+		return nil, fmt.Errorf("chain era: %s", err)
+	}
+	schedule.BondingDuration = duration
+	schedule.sum()
+	return schedule, nil
+}
+
+// removeWithdrawn drops the chunks unlocked at block number that add up to value, oldest first
+func (s *WithdrawSchedule) removeWithdrawn(c Chain, number uint64, value types.U128) error {
+	era, err := eraAt(c, s.Symbol, number)
+	if err != nil {
+		return err
+	}
+	left := new(big.Int).Set(value.Int)
+	kept := s.Chunks[:0]
+	for _, chunk := range s.Chunks {
+		if left.Sign() > 0 && chunk.UnlockEra <= era && chunk.Value.Cmp(left) <= 0 {
+			left.Sub(left, chunk.Value.Int)
+			continue
+		}
+		kept = append(kept, chunk)
+	}
+	s.Chunks = kept
+	return nil
+}
+
+func blockHashOf(c HeaderReader, number uint64) (types.Hash, error) {
+	hashStr, err := c.GetBlockHash(number)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return types.NewHashFromHexString(hashStr)
+}
+
+func eraAt(c Chain, symbol RSymbol, number uint64) (uint32, error) {
+	blockHash, err := blockHashOf(c, number)
+	if err != nil {
+		return 0, err
+	}
+	era, err := c.CurrentChainEra(symbol, blockHash)
+	if err != nil {
+		return 0, fmt.Errorf("block %d: chain era: %s", number, err)
+	}
+	return era, nil
+}
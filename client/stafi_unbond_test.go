@@ -0,0 +1,112 @@
+package client_test
+
+import (
+	"math/big"
+	"testing"
+
+	scale "github.com/itering/scale.go"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/client/clientfake"
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func params(values ...interface{}) []scale.EventParam {
+	ps := make([]scale.EventParam, 0, len(values))
+	for _, v := range values {
+		ps = append(ps, scale.EventParam{Value: v})
+	}
+	return ps
+}
+
+func TestUnbondSchedule(t *testing.T) {
+	headers := clientfake.NewHeaders()
+	events := clientfake.NewEvents(headers)
+	rTokens := clientfake.NewRTokens()
+	c := client.NewChain(headers, nil, events, nil, rTokens)
+
+	who := types.NewAccountID([]byte{0x01})
+	whoHex := types.HexEncodeToString(who[:])
+	rTokens.ChainEras[client.RDOT] = 10
+	rTokens.ChainEras[client.RFIS] = 10
+	rTokens.UnbondingDurations[client.RDOT] = 28
+
+	// storage
+	rTokens.AccountUnbondsOf[client.RDOT] = map[string][]client.UserUnlockChunk{whoHex: {
+		{Pool: types.Bytes{0xaa}, UnlockEra: 9, Value: types.NewU128(*big.NewInt(100))},
+		{Pool: types.Bytes{0xaa}, UnlockEra: 12, Value: types.NewU128(*big.NewInt(50))},
+	}}
+	schedule, err := client.UnbondSchedule(c, client.RDOT, who, 0)
+	assert.NoError(t, err)
+	assert.False(t, schedule.FromEvents)
+	assert.Equal(t, uint32(28), schedule.BondingDuration)
+	assert.True(t, schedule.Chunks[0].Withdrawable)
+	assert.Equal(t, types.NewU128(*big.NewInt(100)), schedule.Withdrawable)
+	assert.Equal(t, types.NewU128(*big.NewInt(50)), schedule.Pending)
+	next, ok := schedule.NextUnlockEra()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(12), next)
+
+	// an account without unbonds has an empty schedule and a missing duration is not an error
+	delete(rTokens.UnbondingDurations, client.RDOT)
+	schedule, err = client.UnbondSchedule(c, client.RDOT, types.NewAccountID([]byte{0x02}), 0)
+	assert.NoError(t, err)
+	assert.False(t, schedule.FromEvents)
+	assert.Empty(t, schedule.Chunks)
+	assert.Zero(t, schedule.BondingDuration)
+
+	// events
+	delete(rTokens.AccountUnbondsOf, client.RDOT)
+	events.Add(headers.Push(), &client.ChainEvent{ModuleId: config.RTokenLedgerModuleId, EventId: config.BondingDurationEventId,
+		Params: params("RDOT", float64(28), float64(2))})
+	events.Add(headers.Push(),
+		&client.ChainEvent{ModuleId: config.RTokenSeriesModuleId, EventId: config.RTokenUnbondEventId,
+			Params: params(whoHex, "RDOT", "0xaa", "70", "0", "1000", "0xbb")},
+		&client.ChainEvent{ModuleId: config.RTokenSeriesModuleId, EventId: config.RTokenUnbondEventId,
+			Params: params(whoHex, "RKSM", "0xaa", "30", "0", "1000", "0xbb")},
+		&client.ChainEvent{ModuleId: config.RFisModuleId, EventId: config.RFisUnbondEventId,
+			Params: params(whoHex, "0xcc", "40", "0", "1000")})
+
+	schedule, err = client.UnbondSchedule(c, client.RDOT, who, 1)
+	assert.NoError(t, err)
+	assert.True(t, schedule.FromEvents)
+	assert.Len(t, schedule.Chunks, 1)
+	assert.Equal(t, client.UserUnlockChunk{Pool: types.Bytes{0xaa}, UnlockEra: 12, Value: types.NewU128(*big.NewInt(70)),
+		Recipient: types.Bytes{0xbb}}, schedule.Chunks[0].UserUnlockChunk)
+	assert.Equal(t, uint64(2), schedule.Chunks[0].Block)
+	assert.Equal(t, types.NewU128(*big.NewInt(70)), schedule.Pending)
+	assert.False(t, schedule.WithdrawalsKnown)
+
+	// rKSM withdrawals have no events, an unlocked chunk is not known to be withdrawable
+	rTokens.ChainEras[client.RKSM] = 10
+	rTokens.UnbondingDurations[client.RKSM] = 0
+	schedule, err = client.UnbondSchedule(c, client.RKSM, who, 1)
+	assert.NoError(t, err)
+	assert.True(t, schedule.Chunks[0].Unlocked)
+	assert.False(t, schedule.Chunks[0].Withdrawable)
+	assert.Equal(t, types.NewU128(*big.NewInt(30)), schedule.Unlocked)
+	assert.Zero(t, schedule.Withdrawable.Int64())
+	_, ok = schedule.NextUnlockEra()
+	assert.False(t, ok)
+
+	// rFIS unlocks at once without bonding duration and its withdrawal is seen
+	rTokens.UnbondingDurations[client.RFIS] = 0
+	schedule, err = client.UnbondScheduleFromEvents(c, client.RFIS, who, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewU128(*big.NewInt(40)), schedule.Withdrawable)
+	events.Add(headers.Push(), &client.ChainEvent{ModuleId: config.RFisModuleId, EventId: config.RFisWithdrawUnbondEventId,
+		Params: params(whoHex, whoHex, "40")})
+	schedule, err = client.UnbondScheduleFromEvents(c, client.RFIS, who, 1, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, schedule.Chunks)
+
+	// the fallback scans a bounded range
+	for i := 0; i < client.MaxUnbondScanBlocks; i++ {
+		headers.Push()
+	}
+	_, err = client.UnbondSchedule(c, client.RDOT, who, 1)
+	assert.Error(t, err)
+	_, err = client.UnbondSchedule(c, client.RDOT, who, 5)
+	assert.NoError(t, err)
+}
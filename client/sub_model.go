@@ -1,6 +1,8 @@
 package client
 
 import (
+	"fmt"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 	commonTypes "github.com/stafiprotocol/go-substrate-rpc-client/types/common"
 )
@@ -37,4 +39,47 @@ type Transaction struct {
 	CallName       string
 	Address        interface{}
 	Params         []commonTypes.ExtrinsicParam
+
+	// filled by GetBlockView only
+	Index   int
+	Events  []*ChainEvent
+	Success bool
+	Error   *DispatchError
+	Weight  uint64
+	// Fee is nil if the events do not tell the fee
+	Fee *types.U128
+}
+
+// DispatchError is the decoded error of a failed extrinsic
+type DispatchError struct {
+	// Kind is the DispatchError variant, e.g. Module, BadOrigin or Token
+	Kind        string
+	ModuleIndex int
+	ErrorIndex  int
+	ModuleName  string
+	ErrorName   string
+	// Detail is the raw value of variants other than Module
+	Detail interface{}
+}
+
+func (e *DispatchError) Error() string {
+	if e.Kind == "Module" {
+		if e.ModuleName != "" && e.ErrorName != "" {
+			return fmt.Sprintf("%s.%s", e.ModuleName, e.ErrorName)
+		}
+		return fmt.Sprintf("module error: index %d, error %d", e.ModuleIndex, e.ErrorIndex)
+	}
+	if e.Detail != nil {
+		return fmt.Sprintf("%s: %v", e.Kind, e.Detail)
+	}
+	return e.Kind
+}
+
+type BlockView struct {
+	Hash      string
+	Timestamp uint64
+	// Transactions are the signed extrinsics of the block in their order, with their events attached
+	Transactions []*Transaction
+	// Events are all events of the block, including the ones of inherents, initialization and finalization
+	Events []*ChainEvent
 }
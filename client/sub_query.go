@@ -1,33 +1,31 @@
 package client
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/gorilla/websocket"
-	scalecodec "github.com/itering/scale.go"
-	scaleTypes "github.com/itering/scale.go/types"
-	scaleBytes "github.com/itering/scale.go/types/scaleBytes"
 	"github.com/itering/scale.go/utiles"
 	"github.com/itering/substrate-api-rpc/model"
 	"github.com/itering/substrate-api-rpc/rpc"
 	"github.com/stafiprotocol/go-substrate-rpc-client/config"
-	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/stafidecoder"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/wsmux"
 	gsrpc "github.com/stafiprotocol/go-substrate-rpc-client/rpc"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
-	commonTypes "github.com/stafiprotocol/go-substrate-rpc-client/types/common"
 )
 
 func (sc *GsrpcClient) FlashApi() (*gsrpc.RPCS, error) {
+	// the multi endpoint client reconnects and fails over by itself
+	if sc.multi != nil {
+		return sc.rpcs, nil
+	}
 	_, err := sc.rpcs.Chain.GetBlockHashLatest()
 	if err != nil {
 		var rpcs *gsrpc.RPCS
 		for i := 0; i < 3; i++ {
-			rpcs, err = gsrpc.NewRPCS(sc.endpoint)
+			rpcs, err = gsrpc.NewRPCSWithConfig(sc.endpoint, sc.cfg)
 			if err == nil {
 				break
 			} else {
@@ -46,7 +44,11 @@ func (sc *GsrpcClient) Address() string {
 }
 
 func (sc *GsrpcClient) GetLatestBlockNumber() (uint64, error) {
-	h, err := sc.GetHeaderLatest()
+	return sc.GetLatestBlockNumberContext(context.Background())
+}
+
+func (sc *GsrpcClient) GetLatestBlockNumberContext(ctx context.Context) (uint64, error) {
+	h, err := sc.GetHeaderLatestContext(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -55,12 +57,16 @@ func (sc *GsrpcClient) GetLatestBlockNumber() (uint64, error) {
 }
 
 func (sc *GsrpcClient) GetFinalizedBlockNumber() (uint64, error) {
-	hash, err := sc.GetFinalizedHead()
+	return sc.GetFinalizedBlockNumberContext(context.Background())
+}
+
+func (sc *GsrpcClient) GetFinalizedBlockNumberContext(ctx context.Context) (uint64, error) {
+	hash, err := sc.GetFinalizedHeadContext(ctx)
 	if err != nil {
 		return 0, err
 	}
 
-	header, err := sc.GetHeader(hash)
+	header, err := sc.GetHeaderContext(ctx, hash)
 	if err != nil {
 		return 0, err
 	}
@@ -69,31 +75,47 @@ func (sc *GsrpcClient) GetFinalizedBlockNumber() (uint64, error) {
 }
 
 func (sc *GsrpcClient) GetHeaderLatest() (*types.Header, error) {
+	return sc.GetHeaderLatestContext(context.Background())
+}
+
+func (sc *GsrpcClient) GetHeaderLatestContext(ctx context.Context) (*types.Header, error) {
 	api, err := sc.FlashApi()
 	if err != nil {
 		return nil, err
 	}
-	return api.Chain.GetHeaderLatest()
+	return api.Chain.GetHeaderLatestContext(ctx)
 }
 
 func (sc *GsrpcClient) GetFinalizedHead() (types.Hash, error) {
+	return sc.GetFinalizedHeadContext(context.Background())
+}
+
+func (sc *GsrpcClient) GetFinalizedHeadContext(ctx context.Context) (types.Hash, error) {
 	api, err := sc.FlashApi()
 	if err != nil {
 		return types.NewHash([]byte{}), err
 	}
-	return api.Chain.GetFinalizedHead()
+	return api.Chain.GetFinalizedHeadContext(ctx)
 }
 
 func (sc *GsrpcClient) GetHeader(blockHash types.Hash) (*types.Header, error) {
+	return sc.GetHeaderContext(context.Background(), blockHash)
+}
+
+func (sc *GsrpcClient) GetHeaderContext(ctx context.Context, blockHash types.Hash) (*types.Header, error) {
 	api, err := sc.FlashApi()
 	if err != nil {
 		return nil, err
 	}
-	return api.Chain.GetHeader(blockHash)
+	return api.Chain.GetHeaderContext(ctx, blockHash)
 }
 
 func (sc *GsrpcClient) GetBlockNumber(blockHash types.Hash) (uint64, error) {
-	head, err := sc.GetHeader(blockHash)
+	return sc.GetBlockNumberContext(context.Background(), blockHash)
+}
+
+func (sc *GsrpcClient) GetBlockNumberContext(ctx context.Context, blockHash types.Hash) (uint64, error) {
+	head, err := sc.GetHeaderContext(ctx, blockHash)
 	if err != nil {
 		return 0, err
 	}
@@ -101,57 +123,87 @@ func (sc *GsrpcClient) GetBlockNumber(blockHash types.Hash) (uint64, error) {
 	return uint64(head.Number), nil
 }
 
-// queryStorage performs a storage lookup. Arguments may be nil, result must be a pointer.
-func (sc *GsrpcClient) QueryStorage(prefix, method string, arg1, arg2 []byte, result interface{}) (bool, error) {
-	entry, err := sc.FindStorageEntryMetadata(prefix, method)
+// QueryStorage performs a storage lookup. Arguments may be nil, result must be a pointer. An optional block hash
+// queries the state at that block, the storage key is then built with the metadata active at that block.
+func (sc *GsrpcClient) QueryStorage(prefix, method string, arg1, arg2 []byte, result interface{}, blockHash ...types.Hash) (bool, error) {
+	return sc.QueryStorageContext(context.Background(), prefix, method, arg1, arg2, result, blockHash...)
+}
+
+func (sc *GsrpcClient) QueryStorageContext(ctx context.Context, prefix, method string, arg1, arg2 []byte, result interface{}, blockHash ...types.Hash) (bool, error) {
+	at := optionalBlockHash(blockHash)
+	key, err := sc.storageKey(ctx, prefix, method, arg1, arg2, at)
+	if err != nil {
+		return false, err
+	}
+
+	api, err := sc.FlashApi()
+	if err != nil {
+		return false, err
+	}
+
+	if at != nil {
+		return api.State.GetStorageContext(ctx, key, result, *at)
+	}
+
+	ok, err := api.State.GetStorageLatestContext(ctx, key, result)
 	if err != nil {
 		return false, err
 	}
 
+	return ok, nil
+}
+
+func optionalBlockHash(blockHash []types.Hash) *types.Hash {
+	if len(blockHash) == 0 {
+		return nil
+	}
+	return &blockHash[0]
+}
+
+func (sc *GsrpcClient) storageKey(ctx context.Context, prefix, method string, arg1, arg2 []byte, blockHash *types.Hash) (types.StorageKey, error) {
+	entry, metaDataVersion, err := sc.findStorageEntryMetadata(ctx, prefix, method, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
 	var key types.StorageKey
 	keySeted := false
 	if entry.IsNMap() {
 		hashers, err := entry.Hashers()
 		if err != nil {
-			return false, err
+			return nil, err
 		}
 
 		if len(hashers) == 1 {
-			key, err = types.CreateStorageKeyWithEntryMeta(uint8(sc.metaDataVersion), entry, prefix, method, arg1)
+			key, err = types.CreateStorageKeyWithEntryMeta(metaDataVersion, entry, prefix, method, arg1)
 			if err != nil {
-				return false, err
+				return nil, err
 			}
 			keySeted = true
 		}
 	}
 
 	if !keySeted {
-		key, err = types.CreateStorageKeyWithEntryMeta(uint8(sc.metaDataVersion), entry, prefix, method, arg1, arg2)
+		key, err = types.CreateStorageKeyWithEntryMeta(metaDataVersion, entry, prefix, method, arg1, arg2)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
 	}
+	sc.log.Trace("QueryStorage", "entry.IsNMap", entry.IsNMap(), "metaDataVersion", metaDataVersion, "prefix", prefix, "method", method, "arg1", arg1, "arg2", arg2, "storageKey", hexutil.Encode(key))
 
-	api, err := sc.FlashApi()
-	if err != nil {
-		return false, err
-	}
-	sc.log.Trace("QueryStorage", "entry.IsNMap", entry.IsNMap(), "metaDataVersion", sc.metaDataVersion, "prefix", prefix, "method", method, "arg1", arg1, "arg2", arg2, "storageKey", hexutil.Encode(key))
-
-	ok, err := api.State.GetStorageLatest(key, result)
-	if err != nil {
-		return false, err
-	}
-
-	return ok, nil
+	return key, nil
 }
 
 func (sc *GsrpcClient) GetLatestRuntimeVersion() (*types.RuntimeVersion, error) {
+	return sc.GetLatestRuntimeVersionContext(context.Background())
+}
+
+func (sc *GsrpcClient) GetLatestRuntimeVersionContext(ctx context.Context) (*types.RuntimeVersion, error) {
 	api, err := sc.FlashApi()
 	if err != nil {
 		return nil, err
 	}
-	rv, err := api.State.GetRuntimeVersionLatest()
+	rv, err := api.State.GetRuntimeVersionLatestContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +212,11 @@ func (sc *GsrpcClient) GetLatestRuntimeVersion() (*types.RuntimeVersion, error)
 }
 
 func (sc *GsrpcClient) GetLatestNonce() (types.U32, error) {
-	ac, err := sc.GetAccountInfo()
+	return sc.GetLatestNonceContext(context.Background())
+}
+
+func (sc *GsrpcClient) GetLatestNonceContext(ctx context.Context) (types.U32, error) {
+	ac, err := sc.GetAccountInfoContext(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -168,9 +224,13 @@ func (sc *GsrpcClient) GetLatestNonce() (types.U32, error) {
 	return ac.Nonce, nil
 }
 
-func (sc *GsrpcClient) GetAccountInfo() (*types.AccountInfo, error) {
+func (sc *GsrpcClient) GetAccountInfo(blockHash ...types.Hash) (*types.AccountInfo, error) {
+	return sc.GetAccountInfoContext(context.Background(), blockHash...)
+}
+
+func (sc *GsrpcClient) GetAccountInfoContext(ctx context.Context, blockHash ...types.Hash) (*types.AccountInfo, error) {
 	ac := new(types.AccountInfo)
-	exist, err := sc.QueryStorage("System", "Account", sc.key.PublicKey, nil, &ac)
+	exist, err := sc.QueryStorageContext(ctx, "System", "Account", sc.key.PublicKey, nil, &ac, blockHash...)
 	if err != nil {
 		return nil, err
 	}
@@ -186,9 +246,13 @@ func (sc *GsrpcClient) PublicKey() []byte {
 	return sc.key.PublicKey
 }
 
-func (sc *GsrpcClient) StakingLedger(ac types.AccountID) (*StakingLedger, error) {
+func (sc *GsrpcClient) StakingLedger(ac types.AccountID, blockHash ...types.Hash) (*StakingLedger, error) {
+	return sc.StakingLedgerContext(context.Background(), ac, blockHash...)
+}
+
+func (sc *GsrpcClient) StakingLedgerContext(ctx context.Context, ac types.AccountID, blockHash ...types.Hash) (*StakingLedger, error) {
 	s := new(StakingLedger)
-	exist, err := sc.QueryStorage(config.StakingModuleId, config.StorageLedger, ac[:], nil, s)
+	exist, err := sc.QueryStorageContext(ctx, config.StakingModuleId, config.StorageLedger, ac[:], nil, s, blockHash...)
 	if err != nil {
 		return nil, err
 	}
@@ -200,16 +264,20 @@ func (sc *GsrpcClient) StakingLedger(ac types.AccountID) (*StakingLedger, error)
 	return s, nil
 }
 
-func (sc *GsrpcClient) FreeBalance(who []byte) (types.U128, error) {
+func (sc *GsrpcClient) FreeBalance(who []byte, blockHash ...types.Hash) (types.U128, error) {
+	return sc.FreeBalanceContext(context.Background(), who, blockHash...)
+}
+
+func (sc *GsrpcClient) FreeBalanceContext(ctx context.Context, who []byte, blockHash ...types.Hash) (types.U128, error) {
 	if sc.addressType == AddressTypeMultiAddress {
-		info, err := sc.NewVersionAccountInfo(who)
+		info, err := sc.NewVersionAccountInfoContext(ctx, who, blockHash...)
 		if err != nil {
 			return types.U128{}, err
 		}
 		return info.Data.Free, nil
 	}
 
-	info, err := sc.AccountInfo(who)
+	info, err := sc.AccountInfoContext(ctx, who, blockHash...)
 	if err != nil {
 		return types.U128{}, err
 	}
@@ -217,9 +285,13 @@ func (sc *GsrpcClient) FreeBalance(who []byte) (types.U128, error) {
 	return info.Data.Free, nil
 }
 
-func (sc *GsrpcClient) AccountInfo(who []byte) (*types.AccountInfo, error) {
+func (sc *GsrpcClient) AccountInfo(who []byte, blockHash ...types.Hash) (*types.AccountInfo, error) {
+	return sc.AccountInfoContext(context.Background(), who, blockHash...)
+}
+
+func (sc *GsrpcClient) AccountInfoContext(ctx context.Context, who []byte, blockHash ...types.Hash) (*types.AccountInfo, error) {
 	ac := new(types.AccountInfo)
-	exist, err := sc.QueryStorage(config.SystemModuleId, config.StorageAccount, who, nil, ac)
+	exist, err := sc.QueryStorageContext(ctx, config.SystemModuleId, config.StorageAccount, who, nil, ac, blockHash...)
 	if err != nil {
 		return nil, err
 	}
@@ -231,9 +303,13 @@ func (sc *GsrpcClient) AccountInfo(who []byte) (*types.AccountInfo, error) {
 	return ac, nil
 }
 
-func (sc *GsrpcClient) NewVersionAccountInfo(who []byte) (*AccountInfo, error) {
+func (sc *GsrpcClient) NewVersionAccountInfo(who []byte, blockHash ...types.Hash) (*AccountInfo, error) {
+	return sc.NewVersionAccountInfoContext(context.Background(), who, blockHash...)
+}
+
+func (sc *GsrpcClient) NewVersionAccountInfoContext(ctx context.Context, who []byte, blockHash ...types.Hash) (*AccountInfo, error) {
 	ac := new(AccountInfo)
-	exist, err := sc.QueryStorage(config.SystemModuleId, config.StorageAccount, who, nil, ac)
+	exist, err := sc.QueryStorageContext(ctx, config.SystemModuleId, config.StorageAccount, who, nil, ac, blockHash...)
 	if err != nil {
 		return nil, err
 	}
@@ -244,188 +320,81 @@ func (sc *GsrpcClient) NewVersionAccountInfo(who []byte) (*AccountInfo, error) {
 	return ac, nil
 }
 
-func (sc *GsrpcClient) ExistentialDeposit() (types.U128, error) {
+func (sc *GsrpcClient) ExistentialDeposit(blockHash ...types.Hash) (types.U128, error) {
+	return sc.ExistentialDepositContext(context.Background(), blockHash...)
+}
+
+func (sc *GsrpcClient) ExistentialDepositContext(ctx context.Context, blockHash ...types.Hash) (types.U128, error) {
 	_, err := sc.FlashApi()
 	if err != nil {
 		return types.U128{}, err
 	}
 	var e types.U128
-	err = sc.GetConst(config.BalancesModuleId, config.ConstExistentialDeposit, &e)
+	err = sc.GetConstContext(ctx, config.BalancesModuleId, config.ConstExistentialDeposit, &e, blockHash...)
 	if err != nil {
 		return types.U128{}, err
 	}
 	return e, nil
 }
 
-func (sc *GsrpcClient) GetConst(prefix, name string, res interface{}) error {
-
-	switch sc.chainType {
-	case ChainTypeStafi:
-		return sc.rpcs.State.GetConst(prefix, name, &res)
-	case ChainTypePolkadot:
-		blockHash, err := sc.GetFinalizedHead()
-		if err != nil {
-			return err
-		}
-		md, err := sc.getPolkaMetaDecoder(blockHash.Hex())
-		if err != nil {
-			return err
-		}
-
-		for _, mod := range md.Metadata.Metadata.Modules {
-			if string(mod.Prefix) == prefix {
-				for _, cons := range mod.Constants {
-					if cons.Name == name {
+// GetConst reads a module constant, from the metadata of the optional block hash or the latest one
+func (sc *GsrpcClient) GetConst(prefix, name string, res interface{}, blockHash ...types.Hash) error {
+	return sc.GetConstContext(context.Background(), prefix, name, res, blockHash...)
+}
 
-						return types.DecodeFromHexString(cons.ConstantsValue, res)
-					}
-				}
-			}
-		}
-		return fmt.Errorf("could not find constant %s.%s", prefix, name)
-	default:
-		return errors.New("GetConst chainType not supported")
+func (sc *GsrpcClient) GetConstContext(ctx context.Context, prefix, name string, res interface{}, blockHash ...types.Hash) error {
+	md, err := sc.metaDecoderAt(ctx, optionalBlockHash(blockHash))
+	if err != nil {
+		return err
+	}
+	value, err := md.ConstValue(prefix, name)
+	if err != nil {
+		return err
 	}
+	return types.DecodeFromBytes(value, res)
 }
 
 func (sc *GsrpcClient) FindStorageEntryMetadata(module string, fn string) (types.StorageEntryMetadata, error) {
-	switch sc.chainType {
-	case ChainTypeStafi:
-		meta, err := sc.rpcs.State.GetMetadataLatest()
-		if err != nil {
-			return nil, err
-		}
+	return sc.FindStorageEntryMetadataContext(context.Background(), module, fn)
+}
 
-		return meta.FindStorageEntryMetadata(module, fn)
-	case ChainTypePolkadot:
-		blockHash, err := sc.GetFinalizedHead()
-		if err != nil {
-			return nil, err
-		}
-		md, err := sc.getPolkaMetaDecoder(blockHash.Hex())
-		if err != nil {
-			return nil, err
-		}
+func (sc *GsrpcClient) FindStorageEntryMetadataContext(ctx context.Context, module string, fn string) (types.StorageEntryMetadata, error) {
+	entry, _, err := sc.findStorageEntryMetadata(ctx, module, fn, nil)
+	return entry, err
+}
 
-		for _, mod := range md.Metadata.Metadata.Modules {
-			if string(mod.Prefix) != module {
-				continue
-			}
-			for _, s := range mod.Storage {
-				if string(s.Name) != fn {
-					continue
-				}
-
-				sfm := types.StorageFunctionMetadataV13{
-					Name: types.Text(s.Name),
-				}
-
-				if s.Type.PlainType != nil {
-					sfm.Type = types.StorageFunctionTypeV13{
-						IsType: true,
-						AsType: types.Type(*s.Type.PlainType),
-					}
-				}
-
-				if s.Type.DoubleMapType != nil {
-					dmt := types.DoubleMapTypeV10{
-						Key1:       types.Type(s.Type.DoubleMapType.Key),
-						Key2:       types.Type(s.Type.DoubleMapType.Key2),
-						Value:      types.Type(s.Type.DoubleMapType.Value),
-						Hasher:     TransformHasher(s.Type.DoubleMapType.Hasher),
-						Key2Hasher: TransformHasher(s.Type.DoubleMapType.Key2Hasher),
-					}
-
-					sfm.Type = types.StorageFunctionTypeV13{
-						IsDoubleMap: true,
-						AsDoubleMap: dmt,
-					}
-				}
-
-				if s.Type.MapType != nil {
-					mt := types.MapTypeV10{
-						Key:    types.Type(s.Type.MapType.Key),
-						Value:  types.Type(s.Type.MapType.Value),
-						Linked: s.Type.MapType.IsLinked,
-						Hasher: TransformHasher(s.Type.MapType.Hasher),
-					}
-
-					sfm.Type = types.StorageFunctionTypeV13{
-						IsMap: true,
-						AsMap: mt,
-					}
-				}
-
-				if s.Type.NMapType != nil {
-					keys := make([]types.Type, 0)
-					for _, key := range s.Type.NMapType.KeyVec {
-						keys = append(keys, types.Type(key))
-					}
-
-					hashers := make([]types.StorageHasherV10, 0)
-					for _, hasher := range s.Type.NMapType.Hashers {
-						hashers = append(hashers, TransformHasher(hasher))
-					}
-
-					nmt := types.NMapTypeV13{
-						Keys:    keys,
-						Hashers: hashers,
-						Value:   types.Type(s.Type.NMapType.Value),
-					}
-
-					sfm.Type = types.StorageFunctionTypeV13{
-						IsNMap: true,
-						AsNMap: nmt,
-					}
-				}
-
-				return sfm, nil
-			}
-			return nil, fmt.Errorf("storage %v not found within module %v", fn, module)
-		}
-		return nil, fmt.Errorf("module %v not found in metadata", module)
-	default:
-		return nil, errors.New("chainType not supported")
+// findStorageEntryMetadata returns the storage entry and the metadata version at blockHash, or the latest ones if
+// blockHash is nil
+func (sc *GsrpcClient) findStorageEntryMetadata(ctx context.Context, module string, fn string, blockHash *types.Hash) (types.StorageEntryMetadata, uint8, error) {
+	md, err := sc.metaDecoderAt(ctx, blockHash)
+	if err != nil {
+		return nil, 0, err
+	}
+	entry, err := md.StorageEntry(module, fn)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %s", ErrorStorageNotFound, err)
 	}
+	return entry, uint8(md.MetadataVersion()), nil
 }
 
 func (sc *GsrpcClient) FindCallIndex(call string) (types.CallIndex, error) {
-	switch sc.chainType {
-	case ChainTypeStafi:
-		meta, err := sc.rpcs.State.GetMetadataLatest()
-		if err != nil {
-			return types.CallIndex{}, err
-		}
-
-		return meta.FindCallIndex(call)
-	case ChainTypePolkadot:
-		blockHash, err := sc.GetFinalizedHead()
-		if err != nil {
-			return types.CallIndex{}, err
-		}
-
-		md, err := sc.getPolkaMetaDecoder(blockHash.Hex())
-		if err != nil {
-			return types.CallIndex{}, err
-		}
-		s := strings.Split(call, ".")
+	return sc.FindCallIndexContext(context.Background(), call)
+}
 
-		for _, mod := range md.Metadata.Metadata.Modules {
-			if string(mod.Name) != s[0] {
-				continue
-			}
-			for ci, f := range mod.Calls {
-				if string(f.Name) == s[1] {
-					return types.CallIndex{SectionIndex: uint8(mod.Index), MethodIndex: uint8(ci)}, nil
-				}
-			}
-			return types.CallIndex{}, fmt.Errorf("method %v not found within module %v for call %v", s[1], mod.Name, call)
-		}
-		return types.CallIndex{}, fmt.Errorf("module %v not found in metadata for call %v", s[0], call)
+func (sc *GsrpcClient) FindCallIndexContext(ctx context.Context, call string) (types.CallIndex, error) {
+	md, err := sc.getLatestMetaDecoder(ctx)
+	if err != nil {
+		return types.CallIndex{}, err
+	}
+	return md.CallIndex(call)
+}
 
-	default:
-		return types.CallIndex{}, errors.New("FindCallIndex chainType not supported")
+// metaDecoderAt returns the decoder at blockHash, the latest one if blockHash is nil
+func (sc *GsrpcClient) metaDecoderAt(ctx context.Context, blockHash *types.Hash) (metaDecoder, error) {
+	if blockHash == nil {
+		return sc.getLatestMetaDecoder(ctx)
 	}
+	return sc.getMetaDecoder(ctx, blockHash.Hex())
 }
 
 func TransformHasher(Hasher string) types.StorageHasherV10 {
@@ -456,47 +425,74 @@ func TransformHasher(Hasher string) types.StorageHasherV10 {
 	return types.StorageHasherV10{IsIdentity: true}
 }
 
+// wsClient returns the websocket client of the active endpoint, ErrorNoEndpoint if the client was not built with one
+func (sc *GsrpcClient) wsClient() (*wsmux.Client, error) {
+	endpoint := sc.endpoint
+	if sc.multi != nil {
+		endpoint = sc.multi.URL()
+	}
+	if endpoint == "" {
+		return nil, ErrorNoEndpoint
+	}
+
+	sc.Lock()
+	defer sc.Unlock()
+	if sc.wsClients == nil {
+		sc.wsClients = make(map[string]*wsmux.Client)
+	}
+	c, exist := sc.wsClients[endpoint]
+	if !exist {
+		c = wsmux.NewClient(endpoint, wsmux.Config{HandshakeTimeout: sc.cfg.DialTimeout, Hooks: sc.cfg.Instrumentation})
+		sc.wsClients[endpoint] = c
+	}
+	return c, nil
+}
+
+// sendWsRequest sends a request built by the itering rpc helpers over the multiplexed websocket client. Requests that
+// lost their connection are sent again on a new one, they are all reads.
 func (sc *GsrpcClient) sendWsRequest(v interface{}, action []byte) error {
+	return sc.sendWsRequestContext(context.Background(), v, action)
+}
 
+func (sc *GsrpcClient) sendWsRequestContext(ctx context.Context, v interface{}, action []byte) error {
 	retry := 0
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if retry >= 100 {
 			return fmt.Errorf("sendWsRequest reach retry limit")
 		}
 
-		if poolConn, err := sc.initial(); err != nil {
+		ws, err := sc.wsClient()
+		if err != nil {
 			return err
-		} else {
-
-			if err = poolConn.Conn.WriteMessage(websocket.TextMessage, action); err != nil {
-				poolConn.MarkUnusable()
-				sc.wsPool.Put(poolConn)
-
-				sc.log.Debug("websocket send error", "err", err)
-				time.Sleep(time.Millisecond * 100)
-				retry++
-				continue
-			}
-
-			if err = poolConn.Conn.ReadJSON(v); err != nil {
-				poolConn.MarkUnusable()
-				sc.wsPool.Put(poolConn)
-
-				sc.log.Debug("websocket read error", "err", err)
-				time.Sleep(time.Millisecond * 100)
-				retry++
-				continue
-			}
-
-			sc.wsPool.Put(poolConn)
+		}
+		err = ws.SendContext(ctx, action, v)
+		if err == nil {
 			return nil
 		}
+		if !errors.Is(err, wsmux.ErrConnectionLost) && !errors.Is(err, wsmux.ErrNotConnected) {
+			return err
+		}
+
+		sc.log.Debug("websocket request error", "err", err)
+		select {
+		case <-time.After(time.Millisecond * 100):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		retry++
 	}
 }
 
 func (sc *GsrpcClient) GetBlock(blockHash string) (*model.Block, error) {
+	return sc.GetBlockContext(context.Background(), blockHash)
+}
+
+func (sc *GsrpcClient) GetBlockContext(ctx context.Context, blockHash string) (*model.Block, error) {
 	v := &model.JsonRpcResult{}
-	if err := sc.sendWsRequest(v, rpc.ChainGetBlock(wsId, blockHash)); err != nil {
+	if err := sc.sendWsRequestContext(ctx, v, rpc.ChainGetBlock(wsId, blockHash)); err != nil {
 		return nil, err
 	}
 	rpcBlock := v.ToBlock()
@@ -504,79 +500,40 @@ func (sc *GsrpcClient) GetBlock(blockHash string) (*model.Block, error) {
 }
 
 func (sc *GsrpcClient) GetExtrinsics(blockHash string) ([]*Transaction, error) {
-	blk, err := sc.GetBlock(blockHash)
+	return sc.GetExtrinsicsContext(context.Background(), blockHash)
+}
+
+func (sc *GsrpcClient) GetExtrinsicsContext(ctx context.Context, blockHash string) ([]*Transaction, error) {
+	blk, err := sc.GetBlockContext(ctx, blockHash)
 	if err != nil {
 		return nil, err
 	}
 
+	md, err := sc.getMetaDecoder(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
 	exts := make([]*Transaction, 0)
-	switch sc.chainType {
-	case ChainTypeStafi:
-		md, err := sc.getStafiMetaDecoder(blockHash)
-		if err != nil {
-			return nil, err
-		}
-		e := new(stafi_decoder.ExtrinsicDecoder)
-		option := stafi_decoder.ScaleDecoderOption{Metadata: &md.Metadata}
-		for _, raw := range blk.Extrinsics {
-			e.Init(stafi_decoder.ScaleBytes{Data: utiles.HexToBytes(raw)}, &option)
-			e.Process()
-			if e.ExtrinsicHash != "" && e.ContainsTransaction {
-				ext := &Transaction{
-					ExtrinsicHash:  e.ExtrinsicHash,
-					CallModuleName: e.CallModule.Name,
-					CallName:       e.Call.Name,
-					Address:        e.Address,
-					Params:         e.Params,
-				}
-				exts = append(exts, ext)
-			}
-		}
-		return exts, nil
-	case ChainTypePolkadot:
-		md, err := sc.getPolkaMetaDecoder(blockHash)
+	for _, raw := range blk.Extrinsics {
+		e, err := md.DecodeExtrinsic(raw)
 		if err != nil {
 			return nil, err
 		}
-		e := new(scalecodec.ExtrinsicDecoder)
-		option := scaleTypes.ScaleDecoderOption{Metadata: &md.Metadata}
-		for _, raw := range blk.Extrinsics {
-			e.Init(scaleBytes.ScaleBytes{Data: utiles.HexToBytes(raw)}, &option)
-			e.Process()
-			decodeExtrinsic := e.Value.(*scalecodec.GenericExtrinsic)
-			var ce ChainExtrinsic
-			eb, _ := json.Marshal(decodeExtrinsic)
-			_ = json.Unmarshal(eb, &ce)
-			if e.ExtrinsicHash != "" && e.ContainsTransaction {
-				params := make([]commonTypes.ExtrinsicParam, 0)
-				for _, p := range e.Params {
-					params = append(params, commonTypes.ExtrinsicParam{
-						Name:  p.Name,
-						Type:  p.Type,
-						Value: p.Value,
-					})
-				}
-
-				ext := &Transaction{
-					ExtrinsicHash:  e.ExtrinsicHash,
-					CallModuleName: ce.CallModule,
-					CallName:       ce.CallModuleFunction,
-					Address:        e.Address,
-					Params:         params,
-				}
-				exts = append(exts, ext)
-			}
+		if tx := e.transaction(); tx != nil {
+			exts = append(exts, tx)
 		}
-		return exts, nil
-	default:
-		return nil, errors.New("chainType not supported")
 	}
+	return exts, nil
 }
 
 func (sc *GsrpcClient) GetBlockHash(blockNum uint64) (string, error) {
+	return sc.GetBlockHashContext(context.Background(), blockNum)
+}
+
+func (sc *GsrpcClient) GetBlockHashContext(ctx context.Context, blockNum uint64) (string, error) {
 	v := &model.JsonRpcResult{}
-	if err := sc.sendWsRequest(v, rpc.ChainGetBlockHash(wsId, int(blockNum))); err != nil {
-		return "", fmt.Errorf("websocket get block hash error: %v", err)
+	if err := sc.sendWsRequestContext(ctx, v, rpc.ChainGetBlockHash(wsId, int(blockNum))); err != nil {
+		return "", fmt.Errorf("websocket get block hash error: %w", err)
 	}
 
 	blockHash, err := v.ToString()
@@ -591,70 +548,41 @@ func (sc *GsrpcClient) GetBlockHash(blockNum uint64) (string, error) {
 }
 
 func (sc *GsrpcClient) GetChainEvents(blockHash string) ([]*ChainEvent, error) {
-	v := &model.JsonRpcResult{}
-	if err := sc.sendWsRequest(v, rpc.StateGetStorage(wsId, storageKey, blockHash)); err != nil {
-		return nil, fmt.Errorf("websocket get event raw error: %v", err)
-	}
-	eventRaw, err := v.ToString()
+	return sc.GetChainEventsContext(context.Background(), blockHash)
+}
+
+func (sc *GsrpcClient) GetChainEventsContext(ctx context.Context, blockHash string) ([]*ChainEvent, error) {
+	eventRaw, err := sc.getEventsRaw(ctx, blockHash)
 	if err != nil {
 		return nil, err
 	}
 
-	var events []*ChainEvent
-	switch sc.chainType {
-	case ChainTypeStafi:
-		md, err := sc.getStafiMetaDecoder(blockHash)
-		if err != nil {
-			return nil, err
-		}
-		e := stafi_decoder.EventsDecoder{}
-		option := stafi_decoder.ScaleDecoderOption{Metadata: &md.Metadata}
-		e.Init(stafi_decoder.ScaleBytes{Data: utiles.HexToBytes(eventRaw)}, &option)
-		e.Process()
-		b, err := json.Marshal(e.Value)
-		if err != nil {
-			return nil, err
-		}
-		err = json.Unmarshal(b, &events)
-		if err != nil {
-			return nil, err
-		}
-
-	case ChainTypePolkadot:
-		md, err := sc.getPolkaMetaDecoder(blockHash)
-		if err != nil {
-			return nil, err
-		}
-		option := scaleTypes.ScaleDecoderOption{Metadata: &md.Metadata}
-
-		e := scalecodec.EventsDecoder{}
-		e.Init(scaleBytes.ScaleBytes{Data: utiles.HexToBytes(eventRaw)}, &option)
-
-		e.Process()
-
-		b, err := json.Marshal(e.Value)
-		if err != nil {
-			return nil, err
-		}
-		err = json.Unmarshal(b, &events)
-		if err != nil {
-			return nil, err
-		}
-
-	default:
-		return nil, errors.New("chainType not supported")
+	md, err := sc.getMetaDecoder(ctx, blockHash)
+	if err != nil {
+		return nil, err
 	}
+	return md.DecodeEvents(eventRaw)
+}
 
-	return events, nil
+func (sc *GsrpcClient) getEventsRaw(ctx context.Context, blockHash string) (string, error) {
+	v := &model.JsonRpcResult{}
+	if err := sc.sendWsRequestContext(ctx, v, rpc.StateGetStorage(wsId, storageKey, blockHash)); err != nil {
+		return "", fmt.Errorf("websocket get event raw error: %v", err)
+	}
+	return v.ToString()
 }
 
 func (sc *GsrpcClient) GetEvents(blockNum uint64) ([]*ChainEvent, error) {
-	blockHash, err := sc.GetBlockHash(blockNum)
+	return sc.GetEventsContext(context.Background(), blockNum)
+}
+
+func (sc *GsrpcClient) GetEventsContext(ctx context.Context, blockNum uint64) ([]*ChainEvent, error) {
+	blockHash, err := sc.GetBlockHashContext(ctx, blockNum)
 	if err != nil {
 		return nil, err
 	}
 
-	evts, err := sc.GetChainEvents(blockHash)
+	evts, err := sc.GetChainEventsContext(ctx, blockHash)
 	if err != nil {
 		return nil, err
 	}
@@ -663,114 +591,68 @@ func (sc *GsrpcClient) GetEvents(blockNum uint64) ([]*ChainEvent, error) {
 }
 
 func (sc *GsrpcClient) GetBlockTimestampAndExtrinsics(height uint64) (uint64, map[int]*Transaction, error) {
-	blockHash, err := sc.GetBlockHash(height)
+	return sc.GetBlockTimestampAndExtrinsicsContext(context.Background(), height)
+}
+
+func (sc *GsrpcClient) GetBlockTimestampAndExtrinsicsContext(ctx context.Context, height uint64) (uint64, map[int]*Transaction, error) {
+	blockHash, err := sc.GetBlockHashContext(ctx, height)
 	if err != nil {
 		return 0, nil, err
 	}
 
-	blk, err := sc.GetBlock(blockHash)
+	return sc.getBlockTimestampAndExtrinsics(ctx, blockHash)
+}
+
+func (sc *GsrpcClient) getBlockTimestampAndExtrinsics(ctx context.Context, blockHash string) (uint64, map[int]*Transaction, error) {
+	blk, err := sc.GetBlockContext(ctx, blockHash)
 	if err != nil {
 		return 0, nil, err
 	}
-	if len(blk.Extrinsics) == 0 {
-		return 0, nil, fmt.Errorf("no set time extrinsic in block: %d", height)
+	md, err := sc.getMetaDecoder(ctx, blockHash)
+	if err != nil {
+		return 0, nil, err
 	}
+	return decodeBlockTimestampAndExtrinsics(md, blockHash, blk)
+}
 
-	exts := make(map[int]*Transaction)
-	switch sc.chainType {
-	case ChainTypeStafi:
-		e := new(stafi_decoder.ExtrinsicDecoder)
-		md, err := sc.getStafiMetaDecoder(blockHash)
-		if err != nil {
-			return 0, nil, err
-		}
-
-		option := stafi_decoder.ScaleDecoderOption{Metadata: &md.Metadata, Spec: md.Spec}
+func decodeBlockTimestampAndExtrinsics(md metaDecoder, blockHash string, blk *model.Block) (uint64, map[int]*Transaction, error) {
+	if len(blk.Extrinsics) == 0 {
+		return 0, nil, fmt.Errorf("no set time extrinsic in block: %s", blockHash)
+	}
 
-		raw := blk.Extrinsics[0]
-		e.Init(stafi_decoder.ScaleBytes{Data: utiles.HexToBytes(raw)}, &option)
-		e.Process()
-		if len(e.Params) == 0 {
-			return 0, nil, fmt.Errorf("no params")
-		}
-		stamp, ok := e.Params[0].Value.(int)
-		if !ok {
-			return 0, nil, fmt.Errorf("interface not ok: %s", e.Params[0].Value)
-		}
+	first, err := md.DecodeExtrinsic(blk.Extrinsics[0])
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(first.Params) == 0 {
+		return 0, nil, fmt.Errorf("no params")
+	}
+	stamp, ok := first.Params[0].Value.(int)
+	if !ok {
+		return 0, nil, fmt.Errorf("interface not ok: %s", first.Params[0].Value)
+	}
 
-		for index, raw := range blk.Extrinsics {
-			e.Init(stafi_decoder.ScaleBytes{Data: utiles.HexToBytes(raw)}, &option)
-			e.Process()
-			if e.ExtrinsicHash != "" && e.ContainsTransaction {
-				ext := &Transaction{
-					ExtrinsicHash:  utiles.AddHex(e.ExtrinsicHash),
-					CallModuleName: e.CallModule.Name,
-					CallName:       e.Call.Name,
-					Address:        e.Address,
-					Params:         e.Params,
-				}
-				exts[index] = ext
-			}
-		}
-		return uint64(stamp), exts, nil
-	case ChainTypePolkadot:
-		e := new(scalecodec.ExtrinsicDecoder)
-		md, err := sc.getPolkaMetaDecoder(blockHash)
+	exts := make(map[int]*Transaction)
+	for index, raw := range blk.Extrinsics {
+		e, err := md.DecodeExtrinsic(raw)
 		if err != nil {
 			return 0, nil, err
 		}
-		option := scaleTypes.ScaleDecoderOption{Metadata: &md.Metadata, Spec: md.Spec}
-
-		raw := blk.Extrinsics[0]
-		e.Init(scaleBytes.ScaleBytes{Data: utiles.HexToBytes(raw)}, &option)
-		e.Process()
-
-		if len(e.Params) == 0 {
-			return 0, nil, fmt.Errorf("no params")
-		}
-		stamp, ok := e.Params[0].Value.(int)
-		if !ok {
-			return 0, nil, fmt.Errorf("interface not ok: %s", e.Params[0].Value)
-		}
-
-		for index, raw := range blk.Extrinsics {
-			e.Init(scaleBytes.ScaleBytes{Data: utiles.HexToBytes(raw)}, &option)
-			e.Process()
-
-			call, exist := e.Metadata.CallIndex[e.CallIndex]
-			if !exist {
-				return 0, nil, fmt.Errorf("callIndex: %s not exist metaData", e.CallIndex)
-			}
-
-			if e.ExtrinsicHash != "" && e.ContainsTransaction {
-				params := make([]commonTypes.ExtrinsicParam, 0)
-				for _, p := range e.Params {
-					params = append(params, commonTypes.ExtrinsicParam{
-						Name:  p.Name,
-						Type:  p.Type,
-						Value: p.Value,
-					})
-				}
-				ext := &Transaction{
-					ExtrinsicHash:  utiles.AddHex(e.ExtrinsicHash),
-					CallModuleName: call.Module.Name,
-					CallName:       call.Call.Name,
-					Address:        e.Address,
-					Params:         params,
-				}
-				exts[index] = ext
-			}
+		if tx := e.transaction(); tx != nil {
+			tx.ExtrinsicHash = utiles.AddHex(tx.ExtrinsicHash)
+			exts[index] = tx
 		}
-
-		return uint64(stamp), exts, nil
-	default:
-		return 0, nil, errors.New("chainType not supported")
 	}
+	return uint64(stamp), exts, nil
 }
 
 func (sc *GsrpcClient) GetPaymentQueryInfo(encodedExtrinsic string) (paymentInfo *model.PaymentQueryInfo, err error) {
+	return sc.GetPaymentQueryInfoContext(context.Background(), encodedExtrinsic)
+}
+
+func (sc *GsrpcClient) GetPaymentQueryInfoContext(ctx context.Context, encodedExtrinsic string) (paymentInfo *model.PaymentQueryInfo, err error) {
 	v := &model.JsonRpcResult{}
-	if err = sc.sendWsRequest(v, rpc.SystemPaymentQueryInfo(wsId, encodedExtrinsic)); err != nil {
+	if err = sc.sendWsRequestContext(ctx, v, rpc.SystemPaymentQueryInfo(wsId, encodedExtrinsic)); err != nil {
 		return
 	}
 
@@ -781,9 +663,13 @@ func (sc *GsrpcClient) GetPaymentQueryInfo(encodedExtrinsic string) (paymentInfo
 	return
 }
 
-func (c *GsrpcClient) CurrentEra() (uint32, error) {
+func (c *GsrpcClient) CurrentEra(blockHash ...types.Hash) (uint32, error) {
+	return c.CurrentEraContext(context.Background(), blockHash...)
+}
+
+func (c *GsrpcClient) CurrentEraContext(ctx context.Context, blockHash ...types.Hash) (uint32, error) {
 	var index uint32
-	exist, err := c.QueryStorage(config.StakingModuleId, config.StorageActiveEra, nil, nil, &index)
+	exist, err := c.QueryStorageContext(ctx, config.StakingModuleId, config.StorageActiveEra, nil, nil, &index, blockHash...)
 	if err != nil {
 		return 0, err
 	}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -9,10 +10,26 @@ import (
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
+// CallBuilder is a typed call, like the report calls of RTokenLedger
+type CallBuilder interface {
+	// Method is the call as module.function
+	Method() string
+	Args() []interface{}
+}
+
+// NewCallExtrinsic builds the unsigned extrinsic of call, to be signed and submitted by tx
+func NewCallExtrinsic(tx TxSubmitter, call CallBuilder) (interface{}, error) {
+	return tx.NewUnsignedExtrinsic(call.Method(), call.Args()...)
+}
+
 func (sc *GsrpcClient) NewUnsignedExtrinsic(callMethod string, args ...interface{}) (interface{}, error) {
+	return sc.NewUnsignedExtrinsicContext(context.Background(), callMethod, args...)
+}
+
+func (sc *GsrpcClient) NewUnsignedExtrinsicContext(ctx context.Context, callMethod string, args ...interface{}) (interface{}, error) {
 	sc.log.Debug("Submitting substrate call...", "callMethod", callMethod, "addressType", sc.addressType, "sender", sc.key.Address)
 
-	ci, err := sc.FindCallIndex(callMethod)
+	ci, err := sc.FindCallIndexContext(ctx, callMethod)
 	if err != nil {
 		return nil, err
 	}
@@ -32,58 +49,90 @@ func (sc *GsrpcClient) NewUnsignedExtrinsic(callMethod string, args ...interface
 	}
 }
 
+// EstimateWeight returns the weight of ext from its payment info, ext needs not be signed
+func (sc *GsrpcClient) EstimateWeight(ext interface{}) (uint64, error) {
+	return sc.EstimateWeightContext(context.Background(), ext)
+}
+
+func (sc *GsrpcClient) EstimateWeightContext(ctx context.Context, ext interface{}) (uint64, error) {
+	hex, err := types.EncodeToHexString(ext)
+	if err != nil {
+		return 0, err
+	}
+	info, err := sc.GetPaymentQueryInfoContext(ctx, hex)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(info.Weight), nil
+}
+
 func (sc *GsrpcClient) SignAndSubmitTx(ext interface{}) error {
-	err := sc.signExtrinsic(ext)
+	return sc.SignAndSubmitTxContext(context.Background(), ext)
+}
+
+// SignAndSubmitTxContext is SignAndSubmitTx with a context, canceling it stops watching the submitted extrinsic
+func (sc *GsrpcClient) SignAndSubmitTxContext(ctx context.Context, ext interface{}) error {
+	_, err := sc.SignAndSubmitTxInBlock(ctx, ext)
+	return err
+}
+
+// SignAndSubmitTxInBlock is SignAndSubmitTxContext returning the hash of the block that included ext
+func (sc *GsrpcClient) SignAndSubmitTxInBlock(ctx context.Context, ext interface{}) (types.Hash, error) {
+	err := sc.signExtrinsic(ctx, ext)
 	if err != nil {
-		return err
+		return types.Hash{}, err
 	}
 	sc.log.Trace("signExtrinsic ok")
 
 	api, err := sc.FlashApi()
 	if err != nil {
-		return err
+		return types.Hash{}, err
 	}
 	sc.log.Trace("flashApi ok")
 	// Do the transfer and track the actual status
-	sub, err := api.Author.SubmitAndWatch(ext)
+	subCtx, cancel := context.WithTimeout(ctx, sc.cfg.SubscribeTimeout)
+	defer cancel()
+	sub, err := api.Author.SubmitAndWatchContext(subCtx, ext)
 	if err != nil {
-		return err
+		return types.Hash{}, err
 	}
 	sc.log.Trace("Extrinsic submission succeeded")
 	defer sub.Unsubscribe()
 
-	return sc.watchSubmission(sub)
+	return sc.watchSubmission(ctx, sub)
 }
 
-func (sc *GsrpcClient) watchSubmission(sub *author.ExtrinsicStatusSubscription) error {
+func (sc *GsrpcClient) watchSubmission(ctx context.Context, sub *author.ExtrinsicStatusSubscription) (types.Hash, error) {
 	for {
 		select {
 		case status := <-sub.Chan():
 			switch {
 			case status.IsInBlock:
 				sc.log.Info("Extrinsic included in block", "block", status.AsInBlock.Hex())
-				return nil
+				return status.AsInBlock, nil
 			case status.IsRetracted:
-				return fmt.Errorf("extrinsic retracted: %s", status.AsRetracted.Hex())
+				return types.Hash{}, fmt.Errorf("extrinsic retracted: %s", status.AsRetracted.Hex())
 			case status.IsDropped:
-				return fmt.Errorf("extrinsic dropped from network")
+				return types.Hash{}, fmt.Errorf("extrinsic dropped from network")
 			case status.IsInvalid:
-				return fmt.Errorf("extrinsic invalid")
+				return types.Hash{}, fmt.Errorf("extrinsic invalid")
 			}
 		case err := <-sub.Err():
 			sc.log.Trace("Extrinsic subscription error", "err", err)
-			return err
+			return types.Hash{}, err
+		case <-ctx.Done():
+			return types.Hash{}, ctx.Err()
 		}
 	}
 }
 
-func (sc *GsrpcClient) signExtrinsic(xt interface{}) error {
-	rv, err := sc.GetLatestRuntimeVersion()
+func (sc *GsrpcClient) signExtrinsic(ctx context.Context, xt interface{}) error {
+	rv, err := sc.GetLatestRuntimeVersionContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	nonce, err := sc.GetLatestNonce()
+	nonce, err := sc.GetLatestNonceContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -118,6 +167,10 @@ func (sc *GsrpcClient) signExtrinsic(xt interface{}) error {
 }
 
 func (sc *GsrpcClient) SingleTransferTo(accountId []byte, value types.UCompact) error {
+	return sc.SingleTransferToContext(context.Background(), accountId, value)
+}
+
+func (sc *GsrpcClient) SingleTransferToContext(ctx context.Context, accountId []byte, value types.UCompact) error {
 	var addr interface{}
 	switch sc.addressType {
 	case AddressTypeAccountId:
@@ -127,9 +180,9 @@ func (sc *GsrpcClient) SingleTransferTo(accountId []byte, value types.UCompact)
 	default:
 		return fmt.Errorf("unsupported address type: %s", sc.addressType)
 	}
-	ext, err := sc.NewUnsignedExtrinsic(config.MethodTransferKeepAlive, addr, value)
+	ext, err := sc.NewUnsignedExtrinsicContext(ctx, config.MethodTransferKeepAlive, addr, value)
 	if err != nil {
 		return err
 	}
-	return sc.SignAndSubmitTx(ext)
+	return sc.SignAndSubmitTxContext(ctx, ext)
 }
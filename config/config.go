@@ -19,6 +19,8 @@ package config
 import (
 	"os"
 	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/instrument"
 )
 
 type Config struct {
@@ -27,6 +29,13 @@ type Config struct {
 	// Timeouts
 	DialTimeout      time.Duration
 	SubscribeTimeout time.Duration
+
+	// Back-off of resilient subscriptions between resubscribe attempts
+	ResubscribeMinInterval time.Duration
+	ResubscribeMaxInterval time.Duration
+
+	// Instrumentation receives the metrics and spans of the connections, none by default
+	Instrumentation instrument.Hooks
 }
 
 var (
@@ -34,6 +43,12 @@ var (
 	SubscribeTimeout = 10 * time.Second
 )
 
+// Default back-off of resilient subscriptions, set per client with Config
+const (
+	defaultResubscribeMinInterval = time.Second
+	defaultResubscribeMaxInterval = 30 * time.Second
+)
+
 // DefaultConfig returns the default config. Default values can be overwritten with env variables, most importantly
 // RPC_URL for a custom RPC endpoint.
 func Default() Config {
@@ -41,7 +56,27 @@ func Default() Config {
 		RPCURL:           extractDefaultRPCURL(),
 		DialTimeout:      DialTimeout,
 		SubscribeTimeout: SubscribeTimeout,
+
+		ResubscribeMinInterval: defaultResubscribeMinInterval,
+		ResubscribeMaxInterval: defaultResubscribeMaxInterval,
+	}
+}
+
+// WithDefaults returns c with each zero timeout and interval set to the one of def, the other fields are kept
+func (c Config) WithDefaults(def Config) Config {
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = def.DialTimeout
+	}
+	if c.SubscribeTimeout <= 0 {
+		c.SubscribeTimeout = def.SubscribeTimeout
+	}
+	if c.ResubscribeMinInterval <= 0 {
+		c.ResubscribeMinInterval = def.ResubscribeMinInterval
+	}
+	if c.ResubscribeMaxInterval <= 0 {
+		c.ResubscribeMaxInterval = def.ResubscribeMaxInterval
 	}
+	return c
 }
 
 // ExtractDefaultRPCURL reads the env variable RPC_URL and returns it. If that variable is unset or empty,
@@ -17,6 +17,12 @@ const (
 	StorageREthActs            = "REthActs"
 	StorageREthActCurrentCycle = "REthActCurrentCycle"
 	StorageMintTxHashExist     = "MintTxHashExist"
+	StorageUserMintsCount      = "UserMintsCount"
+	StorageREthUserMintsCount  = "REthUserMintsCount"
+	StorageClaimInfos          = "ClaimInfos"
+	StorageREthClaimInfos      = "REthClaimInfos"
+	MethodClaimRTokenReward    = "RClaim.claim_rtoken_reward"
+	MethodClaimREthReward      = "RClaim.claim_reth_reward"
 
 	StorageBondRecords       = "BondRecords"
 	StorageBondStates        = "BondStates"
@@ -61,6 +67,8 @@ const (
 	StorageBondedPools                        = "BondedPools"
 	StorageSnapshots                          = "Snapshots"
 	StoragePoolUnbonds                        = "PoolUnbonds"
+	StorageAccountUnbonds                     = "AccountUnbonds"
+	StorageChainBondingDuration               = "ChainBondingDuration"
 	SignaturesEnoughEventId                   = "SignaturesEnough"
 	StorageSignatures                         = "Signatures"
 	SubmitSignatures                          = "RTokenSeries.submit_signatures"
@@ -87,4 +95,10 @@ const (
 	RDexSwapEventId         = "Swap"
 	RDexAddLiquidityEventId = "AddLiquidity"
 	RDexRmLiquidityEventId  = "RemoveLiquidity"
+
+	StorageSwapPools              = "SwapPools"
+	StorageSwapLiquidityProviders = "SwapLiquidityProviders"
+	MethodRDexSwap                = "RDexSwap.swap"
+	MethodRDexAddLiquidity        = "RDexSwap.add_liquidity"
+	MethodRDexRemoveLiquidity     = "RDexSwap.remove_liquidity"
 )
@@ -27,11 +27,51 @@ const (
 	MultisigExecutedEventId = "MultisigExecuted"
 	StorageMultisigs        = "Multisigs"
 	MethodAsMulti           = "Multisig.as_multi"
+	MethodApproveAsMulti    = "Multisig.approve_as_multi"
 
-	SystemModuleId = "System"
-	StorageAccount = "Account"
+	ProxyModuleId        = "Proxy"
+	StorageProxies       = "Proxies"
+	StorageAnnouncements = "Announcements"
+	MethodProxy          = "Proxy.proxy"
+	MethodProxyAnnounced = "Proxy.proxy_announced"
+	MethodAddProxy       = "Proxy.add_proxy"
+	MethodRemoveProxy    = "Proxy.remove_proxy"
+	MethodCreatePure     = "Proxy.create_pure"
 
-	MethodBatch = "Utility.batch"
+	SystemModuleId          = "System"
+	ConstBlockLength        = "BlockLength"
+	ConstBlockWeights       = "BlockWeights"
+	StorageAccount          = "Account"
+	ExtrinsicSuccessEventId = "ExtrinsicSuccess"
+	ExtrinsicFailedEventId  = "ExtrinsicFailed"
+
+	TransactionPaymentModuleId = "TransactionPayment"
+	TransactionFeePaidEventId  = "TransactionFeePaid"
+	BalancesDepositEventId     = "Deposit"
+	TreasuryModuleId           = "Treasury"
+	TreasuryDepositEventId     = "Deposit"
+
+	GrandpaModuleId     = "Grandpa"
+	StorageCurrentSetId = "CurrentSetId"
+
+	SessionModuleId   = "Session"
+	StorageValidators = "Validators"
+
+	AuraModuleId       = "Aura"
+	StorageAuthorities = "Authorities"
+
+	TimestampModuleId = "Timestamp"
+	StorageNow        = "Now"
+
+	UtilityModuleId                 = "Utility"
+	MethodBatch                     = "Utility.batch"
+	MethodBatchAll                  = "Utility.batch_all"
+	MethodForceBatch                = "Utility.force_batch"
+	BatchInterruptedEventId         = "BatchInterrupted"
+	BatchCompletedEventId           = "BatchCompleted"
+	BatchCompletedWithErrorsEventId = "BatchCompletedWithErrors"
+	ItemCompletedEventId            = "ItemCompleted"
+	ItemFailedEventId               = "ItemFailed"
 
 	ParamDest     = "dest"
 	ParamDestType = "Address"
@@ -28,12 +28,17 @@ import (
 type Client interface {
 	Call(result interface{}, method string, args ...interface{}) error
 
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+
 	Subscribe(ctx context.Context, namespace, subscribeMethodSuffix, unsubscribeMethodSuffix,
 		notificationMethodSuffix string, channel interface{}, args ...interface{}) (
 		*gethrpc.ClientSubscription, error)
 
 	URL() string
 
+	// Config returns the configuration the client was connected with
+	Config() config.Config
+
 	Close()
 }
 
@@ -41,6 +46,7 @@ type client struct {
 	gethrpc.Client
 
 	url string
+	cfg config.Config
 }
 
 // URL returns the URL the client connects to
@@ -48,28 +54,53 @@ func (c client) URL() string {
 	return c.url
 }
 
+// Config returns the configuration the client was connected with
+func (c client) Config() config.Config {
+	return c.cfg
+}
+
 func (c client) Close() {
 	c.Client.Close()
 }
 
-// Connect connects to the provided url
+// Connect connects to the provided url with the default configuration
 func Connect(url string) (Client, error) {
-	log.Printf("Connecting to %v...", url)
+	return ConnectWithConfig(url, config.Default())
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), config.Default().DialTimeout)
+// ConnectWithConfig connects to the provided url, dialing is bounded by cfg.DialTimeout. Zero timeouts and intervals
+// of cfg default to those of config.Default()
+func ConnectWithConfig(url string, cfg config.Config) (Client, error) {
+	cfg = cfg.WithDefaults(config.Default())
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DialTimeout)
 	defer cancel()
 
+	return ConnectContext(ctx, url, cfg)
+}
+
+// ConnectContext connects to the provided url, dialing is aborted when ctx is done
+func ConnectContext(ctx context.Context, url string, cfg config.Config) (Client, error) {
+	cfg = cfg.WithDefaults(config.Default())
+	log.Printf("Connecting to %v...", url)
+
 	c, err := gethrpc.DialContext(ctx, url)
+	cfg.Instrumentation.Reconnect(url, err)
 	if err != nil {
 		return nil, err
 	}
-	cc := client{*c, url}
+	c.SetInstrumentation(url, cfg.Instrumentation)
+	cc := client{*c, url, cfg}
 	return &cc, nil
 }
 
 func CallWithBlockHash(c Client, target interface{}, method string, blockHash *types.Hash, args ...interface{}) error {
+	return CallWithBlockHashContext(context.Background(), c, target, method, blockHash, args...)
+}
+
+func CallWithBlockHashContext(ctx context.Context, c Client, target interface{}, method string, blockHash *types.Hash,
+	args ...interface{}) error {
 	if blockHash == nil {
-		err := c.Call(target, method, args...)
+		err := c.CallContext(ctx, target, method, args...)
 		if err != nil {
 			return err
 		}
@@ -80,7 +111,7 @@ func CallWithBlockHash(c Client, target interface{}, method string, blockHash *t
 		return err
 	}
 	hargs := append(args, hexHash)
-	err = c.Call(target, method, hargs...)
+	err = c.CallContext(ctx, target, method, hargs...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,435 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	gethrpc "github.com/stafiprotocol/go-substrate-rpc-client/pkg/gethrpc"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+var ErrNoHealthyEndpoint = errors.New("no healthy endpoint")
+
+// submitMethods are sent to one endpoint only, a transport error does not tell whether the node got the extrinsic
+// and sending it again elsewhere could submit it twice
+var submitMethods = map[string]bool{
+	"author_submitExtrinsic":         true,
+	"author_submitAndWatchExtrinsic": true,
+}
+
+const (
+	defaultCheckInterval = 10 * time.Second
+	defaultMaxBlockLag   = 5
+)
+
+// EndpointHealth is the result of the last health check of an endpoint
+type EndpointHealth struct {
+	URL       string
+	Healthy   bool
+	Peers     uint64
+	IsSyncing bool
+	// BestNumber is the best block of the endpoint, Lag is how far it is behind the highest best block of all endpoints
+	BestNumber uint64
+	Lag        uint64
+	Latency    time.Duration
+	Err        error
+	CheckedAt  time.Time
+}
+
+type MultiConfig struct {
+	// CheckInterval is the wait between health checks, default to 10 seconds
+	CheckInterval time.Duration
+	// MaxBlockLag is the number of blocks an endpoint may be behind the others and still be healthy, default to 5
+	MaxBlockLag uint64
+	// MaxLatency marks slower endpoints unhealthy, 0 means no limit
+	MaxLatency time.Duration
+	// OnEndpointChange is called when the active endpoint, which serves the subscriptions, changes
+	OnEndpointChange func(oldURL, newURL string)
+	// Client is used to connect the endpoints, its DialTimeout also bounds a health check. Its zero timeouts and
+	// intervals default to those of config.Default()
+	Client config.Config
+}
+
+// MultiClient is a Client over several endpoints. Calls go round robin to the healthy endpoints and fail over to the
+// others on transport errors, submissions are never sent again. Subscriptions stay on the active endpoint until it
+// becomes unhealthy
+type MultiClient struct {
+	cfg       MultiConfig
+	endpoints []*endpoint
+
+	mu     sync.RWMutex
+	active int
+	next   int
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+type endpoint struct {
+	url string
+	cfg config.Config
+
+	mu     sync.RWMutex
+	client Client
+	health EndpointHealth
+}
+
+// ConnectMulti connects to all urls and starts the health checks, it fails only if none of the urls is reachable
+func ConnectMulti(urls []string, cfg MultiConfig) (*MultiClient, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no endpoint")
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = defaultCheckInterval
+	}
+	if cfg.MaxBlockLag == 0 {
+		cfg.MaxBlockLag = defaultMaxBlockLag
+	}
+	cfg.Client = cfg.Client.WithDefaults(config.Default())
+
+	m := &MultiClient{
+		cfg:       cfg,
+		endpoints: make([]*endpoint, len(urls)),
+		stop:      make(chan struct{}),
+	}
+	for i, url := range urls {
+		m.endpoints[i] = &endpoint{url: url, cfg: cfg.Client, health: EndpointHealth{URL: url}}
+	}
+
+	m.checkAll()
+	active := m.pickActive(-1)
+	if active < 0 {
+		m.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNoHealthyEndpoint, m.endpoints[0].getHealth().Err)
+	}
+	m.active = active
+
+	go m.checkLoop()
+	return m, nil
+}
+
+// URL returns the url of the active endpoint
+func (m *MultiClient) URL() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.endpoints[m.active].url
+}
+
+// Health returns the last health check of every endpoint
+func (m *MultiClient) Health() []EndpointHealth {
+	hs := make([]EndpointHealth, len(m.endpoints))
+	for i, e := range m.endpoints {
+		hs[i] = e.getHealth()
+	}
+	return hs
+}
+
+// Config returns the configuration the endpoints are connected with
+func (m *MultiClient) Config() config.Config {
+	return m.cfg.Client
+}
+
+func (m *MultiClient) Call(result interface{}, method string, args ...interface{}) error {
+	return m.CallContext(context.Background(), result, method, args...)
+}
+
+func (m *MultiClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	var lastErr error = ErrNoHealthyEndpoint
+	for _, i := range m.callOrder() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		e := m.endpoints[i]
+		c, err := e.getClient()
+		if err != nil {
+			lastErr = err
+			if submitMethods[method] {
+				return err
+			}
+			continue
+		}
+
+		// the result is decoded after the call, a result that does not decode is not a transport error
+		var raw json.RawMessage
+		err = c.CallContext(ctx, &raw, method, args...)
+		if err == nil {
+			if result == nil {
+				return nil
+			}
+			return json.Unmarshal(raw, result)
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		// an error returned by the node is the answer, only transport errors fail over
+		if _, ok := err.(gethrpc.Error); ok || err == gethrpc.ErrNoResult {
+			return err
+		}
+		e.markUnhealthy(err)
+		e.dropClient(c)
+		m.failover()
+		if submitMethods[method] {
+			return err
+		}
+		log.Printf("Call %s on %s failed, trying next endpoint: %s", method, e.url, err)
+		lastErr = err
+	}
+	return lastErr
+}
+
+// Subscribe subscribes on the active endpoint. When the active endpoint changes existing subscriptions are not moved,
+// OnEndpointChange tells the caller to subscribe again
+func (m *MultiClient) Subscribe(ctx context.Context, namespace, subscribeMethodSuffix, unsubscribeMethodSuffix,
+	notificationMethodSuffix string, channel interface{}, args ...interface{}) (*gethrpc.ClientSubscription, error) {
+	m.mu.RLock()
+	e := m.endpoints[m.active]
+	m.mu.RUnlock()
+
+	c, err := e.getClient()
+	if err != nil {
+		m.failover()
+		return nil, err
+	}
+	sub, err := c.Subscribe(ctx, namespace, subscribeMethodSuffix, unsubscribeMethodSuffix, notificationMethodSuffix,
+		channel, args...)
+	if err != nil {
+		if _, ok := err.(gethrpc.Error); !ok {
+			e.markUnhealthy(err)
+			e.dropClient(c)
+			m.failover()
+		}
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (m *MultiClient) Close() {
+	m.closeOnce.Do(func() { close(m.stop) })
+	for _, e := range m.endpoints {
+		e.close()
+	}
+}
+
+// callOrder returns the healthy endpoints starting with the next one in round robin order, followed by the others
+func (m *MultiClient) callOrder() []int {
+	m.mu.Lock()
+	start := m.next
+	m.next = (m.next + 1) % len(m.endpoints)
+	m.mu.Unlock()
+
+	healthy := make([]int, 0, len(m.endpoints))
+	others := make([]int, 0)
+	for j := 0; j < len(m.endpoints); j++ {
+		i := (start + j) % len(m.endpoints)
+		if m.endpoints[i].getHealth().Healthy {
+			healthy = append(healthy, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+	return append(healthy, others...)
+}
+
+func (m *MultiClient) checkLoop() {
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.checkAll()
+			m.failover()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// failover switches the active endpoint if it is unhealthy and another one is healthy
+func (m *MultiClient) failover() {
+	m.mu.Lock()
+	old := m.active
+	if m.endpoints[old].getHealth().Healthy {
+		m.mu.Unlock()
+		return
+	}
+	active := m.pickActive(old)
+	if active < 0 {
+		m.mu.Unlock()
+		return
+	}
+	m.active = active
+	m.mu.Unlock()
+
+	log.Printf("Active endpoint changed from %s to %s", m.endpoints[old].url, m.endpoints[active].url)
+	if m.cfg.OnEndpointChange != nil {
+		m.cfg.OnEndpointChange(m.endpoints[old].url, m.endpoints[active].url)
+	}
+}
+
+// pickActive returns the healthy endpoint with the highest best block and the lowest latency, -1 if none is healthy
+func (m *MultiClient) pickActive(exclude int) int {
+	candidates := make([]int, 0, len(m.endpoints))
+	for i, e := range m.endpoints {
+		if i != exclude && e.getHealth().Healthy {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return -1
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		ha, hb := m.endpoints[candidates[a]].getHealth(), m.endpoints[candidates[b]].getHealth()
+		if ha.BestNumber != hb.BestNumber {
+			return ha.BestNumber > hb.BestNumber
+		}
+		return ha.Latency < hb.Latency
+	})
+	return candidates[0]
+}
+
+// checkAll checks all endpoints concurrently, the block lag is computed once all results are in
+func (m *MultiClient) checkAll() {
+	hs := make([]EndpointHealth, len(m.endpoints))
+	wg := sync.WaitGroup{}
+	for i, e := range m.endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			hs[i] = e.check()
+		}(i, e)
+	}
+	wg.Wait()
+
+	var best uint64
+	for _, h := range hs {
+		if h.Err == nil && h.BestNumber > best {
+			best = h.BestNumber
+		}
+	}
+	for i, h := range hs {
+		h.Lag = best - h.BestNumber
+		h.Healthy = h.Err == nil && !h.IsSyncing && h.Lag <= m.cfg.MaxBlockLag &&
+			(m.cfg.MaxLatency == 0 || h.Latency <= m.cfg.MaxLatency)
+		m.endpoints[i].setHealth(h)
+	}
+}
+
+func (e *endpoint) check() EndpointHealth {
+	h := EndpointHealth{URL: e.url, CheckedAt: time.Now()}
+	c, err := e.getClient()
+	if err != nil {
+		h.Err = err
+		return h
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.cfg.DialTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var health types.Health
+	if err := c.CallContext(ctx, &health, "system_health"); err != nil {
+		h.Err = err
+		e.dropClient(c)
+		return h
+	}
+	h.Latency = time.Since(start)
+	h.Peers = uint64(health.Peers)
+	h.IsSyncing = health.IsSyncing
+	if health.ShouldHavePeers && health.Peers == 0 {
+		h.Err = errors.New("no peers")
+		return h
+	}
+
+	var header types.Header
+	if err := c.CallContext(ctx, &header, "chain_getHeader"); err != nil {
+		h.Err = err
+		e.dropClient(c)
+		return h
+	}
+	h.BestNumber = uint64(header.Number)
+	return h
+}
+
+// getClient returns the connection of the endpoint and dials it if there is none
+func (e *endpoint) getClient() (Client, error) {
+	e.mu.RLock()
+	c := e.client
+	e.mu.RUnlock()
+	if c != nil {
+		return c, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client != nil {
+		return e.client, nil
+	}
+	c, err := ConnectWithConfig(e.url, e.cfg)
+	if err != nil {
+		return nil, err
+	}
+	e.client = c
+	return c, nil
+}
+
+// dropClient closes a broken connection, the next use dials again
+func (e *endpoint) dropClient(c Client) {
+	e.mu.Lock()
+	if e.client == c {
+		e.client = nil
+	}
+	e.mu.Unlock()
+	c.Close()
+}
+
+func (e *endpoint) close() {
+	e.mu.Lock()
+	c := e.client
+	e.client = nil
+	e.mu.Unlock()
+	if c != nil {
+		c.Close()
+	}
+}
+
+func (e *endpoint) getHealth() EndpointHealth {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.health
+}
+
+func (e *endpoint) setHealth(h EndpointHealth) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.health = h
+}
+
+func (e *endpoint) markUnhealthy(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.health.Healthy = false
+	e.health.Err = err
+}
@@ -0,0 +1,206 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/instrument"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockNode struct {
+	mu     sync.Mutex
+	number types.BlockNumber
+	name   string
+}
+
+func (n *mockNode) Health() types.Health {
+	return types.Health{Peers: 3, ShouldHavePeers: true}
+}
+
+func (n *mockNode) GetHeader(hash *string) types.Header {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return types.Header{Number: n.number}
+}
+
+func (n *mockNode) Name() string {
+	return n.name
+}
+
+func (n *mockNode) setNumber(number types.BlockNumber) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.number = number
+}
+
+func startMockNode(t *testing.T, name string, number types.BlockNumber) (*rpcmocksrv.Server, *mockNode) {
+	s := rpcmocksrv.New()
+	n := &mockNode{number: number, name: name}
+	assert.NoError(t, s.RegisterName("system", n))
+	assert.NoError(t, s.RegisterName("chain", n))
+	return s, n
+}
+
+func TestMultiClient_Failover(t *testing.T) {
+	s1, n1 := startMockNode(t, "node1", 100)
+	s2, n2 := startMockNode(t, "node2", 90)
+
+	changed := make(chan string, 1)
+	m, err := client.ConnectMulti([]string{s1.URL, s2.URL}, client.MultiConfig{
+		CheckInterval:    50 * time.Millisecond,
+		OnEndpointChange: func(oldURL, newURL string) { changed <- newURL },
+	})
+	assert.NoError(t, err)
+	defer m.Close()
+
+	// node2 lags behind, node1 is active and serves all calls
+	assert.Equal(t, s1.URL, m.URL())
+	for i := 0; i < 4; i++ {
+		var name string
+		assert.NoError(t, m.Call(&name, "system_name"))
+		assert.Equal(t, "node1", name)
+	}
+
+	// node1 stalls while node2 catches up
+	n1.setNumber(100)
+	n2.setNumber(110)
+
+	select {
+	case url := <-changed:
+		assert.Equal(t, s2.URL, url)
+	case <-time.After(2 * time.Second):
+		t.Fatal("active endpoint not changed")
+	}
+	assert.Equal(t, s2.URL, m.URL())
+
+	var name string
+	assert.NoError(t, m.Call(&name, "system_name"))
+	assert.Equal(t, "node2", name)
+}
+
+type mockAuthor struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (a *mockAuthor) SubmitExtrinsic(xt string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	return "0x01"
+}
+
+func (a *mockAuthor) count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.calls
+}
+
+func TestMultiClient_NoFailoverOnDecodeError(t *testing.T) {
+	s1, _ := startMockNode(t, "node1", 100)
+	s2, _ := startMockNode(t, "node2", 100)
+
+	m, err := client.ConnectMulti([]string{s1.URL, s2.URL}, client.MultiConfig{CheckInterval: time.Hour})
+	assert.NoError(t, err)
+	defer m.Close()
+
+	// the node answered, a result of the wrong type is the caller's error and both endpoints stay healthy
+	var number int
+	err = m.Call(&number, "system_name")
+	assert.IsType(t, &json.UnmarshalTypeError{}, err)
+	for _, h := range m.Health() {
+		assert.True(t, h.Healthy, h.URL)
+	}
+}
+
+func TestMultiClient_SubmitNotResent(t *testing.T) {
+	s1, _ := startMockNode(t, "node1", 100)
+	s2, _ := startMockNode(t, "node2", 100)
+	a1, a2 := &mockAuthor{}, &mockAuthor{}
+	assert.NoError(t, s1.RegisterName("author", a1))
+	assert.NoError(t, s2.RegisterName("author", a2))
+
+	m, err := client.ConnectMulti([]string{s1.URL, s2.URL}, client.MultiConfig{CheckInterval: time.Hour})
+	assert.NoError(t, err)
+	defer m.Close()
+
+	// the first call goes to node1, which is gone: the submission fails instead of going to node2
+	s1.Stop()
+	var hash string
+	assert.Error(t, m.Call(&hash, "author_submitExtrinsic", "0x00"))
+	assert.Equal(t, 0, a1.count())
+	assert.Equal(t, 0, a2.count())
+
+	// node1 is marked unhealthy, the next submission goes to node2
+	assert.NoError(t, m.Call(&hash, "author_submitExtrinsic", "0x00"))
+	assert.Equal(t, 1, a2.count())
+}
+
+func TestConnectMulti_NoEndpoint(t *testing.T) {
+	_, err := client.ConnectMulti([]string{"ws://localhost:1"}, client.MultiConfig{})
+	assert.Error(t, err)
+}
+
+// startFunc is a Tracer of a func type, a Config holding it can not be compared with ==
+type startFunc func(ctx context.Context, name string) context.Context
+
+func (f startFunc) Start(ctx context.Context, name string, attrs ...instrument.Attribute) (context.Context, instrument.Span) {
+	return f(ctx, name), noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...instrument.Attribute) {}
+func (noopSpan) RecordError(err error)                       {}
+func (noopSpan) End()                                        {}
+
+func TestConnectMulti_PartialConfig(t *testing.T) {
+	s, _ := startMockNode(t, "node1", 100)
+
+	m, err := client.ConnectMulti([]string{s.URL}, client.MultiConfig{
+		Client: config.Config{
+			DialTimeout:     3 * time.Second,
+			Instrumentation: instrument.Hooks{Tracer: startFunc(func(ctx context.Context, name string) context.Context { return ctx })},
+		},
+	})
+	assert.NoError(t, err)
+	defer m.Close()
+
+	// the zero fields get their defaults, the set ones are kept
+	cfg := m.Config()
+	assert.Equal(t, 3*time.Second, cfg.DialTimeout)
+	assert.Equal(t, config.Default().SubscribeTimeout, cfg.SubscribeTimeout)
+	assert.Equal(t, config.Default().ResubscribeMinInterval, cfg.ResubscribeMinInterval)
+	assert.Equal(t, config.Default().ResubscribeMaxInterval, cfg.ResubscribeMaxInterval)
+	assert.NotNil(t, cfg.Instrumentation.Tracer)
+
+	c, err := client.ConnectWithConfig(s.URL, config.Config{SubscribeTimeout: time.Minute})
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, config.Default().DialTimeout, c.Config().DialTimeout)
+	assert.Equal(t, time.Minute, c.Config().SubscribeTimeout)
+}
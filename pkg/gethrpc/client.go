@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/instrument"
 )
 
 var (
@@ -83,6 +84,10 @@ type Client struct {
 	// This function, if non-nil, is called when the connection is lost.
 	reconnectFunc reconnectFunc
 
+	// instrumentation, set by SetInstrumentation before the client is used
+	endpoint string
+	hooks    instrument.Hooks
+
 	// writeConn is used for writing to the connection on the caller's goroutine. It should
 	// only be accessed outside of dispatch, with the write lock held. The write lock is
 	// taken by sending on requestOp and released by sending on sendDone.
@@ -233,6 +238,13 @@ func (c *Client) RegisterName(name string, receiver interface{}) error {
 	return c.services.registerName(name, receiver)
 }
 
+// SetInstrumentation makes the client report its calls, subscriptions and reconnects to hooks, labeled with
+// endpoint. It must be called before the client is used.
+func (c *Client) SetInstrumentation(endpoint string, hooks instrument.Hooks) {
+	c.endpoint = endpoint
+	c.hooks = hooks
+}
+
 func (c *Client) nextID() json.RawMessage {
 	id := atomic.AddUint32(&c.idCounter, 1)
 	return strconv.AppendUint(nil, uint64(id), 10)
@@ -275,7 +287,10 @@ func (c *Client) Call(result interface{}, method string, args ...interface{}) er
 //
 // The result must be a pointer so that package json can unmarshal into it. You
 // can also pass nil, in which case the result is ignored.
-func (c *Client) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+func (c *Client) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) (err error) {
+	ctx, end := c.hooks.StartCall(ctx, c.endpoint, method)
+	defer func() { end(err) }()
+
 	msg, err := c.newMessage(method, args...)
 	if err != nil {
 		return err
@@ -325,7 +340,10 @@ func (c *Client) BatchCall(b []BatchElem) error {
 // Error field of the corresponding BatchElem.
 //
 // Note that batch calls may not be executed atomically on the server side.
-func (c *Client) BatchCallContext(ctx context.Context, b []BatchElem) error {
+func (c *Client) BatchCallContext(ctx context.Context, b []BatchElem) (err error) {
+	ctx, end := c.hooks.StartCall(ctx, c.endpoint, "batch")
+	defer func() { end(err) }()
+
 	msgs := make([]*jsonrpcMessage, len(b))
 	op := &requestOp{
 		ids:  make([]json.RawMessage, len(b)),
@@ -340,7 +358,6 @@ func (c *Client) BatchCallContext(ctx context.Context, b []BatchElem) error {
 		op.ids[i] = msg.ID
 	}
 
-	var err error
 	if c.isHTTP {
 		err = c.sendBatchHTTP(ctx, op, msgs)
 	} else {
@@ -429,8 +446,10 @@ func (c *Client) Subscribe(ctx context.Context, namespace, subscribeMethodSuffix
 		return nil, ErrNotificationsUnsupported
 	}
 
+	ctx, end := c.hooks.StartSubscription(ctx, c.endpoint, namespace+"_"+subscribeMethodSuffix)
 	msg, err := c.newMessage(namespace+"_"+subscribeMethodSuffix, args...)
 	if err != nil {
+		end(err)
 		return nil, err
 	}
 	op := &requestOp{
@@ -439,13 +458,16 @@ func (c *Client) Subscribe(ctx context.Context, namespace, subscribeMethodSuffix
 		sub: newClientSubscription(c, namespace, subscribeMethodSuffix, unsubscribeMethodSuffix,
 			notificationMethodSuffix, chanVal),
 	}
+	op.sub.end = end
 
 	// Send the subscription request.
 	// The arrival and validity of the response is signaled on sub.quit.
 	if err := c.send(ctx, op, msg); err != nil {
+		end(err)
 		return nil, err
 	}
 	if _, err := op.wait(ctx, c); err != nil {
+		end(err)
 		return nil, err
 	}
 	return op.sub, nil
@@ -520,6 +542,7 @@ func (c *Client) reconnect(ctx context.Context) error {
 		defer cancel()
 	}
 	newconn, err := c.reconnectFunc(ctx)
+	c.hooks.Reconnect(c.endpoint, err)
 	if err != nil {
 		log.Trace("RPC client reconnect failed", "err", err)
 		return err
@@ -215,6 +215,7 @@ type ClientSubscription struct {
 	quit     chan struct{} // quit is closed when the subscription exits
 	errOnce  sync.Once     // ensures err is closed once
 	err      chan error
+	end      func(error) // instrumentation, called when the subscription exits
 }
 
 func newClientSubscription(c *Client, namespace, subscribeMethodSuffix, unsubscribeMethodSuffix,
@@ -262,6 +263,9 @@ func (sub *ClientSubscription) quitWithError(err error, unsubscribeServer bool)
 		if unsubscribeServer {
 			sub.requestUnsubscribe()
 		}
+		if sub.end != nil {
+			sub.end(err)
+		}
 		if err != nil {
 			if err == ErrClientQuit {
 				err = nil // Adhere to subscription semantics.
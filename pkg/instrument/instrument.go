@@ -0,0 +1,225 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package instrument provides the metrics and tracing hooks of the RPC transports. Hooks is filled with a Metrics
+// implementation, like the Prometheus one of this package, and a Tracer, which maps directly onto an OpenTelemetry
+// tracer. The zero Hooks does nothing.
+package instrument
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcomes of calls, subscriptions, reconnects and pool operations, used as the outcome label
+const (
+	OutcomeOK       = "ok"
+	OutcomeError    = "error"
+	OutcomeRPCError = "rpc_error"
+	OutcomeTimeout  = "timeout"
+	OutcomeCanceled = "canceled"
+)
+
+// Pool events
+const (
+	PoolHit          = "hit"
+	PoolMiss         = "miss"
+	PoolRedial       = "redial"
+	PoolFactoryError = "factory_error"
+	PoolPut          = "put"
+	PoolDiscard      = "discard"
+)
+
+// Span names
+const (
+	SpanCall         = "rpc.call"
+	SpanSubscription = "rpc.subscription"
+	SpanSubmit       = "rpc.submit"
+)
+
+// Attribute keys
+const (
+	AttrMethod    = "rpc.method"
+	AttrEndpoint  = "rpc.endpoint"
+	AttrOutcome   = "rpc.outcome"
+	AttrErrorCode = "rpc.error_code"
+)
+
+// Metrics receives the measurements of the transports
+type Metrics interface {
+	// ObserveCall is called when a JSON-RPC request finished
+	ObserveCall(endpoint, method, outcome string, duration time.Duration)
+	// ObserveErrorCode is called for every error returned by the node
+	ObserveErrorCode(endpoint, method string, code int)
+	// SubscriptionStarted and SubscriptionEnded bracket a subscription
+	SubscriptionStarted(endpoint, method string)
+	SubscriptionEnded(endpoint, method, outcome string)
+	// ObserveReconnect is called after every (re)connection attempt
+	ObserveReconnect(endpoint, outcome string)
+	// ObservePool is called for every event of a connection pool with the number of idle connections after it
+	ObservePool(event string, idle int)
+}
+
+// Tracer starts spans, it has the shape of an OpenTelemetry tracer so one can be adapted in a few lines
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a started span
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key value pair of a span
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Hooks is the instrumentation of a transport, both fields are optional
+type Hooks struct {
+	Metrics Metrics
+	Tracer  Tracer
+}
+
+// errorCoder is implemented by the errors the node returns, see gethrpc.Error
+type errorCoder interface {
+	ErrorCode() int
+}
+
+// Outcome returns the outcome label of err
+func Outcome(err error) string {
+	if err == nil {
+		return OutcomeOK
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &timeout) && timeout.Timeout()) {
+		return OutcomeTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return OutcomeCanceled
+	}
+	var coder errorCoder
+	if errors.As(err, &coder) {
+		return OutcomeRPCError
+	}
+	return OutcomeError
+}
+
+// StartCall starts measuring a JSON-RPC request, the returned function finishes it with the result of the request
+func (h Hooks) StartCall(ctx context.Context, endpoint, method string) (context.Context, func(err error)) {
+	if h.Metrics == nil && h.Tracer == nil {
+		return ctx, func(error) {}
+	}
+
+	name := SpanCall
+	if isSubmission(method) {
+		name = SpanSubmit
+	}
+	ctx, span := h.startSpan(ctx, name, endpoint, method)
+	start := time.Now()
+	return ctx, func(err error) {
+		outcome := Outcome(err)
+		if h.Metrics != nil {
+			h.Metrics.ObserveCall(endpoint, method, outcome, time.Since(start))
+		}
+		h.observeErrorCode(endpoint, method, err, span)
+		endSpan(span, outcome, err)
+	}
+}
+
+// StartSubscription starts measuring a subscription, the returned function ends it and may be called more than once,
+// only the first call counts
+func (h Hooks) StartSubscription(ctx context.Context, endpoint, method string) (context.Context, func(err error)) {
+	if h.Metrics == nil && h.Tracer == nil {
+		return ctx, func(error) {}
+	}
+
+	name := SpanSubscription
+	if isSubmission(method) {
+		name = SpanSubmit
+	}
+	ctx, span := h.startSpan(ctx, name, endpoint, method)
+	if h.Metrics != nil {
+		h.Metrics.SubscriptionStarted(endpoint, method)
+	}
+	once := sync.Once{}
+	return ctx, func(err error) {
+		once.Do(func() {
+			outcome := Outcome(err)
+			if h.Metrics != nil {
+				h.Metrics.SubscriptionEnded(endpoint, method, outcome)
+			}
+			h.observeErrorCode(endpoint, method, err, span)
+			endSpan(span, outcome, err)
+		})
+	}
+}
+
+// Reconnect records a (re)connection attempt to endpoint
+func (h Hooks) Reconnect(endpoint string, err error) {
+	if h.Metrics != nil {
+		h.Metrics.ObserveReconnect(endpoint, Outcome(err))
+	}
+}
+
+// Pool records an event of a connection pool
+func (h Hooks) Pool(event string, idle int) {
+	if h.Metrics != nil {
+		h.Metrics.ObservePool(event, idle)
+	}
+}
+
+func (h Hooks) startSpan(ctx context.Context, name, endpoint, method string) (context.Context, Span) {
+	if h.Tracer == nil {
+		return ctx, nil
+	}
+	return h.Tracer.Start(ctx, name, Attribute{AttrMethod, method}, Attribute{AttrEndpoint, endpoint})
+}
+
+func (h Hooks) observeErrorCode(endpoint, method string, err error, span Span) {
+	var coder errorCoder
+	if err == nil || !errors.As(err, &coder) {
+		return
+	}
+	if h.Metrics != nil {
+		h.Metrics.ObserveErrorCode(endpoint, method, coder.ErrorCode())
+	}
+	if span != nil {
+		span.SetAttributes(Attribute{AttrErrorCode, coder.ErrorCode()})
+	}
+}
+
+func endSpan(span Span, outcome string, err error) {
+	if span == nil {
+		return
+	}
+	span.SetAttributes(Attribute{AttrOutcome, outcome})
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// isSubmission tells if method submits an extrinsic, like author_submitExtrinsic and author_submitAndWatchExtrinsic
+func isSubmission(method string) bool {
+	return strings.HasPrefix(method, "author_submit")
+}
@@ -0,0 +1,126 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrument_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/instrument"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stretchr/testify/assert"
+)
+
+type system struct{}
+
+func (system) Name() string {
+	return "node"
+}
+
+func (system) Fail() error {
+	return errors.New("failed")
+}
+
+type span struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *span) SetAttributes(attrs ...instrument.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *span) RecordError(err error) { s.err = err }
+func (s *span) End()                  { s.ended = true }
+
+type tracer struct {
+	mu    sync.Mutex
+	spans []*span
+}
+
+func (t *tracer) Start(ctx context.Context, name string, attrs ...instrument.Attribute) (context.Context,
+	instrument.Span) {
+	s := &span{name: name, attrs: make(map[string]interface{})}
+	s.SetAttributes(attrs...)
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return ctx, s
+}
+
+func TestHooks_Client(t *testing.T) {
+	s := rpcmocksrv.New()
+	assert.NoError(t, s.RegisterName("system", system{}))
+
+	metrics := instrument.NewPrometheus("gsrpc")
+	tr := &tracer{}
+	cfg := config.Default()
+	cfg.Instrumentation = instrument.Hooks{Metrics: metrics, Tracer: tr}
+
+	c, err := client.ConnectWithConfig(s.URL, cfg)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var name string
+	assert.NoError(t, c.Call(&name, "system_name"))
+	assert.Error(t, c.Call(nil, "system_fail"))
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, metrics.Write(&buf))
+	out := buf.String()
+	endpoint := `endpoint="` + s.URL + `"`
+	assert.Contains(t, out, `gsrpc_rpc_requests_total{`+endpoint+`,method="system_name",outcome="ok"} 1`)
+	assert.Contains(t, out, `gsrpc_rpc_requests_total{`+endpoint+`,method="system_fail",outcome="rpc_error"} 1`)
+	assert.Contains(t, out, `gsrpc_rpc_errors_total{`+endpoint+`,method="system_fail",code="-32000"} 1`)
+	assert.Contains(t, out, `gsrpc_rpc_request_duration_seconds_count{`+endpoint+`,method="system_name",outcome="ok"} 1`)
+	assert.Contains(t, out, `gsrpc_rpc_reconnects_total{`+endpoint+`,outcome="ok"} 1`)
+
+	assert.Len(t, tr.spans, 2)
+	assert.Equal(t, instrument.SpanCall, tr.spans[0].name)
+	assert.Equal(t, "system_name", tr.spans[0].attrs[instrument.AttrMethod])
+	assert.Equal(t, instrument.OutcomeOK, tr.spans[0].attrs[instrument.AttrOutcome])
+	assert.True(t, tr.spans[0].ended)
+	assert.Equal(t, instrument.OutcomeRPCError, tr.spans[1].attrs[instrument.AttrOutcome])
+	assert.Equal(t, -32000, tr.spans[1].attrs[instrument.AttrErrorCode])
+	assert.Error(t, tr.spans[1].err)
+}
+
+func TestOutcome(t *testing.T) {
+	assert.Equal(t, instrument.OutcomeOK, instrument.Outcome(nil))
+	assert.Equal(t, instrument.OutcomeTimeout, instrument.Outcome(context.DeadlineExceeded))
+	assert.Equal(t, instrument.OutcomeCanceled, instrument.Outcome(context.Canceled))
+	assert.Equal(t, instrument.OutcomeError, instrument.Outcome(errors.New("broken pipe")))
+}
+
+func TestPrometheus_LabelEscaping(t *testing.T) {
+	metrics := instrument.NewPrometheus("gsrpc")
+	metrics.ObserveReconnect("wss://nöde.io/a\\b\"c\nd\t", instrument.OutcomeOK)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, metrics.Write(&buf))
+	// only backslash, double quote and line feed are escaped, UTF-8 and tabs are written as they are
+	assert.Contains(t, buf.String(), `gsrpc_rpc_reconnects_total{endpoint="wss://nöde.io/a\\b\"c\nd`+"\t"+`",outcome="ok"} 1`)
+}
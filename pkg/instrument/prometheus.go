@@ -0,0 +1,258 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instrument
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the request duration histogram
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Prometheus is a Metrics that keeps its series in memory and serves them in the Prometheus text exposition format
+// over http, so it can be scraped directly or mounted next to an existing registry's handler. Metric names are
+// prefixed with the namespace:
+//
+//	<ns>_rpc_requests_total{endpoint,method,outcome}
+//	<ns>_rpc_request_duration_seconds{endpoint,method,outcome}
+//	<ns>_rpc_errors_total{endpoint,method,code}
+//	<ns>_rpc_subscriptions_active{endpoint,method}
+//	<ns>_rpc_subscriptions_total{endpoint,method,outcome}
+//	<ns>_rpc_reconnects_total{endpoint,outcome}
+//	<ns>_rpc_pool_events_total{event}
+//	<ns>_rpc_pool_idle_connections
+type Prometheus struct {
+	namespace string
+	buckets   []float64
+
+	mu                  sync.Mutex
+	requests            *family
+	durations           *family
+	errors              *family
+	subscriptionsActive *family
+	subscriptions       *family
+	reconnects          *family
+	poolEvents          *family
+	poolIdle            *family
+}
+
+// NewPrometheus creates the metrics with the given namespace, DefaultBuckets are used if buckets is empty
+func NewPrometheus(namespace string, buckets ...float64) *Prometheus {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	name := func(s string) string {
+		if namespace == "" {
+			return s
+		}
+		return namespace + "_" + s
+	}
+	return &Prometheus{
+		namespace: namespace,
+		buckets:   buckets,
+		requests: newFamily(name("rpc_requests_total"), "counter",
+			"JSON-RPC requests by endpoint, method and outcome.", "endpoint", "method", "outcome"),
+		durations: newFamily(name("rpc_request_duration_seconds"), "histogram",
+			"Duration of JSON-RPC requests.", "endpoint", "method", "outcome"),
+		errors: newFamily(name("rpc_errors_total"), "counter",
+			"Errors returned by the node by error code.", "endpoint", "method", "code"),
+		subscriptionsActive: newFamily(name("rpc_subscriptions_active"), "gauge",
+			"Subscriptions currently open.", "endpoint", "method"),
+		subscriptions: newFamily(name("rpc_subscriptions_total"), "counter",
+			"Ended subscriptions by outcome.", "endpoint", "method", "outcome"),
+		reconnects: newFamily(name("rpc_reconnects_total"), "counter",
+			"Connection attempts by outcome.", "endpoint", "outcome"),
+		poolEvents: newFamily(name("rpc_pool_events_total"), "counter",
+			"Connection pool events.", "event"),
+		poolIdle: newFamily(name("rpc_pool_idle_connections"), "gauge",
+			"Idle connections in the pool."),
+	}
+}
+
+func (p *Prometheus) ObserveCall(endpoint, method, outcome string, duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests.get(endpoint, method, outcome).value++
+	p.durations.get(endpoint, method, outcome).observe(p.buckets, duration.Seconds())
+}
+
+func (p *Prometheus) ObserveErrorCode(endpoint, method string, code int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errors.get(endpoint, method, strconv.Itoa(code)).value++
+}
+
+func (p *Prometheus) SubscriptionStarted(endpoint, method string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subscriptionsActive.get(endpoint, method).value++
+}
+
+func (p *Prometheus) SubscriptionEnded(endpoint, method, outcome string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subscriptionsActive.get(endpoint, method).value--
+	p.subscriptions.get(endpoint, method, outcome).value++
+}
+
+func (p *Prometheus) ObserveReconnect(endpoint, outcome string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reconnects.get(endpoint, outcome).value++
+}
+
+func (p *Prometheus) ObservePool(event string, idle int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.poolEvents.get(event).value++
+	p.poolIdle.get().value = float64(idle)
+}
+
+// ServeHTTP writes all series in the text exposition format
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = p.Write(w)
+}
+
+// Write writes all series in the text exposition format to w
+func (p *Prometheus) Write(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range []*family{p.requests, p.durations, p.errors, p.subscriptionsActive, p.subscriptions,
+		p.reconnects, p.poolEvents, p.poolIdle} {
+		f.write(bw, p.buckets)
+	}
+	return bw.Flush()
+}
+
+// family is a metric with all its label combinations
+type family struct {
+	name   string
+	kind   string
+	help   string
+	labels []string
+	series map[string]*series
+}
+
+type series struct {
+	values []string
+	value  float64
+	// histograms only, counts[i] is the number of observations in bucket i, not cumulative
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newFamily(name, kind, help string, labels ...string) *family {
+	return &family{name: name, kind: kind, help: help, labels: labels, series: make(map[string]*series)}
+}
+
+func (f *family) get(values ...string) *series {
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: values}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (s *series) observe(buckets []float64, v float64) {
+	if s.counts == nil {
+		s.counts = make([]uint64, len(buckets))
+	}
+	for i, b := range buckets {
+		if v <= b {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (f *family) write(w *bufio.Writer, buckets []float64) {
+	if len(f.series) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := f.series[k]
+		if f.kind != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, labelString(f.labels, s.values), formatFloat(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, b := range buckets {
+			if s.counts != nil {
+				cumulative += s.counts[i]
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, labelString(f.labels, s.values, "le", formatFloat(b)),
+				cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, labelString(f.labels, s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labelString(f.labels, s.values), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, labelString(f.labels, s.values), s.count)
+	}
+}
+
+// labelString formats the labels of a series, extra is an additional name and value like the le label of a bucket
+func labelString(names, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(names)+1)
+	for i, n := range names {
+		pairs = append(pairs, n+"="+quoteLabelValue(values[i]))
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, extra[0]+"="+quoteLabelValue(extra[1]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelValueEscaper escapes a label value as the text exposition format wants, other characters like UTF-8 are kept
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabelValue(v string) string {
+	return `"` + labelValueEscaper.Replace(v) + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
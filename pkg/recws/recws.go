@@ -14,6 +14,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/jpillora/backoff"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/instrument"
 )
 
 // ErrNotConnected is returned when the application read/writes
@@ -47,6 +48,8 @@ type RecConn struct {
 	WriteBufferSize  int
 	ReadBufferSize   int
 	NonVerbose       bool
+	// Hooks receives a reconnect measurement for every dial, disabled if zero
+	Hooks instrument.Hooks
 
 	isConnected bool
 	mu          sync.RWMutex
@@ -406,6 +409,7 @@ LOOP:
 	for {
 		nextItvl := b.Duration()
 		wsConn, httpResp, err := rc.dialer.Dial(rc.url, rc.reqHeader)
+		rc.Hooks.Reconnect(rc.url, err)
 		if err != nil {
 			break LOOP
 		}
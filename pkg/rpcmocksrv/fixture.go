@@ -0,0 +1,126 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmocksrv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Fixture is the recorded JSON-RPC traffic with one endpoint
+type Fixture struct {
+	Endpoint  string      `json:"endpoint"`
+	Exchanges []*Exchange `json:"exchanges"`
+}
+
+// Exchange is one request with its response and, for subscriptions, the notifications of the subscription. The
+// messages are kept as they were sent, only the request id is replaced on replay.
+type Exchange struct {
+	Method        string            `json:"method"`
+	Params        json.RawMessage   `json:"params,omitempty"`
+	Response      json.RawMessage   `json:"response"`
+	Notifications []json.RawMessage `json:"notifications,omitempty"`
+}
+
+// LoadFixture reads a fixture written by Fixture.Save
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &Fixture{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("fixture %s: %s", path, err)
+	}
+	return f, nil
+}
+
+// Save writes the fixture to path with one exchange per line. The messages are not indented so they are replayed
+// with the bytes they were recorded with.
+func (f *Fixture) Save(path string) error {
+	endpoint, err := json.Marshal(f.Endpoint)
+	if err != nil {
+		return err
+	}
+	buf := bytes.Buffer{}
+	buf.WriteString(`{"endpoint":` + string(endpoint) + `,"exchanges":[`)
+	for i, ex := range f.Exchanges {
+		data, err := json.Marshal(ex)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("\n")
+		buf.Write(data)
+	}
+	buf.WriteString("\n]}\n")
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// jsonrpcMessage is the part of a JSON-RPC message the recorder and the replay server look at
+type jsonrpcMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+func (m *jsonrpcMessage) isRequest() bool {
+	return m.Method != "" && len(m.ID) != 0
+}
+
+func (m *jsonrpcMessage) isNotification() bool {
+	return m.Method != "" && len(m.ID) == 0
+}
+
+// subscriptionId returns the subscription a notification belongs to
+func (m *jsonrpcMessage) subscriptionId() string {
+	var params struct {
+		Subscription json.RawMessage `json:"subscription"`
+	}
+	if err := json.Unmarshal(m.Params, &params); err != nil {
+		return ""
+	}
+	return string(params.Subscription)
+}
+
+// splitMessages returns the messages of a websocket frame, which is a single message or a batch
+func splitMessages(frame []byte) ([]json.RawMessage, bool, error) {
+	frame = bytes.TrimSpace(frame)
+	if len(frame) > 0 && frame[0] == '[' {
+		var msgs []json.RawMessage
+		err := json.Unmarshal(frame, &msgs)
+		return msgs, true, err
+	}
+	return []json.RawMessage{frame}, false, nil
+}
+
+// compactParams returns params without insignificant whitespace, so equal params compare equal
+func compactParams(params json.RawMessage) string {
+	if len(params) == 0 {
+		return "[]"
+	}
+	buf := bytes.Buffer{}
+	if err := json.Compact(&buf, params); err != nil {
+		return string(params)
+	}
+	return buf.String()
+}
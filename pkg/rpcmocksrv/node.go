@@ -0,0 +1,567 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmocksrv
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/sr25519"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	// ErrBadSignature is returned for extrinsics whose signature does not match their payload
+	ErrBadSignature = errors.New("Transaction has a bad signature")
+	// ErrUnsigned is returned for unsigned extrinsics unless NodeConfig.AllowUnsigned is set
+	ErrUnsigned = errors.New("Could not find an unsigned validator for the unsigned transaction")
+	// ErrAncientBirthBlock is returned for mortal extrinsics whose birth block is unknown or whose era has passed
+	ErrAncientBirthBlock = errors.New("Transaction has an ancient birth block")
+	// ErrRewindFinalized is returned by Rewind for a block below the last finalized block
+	ErrRewindFinalized = errors.New("cannot rewind below the finalized block")
+)
+
+// NodeConfig configures a simulated node, every field is optional
+type NodeConfig struct {
+	// Name, Version and Chain are returned by system_name, system_version and system_chain
+	Name    string
+	Version string
+	Chain   string
+	// Properties are returned by system_properties
+	Properties map[string]interface{}
+	// RuntimeVersion of the genesis block, default to spec version 1 and transaction version 1
+	RuntimeVersion *types.RuntimeVersion
+	// Metadata is the hex encoded metadata returned by state_getMetadata, default to
+	// types.ExamplaryMetadataV13SubstrateString
+	Metadata string
+	// Genesis is the storage of the genesis block by hex encoded key
+	Genesis map[string][]byte
+
+	// MultiAddress makes the node decode extrinsics as types.ExtrinsicMulti instead of types.Extrinsic
+	MultiAddress bool
+	// AllowUnsigned accepts unsigned extrinsics into the pool
+	AllowUnsigned bool
+	// FinalityLag is the number of blocks finality is behind the best block, 0 finalizes every block right away
+	FinalityLag int
+	// BlockTime produces blocks periodically, 0 produces blocks only on ProduceBlock
+	BlockTime time.Duration
+	// OnExtrinsic is called for every extrinsic included in a block, before the block's storage is committed. It
+	// may change the storage with SetStorage, like the runtime would.
+	OnExtrinsic func(n *Node, xt []byte)
+	// PaymentInfo returns the weight and partial fee payment_queryInfo reports for an extrinsic, both are 0 if it is
+	// not set
+	PaymentInfo func(xt []byte) (weight uint64, partialFee uint64)
+}
+
+// Node is an in-memory Substrate node serving chain_*, state_*, system_* and author_* over websocket. Blocks are
+// produced on demand with ProduceBlock, storage set with SetStorage shows up in the next block.
+type Node struct {
+	// Host consists of hostname and port
+	Host string
+	// URL consists of protocol, hostname and port
+	URL string
+
+	cfg      NodeConfig
+	server   *http.Server
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// produceMu serializes block production, mu guards the chain
+	produceMu sync.Mutex
+	mu        sync.Mutex
+	blocks    []*simBlock
+	byHash    map[types.Hash]*simBlock
+	finalized int
+	overlay   map[string][]byte // pending storage changes, nil deletes
+	runtime   types.RuntimeVersion
+	pool      []*poolEntry
+	subs      map[string]*nodeSubscription
+	nextSubId uint64
+}
+
+type simBlock struct {
+	hash       types.Hash
+	header     types.Header
+	extrinsics [][]byte
+	state      map[string][]byte
+	runtime    types.RuntimeVersion
+	included   []*poolEntry
+	// justification is the grandpa justification served with the block, if any
+	justification []byte
+}
+
+type poolEntry struct {
+	xt      []byte
+	hash    types.Hash
+	watcher *nodeSubscription
+}
+
+// NewNode starts a simulated node on a random local port with a genesis block
+func NewNode(cfg NodeConfig) (*Node, error) {
+	if cfg.Name == "" {
+		cfg.Name = "Simulated Node"
+	}
+	if cfg.Version == "" {
+		cfg.Version = "1.0.0"
+	}
+	if cfg.Chain == "" {
+		cfg.Chain = "Development"
+	}
+	if cfg.Metadata == "" {
+		cfg.Metadata = types.ExamplaryMetadataV13SubstrateString
+	}
+	runtime := types.RuntimeVersion{SpecName: "node-sim", ImplName: "node-sim", SpecVersion: 1, TransactionVersion: 1}
+	if cfg.RuntimeVersion != nil {
+		runtime = *cfg.RuntimeVersion
+	}
+
+	n := &Node{
+		cfg:     cfg,
+		stop:    make(chan struct{}),
+		byHash:  make(map[types.Hash]*simBlock),
+		overlay: make(map[string][]byte),
+		runtime: runtime,
+		subs:    make(map[string]*nodeSubscription),
+	}
+	state := make(map[string][]byte, len(cfg.Genesis))
+	for k, v := range cfg.Genesis {
+		state[normalizeKey(k)] = v
+	}
+	genesis := &simBlock{state: state, runtime: runtime}
+	genesis.header.StateRoot = stateRoot(state)
+	genesis.hash = headerHash(genesis.header)
+	n.blocks = []*simBlock{genesis}
+	n.byHash[genesis.hash] = genesis
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	n.Host = l.Addr().String()
+	n.URL = "ws://" + n.Host
+	n.server = &http.Server{Handler: http.HandlerFunc(n.serve)}
+	go n.server.Serve(l) //nolint:errcheck
+
+	if cfg.BlockTime > 0 {
+		go n.produceLoop()
+	}
+	return n, nil
+}
+
+// Close stops the node and drops all connections
+func (n *Node) Close() error {
+	n.stopOnce.Do(func() { close(n.stop) })
+	return n.server.Close()
+}
+
+// GenesisHash returns the hash of block 0
+func (n *Node) GenesisHash() types.Hash {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.blocks[0].hash
+}
+
+// BestHash returns the hash of the best block
+func (n *Node) BestHash() types.Hash {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.best().hash
+}
+
+// BestNumber returns the number of the best block
+func (n *Node) BestNumber() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return uint64(len(n.blocks) - 1)
+}
+
+// FinalizedHash returns the hash of the last finalized block
+func (n *Node) FinalizedHash() types.Hash {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.blocks[n.finalized].hash
+}
+
+// SetStorage sets the value of key in the next block
+func (n *Node) SetStorage(key types.StorageKey, value []byte) {
+	if value == nil {
+		value = []byte{}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.overlay[key.Hex()] = value
+}
+
+// DeleteStorage removes key in the next block
+func (n *Node) DeleteStorage(key types.StorageKey) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.overlay[key.Hex()] = nil
+}
+
+// GetStorage returns the value of key in the best block
+func (n *Node) GetStorage(key types.StorageKey) ([]byte, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	v, ok := n.best().state[key.Hex()]
+	return v, ok
+}
+
+// SetRuntimeVersion upgrades the runtime with the next block
+func (n *Node) SetRuntimeVersion(v types.RuntimeVersion) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.runtime = v
+}
+
+// Pending returns the extrinsics waiting in the pool
+func (n *Node) Pending() [][]byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	xts := make([][]byte, len(n.pool))
+	for i, e := range n.pool {
+		xts[i] = e.xt
+	}
+	return xts
+}
+
+// ProduceBlock builds a block with the pooled extrinsics and the pending storage changes on top of the best block,
+// finalizes according to FinalityLag and notifies the subscribers. It returns the hash of the new block.
+func (n *Node) ProduceBlock() types.Hash {
+	n.produceMu.Lock()
+	defer n.produceMu.Unlock()
+
+	n.mu.Lock()
+	pool := n.pool
+	n.pool = nil
+	n.mu.Unlock()
+
+	if n.cfg.OnExtrinsic != nil {
+		for _, e := range pool {
+			n.cfg.OnExtrinsic(n, e.xt)
+		}
+	}
+
+	n.mu.Lock()
+	parent := n.best()
+	state := make(map[string][]byte, len(parent.state)+len(n.overlay))
+	for k, v := range parent.state {
+		state[k] = v
+	}
+	changed := make(map[string]bool, len(n.overlay))
+	for k, v := range n.overlay {
+		if v == nil {
+			delete(state, k)
+		} else {
+			state[k] = v
+		}
+		changed[k] = true
+	}
+	n.overlay = make(map[string][]byte)
+
+	b := &simBlock{state: state, runtime: n.runtime, included: pool}
+	xtsRoot := bytes.Buffer{}
+	for _, e := range pool {
+		b.extrinsics = append(b.extrinsics, e.xt)
+		xtsRoot.Write(e.xt)
+	}
+	b.header = types.Header{
+		ParentHash:     parent.hash,
+		Number:         types.BlockNumber(len(n.blocks)),
+		StateRoot:      stateRoot(state),
+		ExtrinsicsRoot: blake2b.Sum256(xtsRoot.Bytes()),
+	}
+	b.hash = headerHash(b.header)
+	n.blocks = append(n.blocks, b)
+	n.byHash[b.hash] = b
+	runtimeChanged := b.runtime.SpecVersion != parent.runtime.SpecVersion ||
+		b.runtime.TransactionVersion != parent.runtime.TransactionVersion
+
+	var finalized []*simBlock
+	if target := len(n.blocks) - 1 - n.cfg.FinalityLag; target > n.finalized {
+		finalized = n.blocks[n.finalized+1 : target+1]
+		n.finalized = target
+	}
+	subs := n.subscriptions()
+	n.mu.Unlock()
+
+	for _, e := range pool {
+		if e.watcher != nil {
+			e.watcher.notify(map[string]types.Hash{"inBlock": b.hash})
+		}
+	}
+	for _, s := range subs {
+		switch s.kind {
+		case subNewHeads, subAllHeads:
+			s.notify(headerJSON(b.header))
+		case subStorage:
+			if cs, ok := s.changes(b, changed); ok {
+				s.notify(cs)
+			}
+		case subRuntimeVersion:
+			if runtimeChanged {
+				s.notify(b.runtime)
+			}
+		}
+	}
+	for _, f := range finalized {
+		n.notifyFinalized(f, subs)
+	}
+	return b.hash
+}
+
+// Rewind drops the best chain blocks above number, the next ProduceBlock forks off the block at number. The dropped
+// blocks stay known by hash. A fork block differs from the dropped block of its number only if its extrinsics or
+// storage differ.
+func (n *Node) Rewind(number uint64) error {
+	n.produceMu.Lock()
+	defer n.produceMu.Unlock()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if number < uint64(n.finalized) {
+		return ErrRewindFinalized
+	}
+	if number < uint64(len(n.blocks)) {
+		n.blocks = n.blocks[:number+1]
+	}
+	return nil
+}
+
+// ErrUnknownBlock is returned for a block hash the node does not know
+var ErrUnknownBlock = errors.New("unknown block")
+
+// Justify attaches a grandpa justification to the block, chain_getBlock serves it in justifications as an FRNK entry
+func (n *Node) Justify(hash types.Hash, justification []byte) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	b, ok := n.byHash[hash]
+	if !ok {
+		return ErrUnknownBlock
+	}
+	b.justification = justification
+	return nil
+}
+
+// Finalize finalizes all blocks up to the best block
+func (n *Node) Finalize() {
+	n.produceMu.Lock()
+	defer n.produceMu.Unlock()
+
+	n.mu.Lock()
+	finalized := n.blocks[n.finalized+1:]
+	n.finalized = len(n.blocks) - 1
+	subs := n.subscriptions()
+	n.mu.Unlock()
+
+	for _, f := range finalized {
+		n.notifyFinalized(f, subs)
+	}
+}
+
+func (n *Node) notifyFinalized(b *simBlock, subs []*nodeSubscription) {
+	for _, s := range subs {
+		if s.kind == subFinalizedHeads {
+			s.notify(headerJSON(b.header))
+		}
+	}
+	for _, e := range b.included {
+		if e.watcher != nil {
+			e.watcher.notify(map[string]types.Hash{"finalized": b.hash})
+			e.watcher.close()
+		}
+	}
+}
+
+func (n *Node) produceLoop() {
+	ticker := time.NewTicker(n.cfg.BlockTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			n.ProduceBlock()
+		case <-n.stop:
+			return
+		}
+	}
+}
+
+// enqueue adds a verified extrinsic to the pool and returns its hash
+func (n *Node) enqueue(xt []byte, watcher *nodeSubscription) types.Hash {
+	hash := types.Hash(blake2b.Sum256(xt))
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pool = append(n.pool, &poolEntry{xt: xt, hash: hash, watcher: watcher})
+	return hash
+}
+
+// verify decodes xt and checks its signature against the payload the signer must have signed
+func (n *Node) verify(xt []byte) error {
+	var (
+		signed bool
+		method types.Call
+		era    types.ExtrinsicEra
+		nonce  types.UCompact
+		tip    types.UCompact
+		signer []byte
+		sig    types.MultiSignature
+	)
+	if n.cfg.MultiAddress {
+		ext := types.ExtrinsicMulti{}
+		if err := types.DecodeFromBytes(xt, &ext); err != nil {
+			return err
+		}
+		signed, method = ext.IsSigned(), ext.Method
+		s := ext.Signature
+		era, nonce, tip, sig = s.Era, s.Nonce, s.Tip, s.Signature
+		signer = s.Signer.AsID[:]
+	} else {
+		ext := types.Extrinsic{}
+		if err := types.DecodeFromBytes(xt, &ext); err != nil {
+			return err
+		}
+		signed, method = ext.IsSigned(), ext.Method
+		s := ext.Signature
+		era, nonce, tip, sig = s.Era, s.Nonce, s.Tip, s.Signature
+		signer = s.Signer.AsAccountID[:]
+	}
+
+	if !signed {
+		if n.cfg.AllowUnsigned {
+			return nil
+		}
+		return ErrUnsigned
+	}
+	if !sig.IsSr25519 {
+		return ErrBadSignature
+	}
+
+	n.mu.Lock()
+	runtime := n.runtime
+	genesis := n.blocks[0].hash
+	birth, ok := n.birthBlock(era)
+	n.mu.Unlock()
+	if !ok {
+		return ErrAncientBirthBlock
+	}
+
+	mb, err := types.EncodeToBytes(method)
+	if err != nil {
+		return err
+	}
+	payload := types.ExtrinsicPayloadV4{
+		ExtrinsicPayloadV3: types.ExtrinsicPayloadV3{
+			Method:      mb,
+			Era:         era,
+			Nonce:       nonce,
+			Tip:         tip,
+			SpecVersion: runtime.SpecVersion,
+			GenesisHash: genesis,
+			BlockHash:   birth,
+		},
+		TransactionVersion: runtime.TransactionVersion,
+	}
+	data, err := types.EncodeToBytes(payload)
+	if err != nil {
+		return err
+	}
+	// signature.Sign hashes long payloads before signing
+	if len(data) > 256 {
+		h := blake2b.Sum256(data)
+		data = h[:]
+	}
+
+	pub, err := sr25519.Scheme{}.FromPublicKey(signer)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if !pub.Verify(data, sig.AsSr25519[:]) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// birthBlock returns the block a mortal era starts at, the genesis block for an immortal one
+func (n *Node) birthBlock(era types.ExtrinsicEra) (types.Hash, bool) {
+	if !era.IsMortalEra {
+		return n.blocks[0].hash, true
+	}
+	encoded := uint64(era.AsMortalEra.First) + uint64(era.AsMortalEra.Second)<<8
+	period := uint64(2) << (encoded % 16)
+	quantizeFactor := period >> 12
+	if quantizeFactor == 0 {
+		quantizeFactor = 1
+	}
+	phase := (encoded >> 4) * quantizeFactor
+
+	current := uint64(len(n.blocks) - 1)
+	if current < phase {
+		current = phase
+	}
+	birth := (current-phase)/period*period + phase
+	if birth >= uint64(len(n.blocks)) || uint64(len(n.blocks)-1) >= birth+period {
+		return types.Hash{}, false
+	}
+	return n.blocks[birth].hash, true
+}
+
+// block returns the block with hash, the best block if hash is nil
+func (n *Node) block(hash *types.Hash) (*simBlock, bool) {
+	if hash == nil {
+		return n.best(), true
+	}
+	b, ok := n.byHash[*hash]
+	return b, ok
+}
+
+func (n *Node) best() *simBlock {
+	return n.blocks[len(n.blocks)-1]
+}
+
+func stateRoot(state map[string][]byte) types.Hash {
+	keys := sortedKeys(state)
+	buf := bytes.Buffer{}
+	for _, k := range keys {
+		buf.WriteString(k)
+		buf.Write(state[k])
+	}
+	return blake2b.Sum256(buf.Bytes())
+}
+
+func headerHash(h types.Header) types.Hash {
+	hash, err := types.GetHash(h)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+func sortedKeys(state map[string][]byte) []string {
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,573 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmocksrv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+// JSON-RPC error codes of the author_* methods, as returned by Substrate
+const (
+	errCodeBadFormat          = 1001
+	errCodeInvalidTransaction = 1010
+)
+
+type subscriptionKind int
+
+const (
+	subNewHeads subscriptionKind = iota
+	subAllHeads
+	subFinalizedHeads
+	subStorage
+	subRuntimeVersion
+	subExtrinsic
+)
+
+// notification methods by subscription kind
+var subscriptionMethods = map[subscriptionKind]string{
+	subNewHeads:       "chain_newHead",
+	subAllHeads:       "chain_allHead",
+	subFinalizedHeads: "chain_finalizedHead",
+	subStorage:        "state_storage",
+	subRuntimeVersion: "state_runtimeVersion",
+	subExtrinsic:      "author_extrinsicUpdate",
+}
+
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// nodeConn is a websocket connection to the node, writes come from the read loop and from block production
+type nodeConn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (c *nodeConn) write(v interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.conn.WriteJSON(v)
+}
+
+type nodeSubscription struct {
+	id   string
+	kind subscriptionKind
+	keys []string
+	node *Node
+	conn *nodeConn
+}
+
+func (s *nodeSubscription) notify(result interface{}) {
+	s.conn.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  subscriptionMethods[s.kind],
+		"params":  map[string]interface{}{"subscription": s.id, "result": result},
+	})
+}
+
+// close ends the subscription on the node side, the client is not told
+func (s *nodeSubscription) close() {
+	s.node.mu.Lock()
+	defer s.node.mu.Unlock()
+	delete(s.node.subs, s.id)
+}
+
+// changes returns the changes of b the subscription watches, all changes if it watches no keys
+func (s *nodeSubscription) changes(b *simBlock, changed map[string]bool) (types.StorageChangeSet, bool) {
+	keys := s.keys
+	if len(keys) == 0 {
+		keys = make([]string, 0, len(changed))
+		for k := range changed {
+			keys = append(keys, k)
+		}
+	}
+	cs := types.StorageChangeSet{Block: b.hash}
+	for _, k := range keys {
+		if changed[k] {
+			cs.Changes = append(cs.Changes, keyValue(b.state, k))
+		}
+	}
+	return cs, len(cs.Changes) > 0
+}
+
+// subscriptions returns the open subscriptions, n.mu must be held
+func (n *Node) subscriptions() []*nodeSubscription {
+	subs := make([]*nodeSubscription, 0, len(n.subs))
+	for _, s := range n.subs {
+		subs = append(subs, s)
+	}
+	return subs
+}
+
+func (n *Node) subscribe(conn *nodeConn, kind subscriptionKind, keys []string) *nodeSubscription {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nextSubId++
+	s := &nodeSubscription{id: fmt.Sprintf("sim%x", n.nextSubId), kind: kind, keys: keys, node: n, conn: conn}
+	n.subs[s.id] = s
+	return s
+}
+
+func (n *Node) unsubscribe(conn *nodeConn, id string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	s, ok := n.subs[id]
+	if !ok || s.conn != conn {
+		return false
+	}
+	delete(n.subs, id)
+	return true
+}
+
+func (n *Node) serve(w http.ResponseWriter, req *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	ws, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		return
+	}
+	conn := &nodeConn{conn: ws}
+	defer func() {
+		n.mu.Lock()
+		for id, s := range n.subs {
+			if s.conn == conn {
+				delete(n.subs, id)
+			}
+		}
+		n.mu.Unlock()
+		ws.Close()
+	}()
+
+	for {
+		_, frame, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		msgs, batch, err := splitMessages(frame)
+		if err != nil {
+			return
+		}
+
+		responses := make([]interface{}, 0, len(msgs))
+		var after []func()
+		for _, raw := range msgs {
+			msg := &jsonrpcMessage{}
+			if err := json.Unmarshal(raw, msg); err != nil || !msg.isRequest() {
+				continue
+			}
+			resp := map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID}
+			result, then, err := n.handle(conn, msg.Method, msg.Params)
+			if err != nil {
+				e, ok := err.(*rpcError)
+				if !ok {
+					e = &rpcError{Code: -32602, Message: err.Error()}
+				}
+				resp["error"] = e
+			} else {
+				resp["result"] = result
+			}
+			responses = append(responses, resp)
+			if then != nil {
+				after = append(after, then)
+			}
+		}
+
+		if batch {
+			conn.write(responses)
+		} else if len(responses) == 1 {
+			conn.write(responses[0])
+		}
+		for _, f := range after {
+			f()
+		}
+	}
+}
+
+// handle answers a request, then is called after the response is written and sends the first notifications of a
+// subscription
+func (n *Node) handle(conn *nodeConn, method string, params json.RawMessage) (result interface{}, then func(),
+	err error) {
+	var args []json.RawMessage
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, nil, &rpcError{Code: -32602, Message: "invalid params"}
+		}
+	}
+
+	switch method {
+	case "system_name":
+		return n.cfg.Name, nil, nil
+	case "system_version":
+		return n.cfg.Version, nil, nil
+	case "system_chain":
+		return n.cfg.Chain, nil, nil
+	case "system_properties":
+		if n.cfg.Properties == nil {
+			return map[string]interface{}{}, nil, nil
+		}
+		return n.cfg.Properties, nil, nil
+	case "system_health":
+		return map[string]interface{}{"peers": 0, "isSyncing": false, "shouldHavePeers": false}, nil, nil
+	case "system_peers":
+		return []interface{}{}, nil, nil
+
+	case "chain_getBlockHash":
+		number, err := blockNumberArg(args, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if number == nil {
+			return n.best().hash, nil, nil
+		}
+		if *number >= uint64(len(n.blocks)) {
+			return nil, nil, nil
+		}
+		return n.blocks[*number].hash, nil, nil
+	case "chain_getHeader", "chain_getBlock":
+		hash, err := hashArg(args, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		b, ok := n.block(hash)
+		if !ok {
+			return nil, nil, nil
+		}
+		if method == "chain_getHeader" {
+			return headerJSON(b.header), nil, nil
+		}
+		xts := make([]string, len(b.extrinsics))
+		for i, xt := range b.extrinsics {
+			xts[i] = fmt.Sprintf("%#x", xt)
+		}
+		var justifications interface{}
+		if b.justification != nil {
+			// substrate serializes the engine id and the justification as byte arrays
+			justifications = [][2][]int{{bytesJSON([]byte("FRNK")), bytesJSON(b.justification)}}
+		}
+		return map[string]interface{}{
+			"block":          map[string]interface{}{"header": headerJSON(b.header), "extrinsics": xts},
+			"justifications": justifications,
+		}, nil, nil
+	case "chain_getFinalizedHead", "chain_getFinalisedHead":
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		return n.blocks[n.finalized].hash, nil, nil
+	case "chain_subscribeNewHead", "chain_subscribeNewHeads", "chain_subscribeAllHeads":
+		kind := subNewHeads
+		if method == "chain_subscribeAllHeads" {
+			kind = subAllHeads
+		}
+		s := n.subscribe(conn, kind, nil)
+		return s.id, func() {
+			n.mu.Lock()
+			h := headerJSON(n.best().header)
+			n.mu.Unlock()
+			s.notify(h)
+		}, nil
+	case "chain_subscribeFinalizedHeads", "chain_subscribeFinalisedHeads":
+		s := n.subscribe(conn, subFinalizedHeads, nil)
+		return s.id, func() {
+			n.mu.Lock()
+			h := headerJSON(n.blocks[n.finalized].header)
+			n.mu.Unlock()
+			s.notify(h)
+		}, nil
+
+	case "state_getStorage", "state_getStorageAt", "state_getStorageHash", "state_getStorageSize":
+		key, err := stringArg(args, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		hash, err := hashArg(args, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		b, ok := n.block(hash)
+		if !ok {
+			return nil, nil, &rpcError{Code: 4003, Message: "Client error: UnknownBlock"}
+		}
+		v, ok := b.state[normalizeKey(key)]
+		if !ok {
+			return nil, nil, nil
+		}
+		switch method {
+		case "state_getStorageHash":
+			return types.Hash(blake2b.Sum256(v)), nil, nil
+		case "state_getStorageSize":
+			return len(v), nil, nil
+		}
+		return fmt.Sprintf("%#x", v), nil, nil
+	case "state_getKeys", "state_getKeysPaged":
+		prefix, err := stringArg(args, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		count, start, at := -1, "", 1
+		if method == "state_getKeysPaged" {
+			var c uint32
+			if len(args) < 2 || json.Unmarshal(args[1], &c) != nil {
+				return nil, nil, &rpcError{Code: -32602, Message: "invalid count"}
+			}
+			count = int(c)
+			if len(args) > 2 {
+				_ = json.Unmarshal(args[2], &start)
+			}
+			at = 3
+		}
+		hash, err := hashArg(args, at)
+		if err != nil {
+			return nil, nil, err
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		b, ok := n.block(hash)
+		if !ok {
+			return nil, nil, &rpcError{Code: 4003, Message: "Client error: UnknownBlock"}
+		}
+		prefix, start = normalizeKey(prefix), normalizeKey(start)
+		keys := make([]string, 0)
+		for _, k := range sortedKeys(b.state) {
+			if count >= 0 && len(keys) >= count {
+				break
+			}
+			if strings.HasPrefix(k, prefix) && (start == "0x" || k > start) {
+				keys = append(keys, k)
+			}
+		}
+		return keys, nil, nil
+	case "state_queryStorageAt":
+		var keys []string
+		if len(args) < 1 || json.Unmarshal(args[0], &keys) != nil {
+			return nil, nil, &rpcError{Code: -32602, Message: "invalid keys"}
+		}
+		hash, err := hashArg(args, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		b, ok := n.block(hash)
+		if !ok {
+			return nil, nil, &rpcError{Code: 4003, Message: "Client error: UnknownBlock"}
+		}
+		cs := types.StorageChangeSet{Block: b.hash, Changes: make([]types.KeyValueOption, len(keys))}
+		for i, k := range keys {
+			cs.Changes[i] = keyValue(b.state, normalizeKey(k))
+		}
+		return []types.StorageChangeSet{cs}, nil, nil
+	case "state_getMetadata":
+		return n.cfg.Metadata, nil, nil
+	case "state_getRuntimeVersion", "chain_getRuntimeVersion":
+		hash, err := hashArg(args, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		b, ok := n.block(hash)
+		if !ok {
+			return nil, nil, &rpcError{Code: 4003, Message: "Client error: UnknownBlock"}
+		}
+		return b.runtime, nil, nil
+	case "state_subscribeStorage":
+		var keys []string
+		if len(args) > 0 {
+			if err := json.Unmarshal(args[0], &keys); err != nil {
+				return nil, nil, &rpcError{Code: -32602, Message: "invalid keys"}
+			}
+		}
+		for i := range keys {
+			keys[i] = normalizeKey(keys[i])
+		}
+		s := n.subscribe(conn, subStorage, keys)
+		return s.id, func() {
+			n.mu.Lock()
+			b := n.best()
+			cs := types.StorageChangeSet{Block: b.hash, Changes: make([]types.KeyValueOption, 0, len(keys))}
+			for _, k := range keys {
+				cs.Changes = append(cs.Changes, keyValue(b.state, k))
+			}
+			n.mu.Unlock()
+			s.notify(cs)
+		}, nil
+	case "state_subscribeRuntimeVersion", "chain_subscribeRuntimeVersion":
+		s := n.subscribe(conn, subRuntimeVersion, nil)
+		return s.id, func() {
+			n.mu.Lock()
+			v := n.best().runtime
+			n.mu.Unlock()
+			s.notify(v)
+		}, nil
+
+	case "chain_unsubscribeNewHead", "chain_unsubscribeNewHeads", "chain_unsubscribeAllHeads",
+		"chain_unsubscribeFinalizedHeads", "chain_unsubscribeFinalisedHeads", "state_unsubscribeStorage",
+		"state_unsubscribeRuntimeVersion", "chain_unsubscribeRuntimeVersion", "author_unwatchExtrinsic":
+		id, err := stringArg(args, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		return n.unsubscribe(conn, id), nil, nil
+
+	case "author_submitExtrinsic", "author_submitAndWatchExtrinsic":
+		xtHex, err := stringArg(args, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		xt, err := types.HexDecodeString(xtHex)
+		if err != nil {
+			return nil, nil, &rpcError{Code: errCodeBadFormat, Message: "Extrinsic has invalid format: " + err.Error()}
+		}
+		if err := n.verify(xt); err != nil {
+			switch err {
+			case ErrBadSignature, ErrUnsigned, ErrAncientBirthBlock:
+				return nil, nil, &rpcError{Code: errCodeInvalidTransaction, Message: "Invalid Transaction",
+					Data: err.Error()}
+			}
+			return nil, nil, &rpcError{Code: errCodeBadFormat, Message: "Extrinsic has invalid format: " + err.Error()}
+		}
+		if method == "author_submitExtrinsic" {
+			return n.enqueue(xt, nil), nil, nil
+		}
+		// the extrinsic enters the pool after the subscription is announced, so it is ready before it is in a block
+		watcher := n.subscribe(conn, subExtrinsic, nil)
+		return watcher.id, func() {
+			watcher.notify("ready")
+			n.enqueue(xt, watcher)
+		}, nil
+	case "payment_queryInfo":
+		xtHex, err := stringArg(args, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		xt, err := types.HexDecodeString(xtHex)
+		if err != nil {
+			return nil, nil, &rpcError{Code: errCodeBadFormat, Message: "Extrinsic has invalid format: " + err.Error()}
+		}
+		var weight, fee uint64
+		if n.cfg.PaymentInfo != nil {
+			weight, fee = n.cfg.PaymentInfo(xt)
+		}
+		return map[string]interface{}{"weight": weight, "class": "normal", "partialFee": fmt.Sprint(fee)}, nil, nil
+	case "author_pendingExtrinsics":
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		xts := make([]string, len(n.pool))
+		for i, e := range n.pool {
+			xts[i] = fmt.Sprintf("%#x", e.xt)
+		}
+		return xts, nil, nil
+	}
+	return nil, nil, &rpcError{Code: -32601, Message: fmt.Sprintf("Method not found: %s", method)}
+}
+
+// headerJSON encodes a header like Substrate does, types.Header leaves out the 0x of the number
+func headerJSON(h types.Header) map[string]interface{} {
+	return map[string]interface{}{
+		"parentHash":     h.ParentHash,
+		"number":         fmt.Sprintf("%#x", uint64(h.Number)),
+		"stateRoot":      h.StateRoot,
+		"extrinsicsRoot": h.ExtrinsicsRoot,
+		"digest":         h.Digest,
+	}
+}
+
+func keyValue(state map[string][]byte, key string) types.KeyValueOption {
+	kv := types.KeyValueOption{StorageKey: types.MustHexDecodeString(key)}
+	if v, ok := state[key]; ok {
+		kv.HasStorageData = true
+		kv.StorageData = v
+	}
+	return kv
+}
+
+// normalizeKey returns a hex encoded key in the form of types.StorageKey.Hex
+func normalizeKey(key string) string {
+	key = strings.ToLower(strings.TrimPrefix(key, "0x"))
+	return "0x" + key
+}
+
+func stringArg(args []json.RawMessage, i int) (string, error) {
+	var s string
+	if i >= len(args) || json.Unmarshal(args[i], &s) != nil {
+		return "", &rpcError{Code: -32602, Message: fmt.Sprintf("invalid param %d", i)}
+	}
+	return s, nil
+}
+
+// hashArg returns the optional block hash at i
+func hashArg(args []json.RawMessage, i int) (*types.Hash, error) {
+	if i >= len(args) || string(args[i]) == "null" {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(args[i], &s); err != nil {
+		return nil, &rpcError{Code: -32602, Message: fmt.Sprintf("invalid block hash %s", args[i])}
+	}
+	hash, err := types.NewHashFromHexString(s)
+	if err != nil {
+		return nil, &rpcError{Code: -32602, Message: fmt.Sprintf("invalid block hash %s", s)}
+	}
+	return &hash, nil
+}
+
+// blockNumberArg returns the optional block number at i, given as number or hex string
+func blockNumberArg(args []json.RawMessage, i int) (*uint64, error) {
+	if i >= len(args) || string(args[i]) == "null" {
+		return nil, nil
+	}
+	var number uint64
+	if err := json.Unmarshal(args[i], &number); err == nil {
+		return &number, nil
+	}
+	var s string
+	if err := json.Unmarshal(args[i], &s); err != nil {
+		return nil, &rpcError{Code: -32602, Message: fmt.Sprintf("invalid block number %s", args[i])}
+	}
+	number, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+	if err != nil {
+		return nil, &rpcError{Code: -32602, Message: fmt.Sprintf("invalid block number %s", s)}
+	}
+	return &number, nil
+}
+
+func bytesJSON(bz []byte) []int {
+	ints := make([]int, len(bz))
+	for i, b := range bz {
+		ints[i] = int(b)
+	}
+	return ints
+}
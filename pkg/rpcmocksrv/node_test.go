@@ -0,0 +1,205 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmocksrv_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/rpc"
+	"github.com/stafiprotocol/go-substrate-rpc-client/signature"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNode(t *testing.T, cfg rpcmocksrv.NodeConfig) (*rpcmocksrv.Node, *rpc.RPCS) {
+	node, err := rpcmocksrv.NewNode(cfg)
+	assert.NoError(t, err)
+	t.Cleanup(func() { node.Close() })
+	api, err := rpc.NewRPCS(node.URL)
+	assert.NoError(t, err)
+	return node, api
+}
+
+func TestNode_ChainAndStorage(t *testing.T) {
+	node, api := newNode(t, rpcmocksrv.NodeConfig{FinalityLag: 1})
+	key := types.NewStorageKey([]byte{0x01, 0x02})
+
+	heads, err := api.Chain.SubscribeNewHeads()
+	assert.NoError(t, err)
+	defer heads.Unsubscribe()
+	storage, err := api.State.SubscribeStorageRaw([]types.StorageKey{key})
+	assert.NoError(t, err)
+	defer storage.Unsubscribe()
+
+	assert.Equal(t, types.BlockNumber(0), (<-heads.Chan()).Number)
+	assert.False(t, (<-storage.Chan()).Changes[0].HasStorageData)
+
+	node.SetStorage(key, []byte{0xaa})
+	hash := node.ProduceBlock()
+	assert.Equal(t, types.BlockNumber(1), (<-heads.Chan()).Number)
+	changes := <-storage.Chan()
+	assert.Equal(t, hash, changes.Block)
+	assert.Equal(t, types.StorageDataRaw{0xaa}, changes.Changes[0].StorageData)
+
+	header, err := api.Chain.GetHeaderLatest()
+	assert.NoError(t, err)
+	assert.Equal(t, types.BlockNumber(1), header.Number)
+	assert.Equal(t, node.GenesisHash(), header.ParentHash)
+
+	data, err := api.State.GetStorageRawLatest(key)
+	assert.NoError(t, err)
+	assert.Equal(t, types.StorageDataRaw{0xaa}, *data)
+	data, err = api.State.GetStorageRaw(key, node.GenesisHash())
+	assert.NoError(t, err)
+	assert.Empty(t, *data)
+
+	finalized, err := api.Chain.GetFinalizedHead()
+	assert.NoError(t, err)
+	assert.Equal(t, node.GenesisHash(), finalized)
+	node.Finalize()
+	finalized, err = api.Chain.GetFinalizedHead()
+	assert.NoError(t, err)
+	assert.Equal(t, hash, finalized)
+
+	name, err := api.System.Name()
+	assert.NoError(t, err)
+	assert.Equal(t, "Simulated Node", string(name))
+
+	// chain_getRuntimeVersion and state_getStorageAt are the aliases the itering rpc helpers use
+	var rv types.RuntimeVersion
+	assert.NoError(t, api.Client.Call(&rv, "chain_getRuntimeVersion", hash.Hex()))
+	assert.Equal(t, types.U32(1), rv.SpecVersion)
+	var raw string
+	assert.NoError(t, api.Client.Call(&raw, "state_getStorageAt", key.Hex(), hash.Hex()))
+	assert.Equal(t, "0xaa", raw)
+}
+
+func TestNode_SubmitAndWatchExtrinsic(t *testing.T) {
+	node, api := newNode(t, rpcmocksrv.NodeConfig{})
+
+	meta, err := api.State.GetMetadataLatest()
+	assert.NoError(t, err)
+	rv, err := api.State.GetRuntimeVersionLatest()
+	assert.NoError(t, err)
+	bob, err := types.NewAddressFromHexAccountID("0x8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48")
+	assert.NoError(t, err)
+	call, err := types.NewCall(meta, "Balances.transfer", bob, types.NewUCompactFromUInt(12345))
+	assert.NoError(t, err)
+
+	o := types.SignatureOptions{
+		Era:                types.ExtrinsicEra{IsMortalEra: true, AsMortalEra: types.MortalEra{First: 0x05, Second: 0}},
+		SpecVersion:        rv.SpecVersion,
+		GenesisHash:        node.GenesisHash(),
+		BlockHash:          node.GenesisHash(),
+		TransactionVersion: rv.TransactionVersion,
+	}
+	ext := types.NewExtrinsic(call)
+	assert.NoError(t, ext.Sign(signature.TestKeyringPairAlice, o))
+
+	sub, err := api.Author.SubmitAndWatchExtrinsic(ext)
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	next := func() types.ExtrinsicStatus {
+		select {
+		case status := <-sub.Chan():
+			return status
+		case <-time.After(2 * time.Second):
+			t.Fatal("no status")
+		}
+		return types.ExtrinsicStatus{}
+	}
+	assert.True(t, next().IsReady)
+	hash := node.ProduceBlock()
+	status := next()
+	assert.True(t, status.IsInBlock)
+	assert.Equal(t, hash, status.AsInBlock)
+	status = next()
+	assert.True(t, status.IsFinalized)
+	assert.Equal(t, hash, status.AsFinalized)
+
+	block, err := api.Chain.GetBlock(hash)
+	assert.NoError(t, err)
+	assert.Len(t, block.Block.Extrinsics, 1)
+	assert.Equal(t, call, block.Block.Extrinsics[0].Method)
+
+	// signed for another genesis
+	o.GenesisHash = types.NewHash([]byte{0x01})
+	o.Era = types.ExtrinsicEra{IsImmortalEra: true}
+	ext = types.NewExtrinsic(call)
+	assert.NoError(t, ext.Sign(signature.TestKeyringPairAlice, o))
+	_, err = api.Author.SubmitExtrinsic(ext)
+	assert.EqualError(t, err, "Invalid Transaction")
+	assert.Empty(t, node.Pending())
+}
+
+func TestNode_Rewind(t *testing.T) {
+	node, api := newNode(t, rpcmocksrv.NodeConfig{FinalityLag: 2})
+	key := types.NewStorageKey([]byte{0x01})
+
+	node.ProduceBlock()
+	node.ProduceBlock()
+	dropped := node.ProduceBlock()
+	assert.Equal(t, rpcmocksrv.ErrRewindFinalized, node.Rewind(0))
+	assert.NoError(t, node.Rewind(1))
+	assert.Equal(t, uint64(1), node.BestNumber())
+
+	node.SetStorage(key, []byte{0xbb})
+	node.ProduceBlock()
+	fork := node.ProduceBlock()
+	assert.NotEqual(t, dropped, fork)
+	hash, err := api.Chain.GetBlockHash(3)
+	assert.NoError(t, err)
+	assert.Equal(t, fork, hash)
+
+	// the dropped block is still known by hash
+	header, err := api.Chain.GetHeader(dropped)
+	assert.NoError(t, err)
+	assert.Equal(t, types.BlockNumber(3), header.Number)
+}
+
+func TestNode_Justify(t *testing.T) {
+	node, api := newNode(t, rpcmocksrv.NodeConfig{})
+	hash := node.ProduceBlock()
+	var blk struct {
+		Justifications [][2][]byte `json:"justifications"`
+	}
+	assert.NoError(t, api.Client.Call(&blk, "chain_getBlock", hash.Hex()))
+	assert.Nil(t, blk.Justifications)
+
+	assert.NoError(t, node.Justify(hash, []byte{0x01, 0x02}))
+	assert.Equal(t, rpcmocksrv.ErrUnknownBlock, node.Justify(types.NewHash([]byte{0x01}), nil))
+	assert.NoError(t, api.Client.Call(&blk, "chain_getBlock", hash.Hex()))
+	assert.Equal(t, [][2][]byte{{[]byte("FRNK"), {0x01, 0x02}}}, blk.Justifications)
+}
+
+func TestNode_PaymentInfo(t *testing.T) {
+	_, api := newNode(t, rpcmocksrv.NodeConfig{PaymentInfo: func(xt []byte) (uint64, uint64) {
+		return uint64(len(xt)) * 1000, 125000000
+	}})
+	var info struct {
+		Weight     uint64 `json:"weight"`
+		Class      string `json:"class"`
+		PartialFee string `json:"partialFee"`
+	}
+	assert.NoError(t, api.Client.Call(&info, "payment_queryInfo", "0x0102"))
+	assert.Equal(t, uint64(2000), info.Weight)
+	assert.Equal(t, "normal", info.Class)
+	assert.Equal(t, "125000000", info.PartialFee)
+}
@@ -0,0 +1,184 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmocksrv
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Recorder is a websocket proxy in front of a node that records all JSON-RPC traffic passing through it. Clients
+// connect to URL instead of the node, every client connection gets its own connection to the node.
+type Recorder struct {
+	// Host consists of hostname and port
+	Host string
+	// URL consists of protocol, hostname and port
+	URL string
+
+	target   string
+	listener net.Listener
+	server   *http.Server
+
+	mu        sync.Mutex
+	exchanges []*Exchange
+}
+
+// NewRecorder starts a recorder for the websocket endpoint target on a random local port
+func NewRecorder(target string) (*Recorder, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		Host:     l.Addr().String(),
+		URL:      "ws://" + l.Addr().String(),
+		target:   target,
+		listener: l,
+	}
+	r.server = &http.Server{Handler: http.HandlerFunc(r.serve)}
+	go r.server.Serve(l) //nolint:errcheck
+	return r, nil
+}
+
+// Fixture returns the exchanges recorded so far, requests still waiting for their response are left out
+func (r *Recorder) Fixture() *Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := &Fixture{Endpoint: r.target, Exchanges: make([]*Exchange, 0, len(r.exchanges))}
+	for _, ex := range r.exchanges {
+		if len(ex.Response) == 0 {
+			continue
+		}
+		cp := *ex
+		cp.Notifications = append([]json.RawMessage(nil), ex.Notifications...)
+		f.Exchanges = append(f.Exchanges, &cp)
+	}
+	return f
+}
+
+// Save writes the recorded fixture to path
+func (r *Recorder) Save(path string) error {
+	return r.Fixture().Save(path)
+}
+
+// Close stops the recorder and drops the proxied connections
+func (r *Recorder) Close() error {
+	return r.server.Close()
+}
+
+func (r *Recorder) serve(w http.ResponseWriter, req *http.Request) {
+	upstream, _, err := websocket.DefaultDialer.Dial(r.target, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer upstream.Close()
+
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	rc := &recordedConn{recorder: r, pending: make(map[string]*Exchange), subs: make(map[string]*Exchange)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rc.relay(upstream, conn, rc.response)
+	}()
+	rc.relay(conn, upstream, rc.request)
+	_ = upstream.Close()
+	<-done
+}
+
+// recordedConn matches the messages of one proxied connection
+type recordedConn struct {
+	recorder *Recorder
+
+	mu sync.Mutex
+	// pending requests by id, subscriptions by subscription id
+	pending map[string]*Exchange
+	subs    map[string]*Exchange
+}
+
+// relay copies frames from src to dst until one of them fails, every message is passed to record first
+func (rc *recordedConn) relay(src, dst *websocket.Conn, record func(msg *jsonrpcMessage, raw json.RawMessage)) {
+	for {
+		typ, frame, err := src.ReadMessage()
+		if err != nil {
+			_ = dst.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		}
+		if msgs, _, err := splitMessages(frame); err == nil {
+			for _, raw := range msgs {
+				msg := &jsonrpcMessage{}
+				if json.Unmarshal(raw, msg) == nil {
+					record(msg, raw)
+				}
+			}
+		}
+		if err := dst.WriteMessage(typ, frame); err != nil {
+			return
+		}
+	}
+}
+
+func (rc *recordedConn) request(msg *jsonrpcMessage, _ json.RawMessage) {
+	if !msg.isRequest() {
+		return
+	}
+	ex := &Exchange{Method: msg.Method, Params: msg.Params}
+
+	rc.recorder.mu.Lock()
+	rc.recorder.exchanges = append(rc.recorder.exchanges, ex)
+	rc.recorder.mu.Unlock()
+
+	rc.mu.Lock()
+	rc.pending[string(msg.ID)] = ex
+	rc.mu.Unlock()
+}
+
+func (rc *recordedConn) response(msg *jsonrpcMessage, raw json.RawMessage) {
+	rc.recorder.mu.Lock()
+	defer rc.recorder.mu.Unlock()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if msg.isNotification() {
+		if ex, ok := rc.subs[msg.subscriptionId()]; ok {
+			ex.Notifications = append(ex.Notifications, raw)
+		}
+		return
+	}
+
+	ex, ok := rc.pending[string(msg.ID)]
+	if !ok {
+		return
+	}
+	delete(rc.pending, string(msg.ID))
+	ex.Response = raw
+	// a string or number result may be a subscription id, the notifications referring to it are attached to ex
+	if len(msg.Result) > 0 && (msg.Result[0] == '"' || msg.Result[0] >= '0' && msg.Result[0] <= '9') {
+		rc.subs[string(msg.Result)] = ex
+	}
+}
@@ -0,0 +1,277 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmocksrv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// ExtrinsicMethods are the methods whose first param is an extrinsic. A signed extrinsic carries a fresh signature
+// every run, so it is matched with its signature zeroed: the signer, era, nonce, tip and call data must still be the
+// recorded ones.
+var ExtrinsicMethods = []string{
+	"author_submitExtrinsic",
+	"author_submitAndWatchExtrinsic",
+	"payment_queryInfo",
+}
+
+// ReplayServer serves a recorded fixture. A request is answered with the recorded response of the same method and
+// params, identical requests get the recorded responses in order and the last one once they are used up. The
+// notifications of a subscription follow right after its response.
+type ReplayServer struct {
+	// Host consists of hostname and port
+	Host string
+	// URL consists of protocol, hostname and port
+	URL string
+
+	server       *http.Server
+	ignoreParams map[string]bool
+	extrinsics   map[string]bool
+
+	mu        sync.Mutex
+	exchanges map[string][]*Exchange
+	served    map[string]int
+	misses    []string
+}
+
+// NewReplay starts a server for the fixture on a random local port. The params of ignoreParams are not matched.
+func NewReplay(f *Fixture, ignoreParams ...string) (*ReplayServer, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &ReplayServer{
+		Host:         l.Addr().String(),
+		URL:          "ws://" + l.Addr().String(),
+		ignoreParams: make(map[string]bool),
+		extrinsics:   make(map[string]bool),
+		exchanges:    make(map[string][]*Exchange),
+		served:       make(map[string]int),
+	}
+	for _, m := range ignoreParams {
+		s.ignoreParams[m] = true
+	}
+	for _, m := range ExtrinsicMethods {
+		s.extrinsics[m] = true
+	}
+	for _, ex := range f.Exchanges {
+		key := s.key(ex.Method, ex.Params)
+		s.exchanges[key] = append(s.exchanges[key], ex)
+	}
+
+	s.server = &http.Server{Handler: http.HandlerFunc(s.serve)}
+	go s.server.Serve(l) //nolint:errcheck
+	return s, nil
+}
+
+// NewReplayFromFile loads the fixture at path and serves it
+func NewReplayFromFile(path string, ignoreParams ...string) (*ReplayServer, error) {
+	f, err := LoadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplay(f, ignoreParams...)
+}
+
+// Misses returns the requests that had no recorded response, as method and params
+func (s *ReplayServer) Misses() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.misses...)
+}
+
+// Close stops the server and drops the connections
+func (s *ReplayServer) Close() error {
+	return s.server.Close()
+}
+
+func (s *ReplayServer) key(method string, params json.RawMessage) string {
+	if s.ignoreParams[method] {
+		return method
+	}
+	if s.extrinsics[method] {
+		params = unsignedParams(params)
+	}
+	return method + " " + compactParams(params)
+}
+
+// unsignedParams returns params with the signature of the extrinsic in the first param zeroed
+func unsignedParams(params json.RawMessage) json.RawMessage {
+	var ps []json.RawMessage
+	if err := json.Unmarshal(params, &ps); err != nil || len(ps) == 0 {
+		return params
+	}
+	var xt string
+	if err := json.Unmarshal(ps[0], &xt); err != nil {
+		return params
+	}
+	bz, err := types.HexDecodeString(xt)
+	if err != nil {
+		return params
+	}
+	ps[0], _ = json.Marshal(types.HexEncodeToString(zeroSignature(bz)))
+	out, err := json.Marshal(ps)
+	if err != nil {
+		return params
+	}
+	return out
+}
+
+// zeroSignature returns the signed extrinsic xt with a zero signature of the same scheme, other extrinsics as they
+// are. The signer is an AccountId behind 0xff or a MultiAddress::Id behind 0x00.
+func zeroSignature(xt []byte) []byte {
+	var length types.UCompact
+	if err := types.DecodeFromBytes(xt, &length); err != nil {
+		return xt
+	}
+	prefix, err := types.EncodeToBytes(length)
+	if err != nil || len(xt) < len(prefix)+2 || xt[len(prefix)]&types.ExtrinsicBitSigned == 0 {
+		return xt
+	}
+
+	var out []byte
+	switch xt[len(prefix)+1] {
+	case 0xff:
+		ext := types.Extrinsic{}
+		if err := types.DecodeFromBytes(xt, &ext); err != nil {
+			return xt
+		}
+		ext.Signature.Signature = zeroMultiSignature(ext.Signature.Signature)
+		out, err = types.EncodeToBytes(ext)
+	case 0x00:
+		ext := types.ExtrinsicMulti{}
+		if err := types.DecodeFromBytes(xt, &ext); err != nil {
+			return xt
+		}
+		ext.Signature.Signature = zeroMultiSignature(ext.Signature.Signature)
+		out, err = types.EncodeToBytes(ext)
+	default:
+		return xt
+	}
+	if err != nil {
+		return xt
+	}
+	return out
+}
+
+func zeroMultiSignature(sig types.MultiSignature) types.MultiSignature {
+	zero := types.MultiSignature{IsEd25519: sig.IsEd25519, IsSr25519: sig.IsSr25519, IsEcdsa: sig.IsEcdsa}
+	if sig.IsEcdsa {
+		zero.AsEcdsa = make(types.Bytes, len(sig.AsEcdsa))
+	}
+	return zero
+}
+
+// next returns the recorded exchange for a request, nil if there is none
+func (s *ReplayServer) next(msg *jsonrpcMessage) *Exchange {
+	key := s.key(msg.Method, msg.Params)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exs := s.exchanges[key]
+	if len(exs) == 0 {
+		s.misses = append(s.misses, key)
+		return nil
+	}
+	i := s.served[key]
+	if i >= len(exs) {
+		i = len(exs) - 1
+	}
+	s.served[key]++
+	return exs[i]
+}
+
+func (s *ReplayServer) serve(w http.ResponseWriter, req *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		typ, frame, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		msgs, batch, err := splitMessages(frame)
+		if err != nil {
+			return
+		}
+
+		responses := make([]json.RawMessage, 0, len(msgs))
+		var notifications []json.RawMessage
+		for _, raw := range msgs {
+			msg := &jsonrpcMessage{}
+			if err := json.Unmarshal(raw, msg); err != nil || !msg.isRequest() {
+				continue
+			}
+			resp, ns := s.answer(msg)
+			responses = append(responses, resp)
+			notifications = append(notifications, ns...)
+		}
+
+		if batch {
+			out, _ := json.Marshal(responses)
+			err = conn.WriteMessage(typ, out)
+		} else if len(responses) == 1 {
+			err = conn.WriteMessage(typ, responses[0])
+		}
+		if err != nil {
+			return
+		}
+		for _, n := range notifications {
+			if err := conn.WriteMessage(typ, n); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// answer returns the recorded response with the id of msg and the notifications that follow it
+func (s *ReplayServer) answer(msg *jsonrpcMessage) (json.RawMessage, []json.RawMessage) {
+	ex := s.next(msg)
+	if ex == nil {
+		resp, _ := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      msg.ID,
+			"error": map[string]interface{}{
+				"code":    -32601,
+				"message": fmt.Sprintf("rpcmocksrv: no recorded response for %s %s", msg.Method, msg.Params),
+			},
+		})
+		return resp, nil
+	}
+
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(ex.Response, &resp); err != nil {
+		return ex.Response, ex.Notifications
+	}
+	resp["id"] = msg.ID
+	out, err := json.Marshal(resp)
+	if err != nil {
+		return ex.Response, ex.Notifications
+	}
+	return out, ex.Notifications
+}
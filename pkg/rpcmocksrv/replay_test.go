@@ -0,0 +1,180 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmocksrv_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	gethrpc "github.com/stafiprotocol/go-substrate-rpc-client/pkg/gethrpc"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/signature"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// startNode starts a websocket node that answers system_name and sends two heads for chain_subscribeNewHeads
+func startNode(t *testing.T) string {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			var req struct {
+				ID     json.RawMessage `json:"id"`
+				Method string          `json:"method"`
+			}
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			reply := func(result string) {
+				_ = ws.WriteMessage(websocket.TextMessage,
+					[]byte(`{"jsonrpc":"2.0","id":`+string(req.ID)+`,"result":`+result+`}`))
+			}
+			switch req.Method {
+			case "system_name":
+				reply(`"stafi"`)
+			case "chain_subscribeNewHeads":
+				reply(`"sub1"`)
+				for _, n := range []string{"0x1", "0x2"} {
+					_ = ws.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"chain_newHead",`+
+						`"params":{"subscription":"sub1","result":{"number":"`+n+`"}}}`))
+				}
+			case "chain_unsubscribeNewHeads":
+				reply(`true`)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// run does the same calls against url every time
+func run(t *testing.T, url string) (string, []json.RawMessage) {
+	c, err := gethrpc.Dial(url)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var name string
+	assert.NoError(t, c.Call(&name, "system_name"))
+
+	ch := make(chan json.RawMessage)
+	sub, err := c.Subscribe(context.Background(), "chain", "subscribeNewHeads", "unsubscribeNewHeads", "newHead", ch)
+	assert.NoError(t, err)
+	heads := make([]json.RawMessage, 0, 2)
+	for len(heads) < 2 {
+		select {
+		case h := <-ch:
+			heads = append(heads, h)
+		case <-time.After(2 * time.Second):
+			t.Fatal("no head")
+		}
+	}
+	sub.Unsubscribe()
+	return name, heads
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	rec, err := rpcmocksrv.NewRecorder(startNode(t))
+	assert.NoError(t, err)
+	defer rec.Close()
+
+	name, heads := run(t, rec.URL)
+	assert.Equal(t, "stafi", name)
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	assert.NoError(t, rec.Save(path))
+	f, err := rpcmocksrv.LoadFixture(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "chain_subscribeNewHeads", f.Exchanges[1].Method)
+	assert.Len(t, f.Exchanges[1].Notifications, 2)
+
+	replay, err := rpcmocksrv.NewReplayFromFile(path)
+	assert.NoError(t, err)
+	defer replay.Close()
+
+	replayedName, replayedHeads := run(t, replay.URL)
+	assert.Equal(t, name, replayedName)
+	assert.Equal(t, heads, replayedHeads)
+	assert.Empty(t, replay.Misses())
+
+	c, err := gethrpc.Dial(replay.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.Error(t, c.Call(nil, "system_health"))
+	assert.Equal(t, []string{"system_health []"}, replay.Misses())
+}
+
+// signedTransfer returns a transfer of value to Bob signed by Alice, every call gives a different signature
+func signedTransfer(t *testing.T, value uint64) string {
+	meta := types.NewMetadataV13()
+	assert.NoError(t, types.DecodeFromBytes(types.MustHexDecodeString(types.ExamplaryMetadataV13SubstrateString), meta))
+	bob, err := types.NewAddressFromHexAccountID("0x8eaf04151687736326c9fea17e25fc5287613693c912909cb226aa4794f26a48")
+	assert.NoError(t, err)
+	call, err := types.NewCall(meta, "Balances.transfer", bob, types.NewUCompactFromUInt(value))
+	assert.NoError(t, err)
+
+	ext := types.NewExtrinsic(call)
+	assert.NoError(t, ext.Sign(signature.TestKeyringPairAlice, types.SignatureOptions{
+		Nonce:              types.NewUCompactFromUInt(1),
+		Tip:                types.NewUCompactFromUInt(0),
+		SpecVersion:        1,
+		TransactionVersion: 1,
+	}))
+	xt, err := types.EncodeToHexString(ext)
+	assert.NoError(t, err)
+	return xt
+}
+
+func TestReplay_SignedExtrinsic(t *testing.T) {
+	recorded := signedTransfer(t, 100)
+	params, err := json.Marshal([]string{recorded})
+	assert.NoError(t, err)
+	replay, err := rpcmocksrv.NewReplay(&rpcmocksrv.Fixture{Exchanges: []*rpcmocksrv.Exchange{{
+		Method:   "author_submitExtrinsic",
+		Params:   params,
+		Response: json.RawMessage(`{"jsonrpc":"2.0","id":1,"result":"0x01"}`),
+	}}})
+	assert.NoError(t, err)
+	defer replay.Close()
+
+	c, err := gethrpc.Dial(replay.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// signed again, only the signature differs
+	resigned := signedTransfer(t, 100)
+	assert.NotEqual(t, recorded, resigned)
+	var hash string
+	assert.NoError(t, c.Call(&hash, "author_submitExtrinsic", resigned))
+	assert.Equal(t, "0x01", hash)
+	assert.Empty(t, replay.Misses())
+
+	// another call data
+	assert.Error(t, c.Call(&hash, "author_submitExtrinsic", signedTransfer(t, 101)))
+	assert.Len(t, replay.Misses(), 1)
+}
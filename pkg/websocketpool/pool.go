@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/sirupsen/logrus"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/instrument"
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/recws"
 )
 
@@ -24,11 +25,17 @@ type WsPool struct {
 	exist   map[*PoolConn]bool
 	conns   chan *PoolConn
 	factory Factory
+	hooks   instrument.Hooks
 }
 
 type Factory func() (*PoolConn, error)
 
 func NewWsPool(initialCap, maxCap int, factory Factory) (Pool, error) {
+	return NewWsPoolWithHooks(initialCap, maxCap, factory, instrument.Hooks{})
+}
+
+// NewWsPoolWithHooks is NewWsPool reporting every Get and Put to hooks
+func NewWsPoolWithHooks(initialCap, maxCap int, factory Factory, hooks instrument.Hooks) (Pool, error) {
 	if initialCap < 0 || maxCap <= 0 || initialCap > maxCap {
 		return nil, errors.New("invalid capacity settings")
 	}
@@ -36,6 +43,7 @@ func NewWsPool(initialCap, maxCap int, factory Factory) (Pool, error) {
 		conns:   make(chan *PoolConn, maxCap),
 		exist:   map[*PoolConn]bool{},
 		factory: factory,
+		hooks:   hooks,
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -75,19 +83,24 @@ func (c *WsPool) Get() (*PoolConn, error) {
 			logrus.Trace("use factory reconnect")
 			conn, err = factory()
 			if err != nil {
+				c.hooks.Pool(instrument.PoolFactoryError, len(conns))
 				return nil, err
 			}
+			c.hooks.Pool(instrument.PoolRedial, len(conns))
 		} else {
 			c.mu.Lock()
 			delete(c.exist, conn)
 			c.mu.Unlock()
+			c.hooks.Pool(instrument.PoolHit, len(conns))
 		}
 		return conn, nil
 	default:
 		conn, err := factory()
 		if err != nil {
+			c.hooks.Pool(instrument.PoolFactoryError, len(conns))
 			return nil, err
 		}
+		c.hooks.Pool(instrument.PoolMiss, len(conns))
 		return conn, nil
 	}
 }
@@ -104,6 +117,7 @@ func (c *WsPool) Put(conn *PoolConn) error {
 		if conn.Conn != nil {
 			conn.Conn.Close()
 		}
+		c.hooks.Pool(instrument.PoolDiscard, len(c.conns))
 		return nil
 	}
 
@@ -124,9 +138,11 @@ func (c *WsPool) Put(conn *PoolConn) error {
 		c.mu.Lock()
 		c.exist[conn] = true
 		c.mu.Unlock()
+		c.hooks.Pool(instrument.PoolPut, len(c.conns))
 		return nil
 	default:
 		conn.Conn.Close()
+		c.hooks.Pool(instrument.PoolDiscard, len(c.conns))
 		return nil
 	}
 }
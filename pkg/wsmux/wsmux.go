@@ -0,0 +1,381 @@
+// Package wsmux provides a json rpc websocket client that multiplexes many requests over one connection.
+// Every request gets a unique id and its response is routed back to the waiting caller by that id.
+package wsmux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/instrument"
+)
+
+var (
+	ErrClosed = errors.New("wsmux: client is closed")
+	// ErrNotConnected is returned when a connection could not be dialed
+	ErrNotConnected = errors.New("wsmux: not connected")
+	// ErrConnectionLost is returned for requests that were in flight when the connection dropped, they may or may not
+	// have been executed by the server
+	ErrConnectionLost = errors.New("wsmux: connection lost before response")
+	ErrTimeout        = error(timeoutError{})
+)
+
+// timeoutError is the type of ErrTimeout, it is a timeout in the sense of net.Error
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "wsmux: request timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+const (
+	defaultHandshakeTimeout = 10 * time.Second
+	defaultRequestTimeout   = 30 * time.Second
+	defaultKeepAliveTimeout = 2 * time.Minute
+	pingWriteTimeout        = 10 * time.Second
+)
+
+type Config struct {
+	// HandshakeTimeout bounds dialing a new connection, default to 10 seconds
+	HandshakeTimeout time.Duration
+	// RequestTimeout is the default time to wait for a response, default to 30 seconds
+	RequestTimeout time.Duration
+	// KeepAliveTimeout is how long a connection may stay silent before it is dropped, a ping is sent every half of
+	// it. Default to 2 minutes
+	KeepAliveTimeout time.Duration
+	// Header is sent with the websocket handshake
+	Header http.Header
+	// OnUnmatched is called with messages that have no waiting request, like subscription notifications and
+	// responses that arrived after their request timed out
+	OnUnmatched func(msg []byte)
+	// Hooks receives the metrics and spans of the requests and dials
+	Hooks instrument.Hooks
+}
+
+// Client sends json rpc requests over a shared websocket connection. The connection is dialed on the first request
+// and dialed again on the next request after it dropped
+type Client struct {
+	url    string
+	cfg    Config
+	dialer *websocket.Dialer
+	nextId uint64
+
+	mu      sync.Mutex
+	conn    *muxConn
+	dialing chan struct{}
+	closed  bool
+}
+
+// muxConn is one websocket connection with the requests waiting on it
+type muxConn struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+	done    chan struct{}
+
+	mu      sync.Mutex
+	pending map[uint64]chan []byte
+	err     error
+}
+
+func NewClient(url string, cfg Config) *Client {
+	if cfg.HandshakeTimeout <= 0 {
+		cfg.HandshakeTimeout = defaultHandshakeTimeout
+	}
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = defaultRequestTimeout
+	}
+	if cfg.KeepAliveTimeout <= 0 {
+		cfg.KeepAliveTimeout = defaultKeepAliveTimeout
+	}
+	return &Client{
+		url: url,
+		cfg: cfg,
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: cfg.HandshakeTimeout,
+		},
+	}
+}
+
+// URL returns the endpoint of the client
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Send sends a json rpc request and decodes the whole response message into result. The id of the request is
+// replaced by a unique one, so requests built with a constant id can be sent concurrently
+func (c *Client) Send(request []byte, result interface{}) error {
+	return c.SendWithTimeout(request, result, c.cfg.RequestTimeout)
+}
+
+func (c *Client) SendWithTimeout(request []byte, result interface{}, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return c.SendContext(ctx, request, result)
+}
+
+// SendContext is Send bounded by ctx instead of the default timeout, a ctx without deadline still gets the default
+// timeout. ErrTimeout is returned when the deadline passes, ctx.Err() when ctx is canceled. The other requests on the
+// connection keep waiting, a dead connection is detected by the keep alive instead
+func (c *Client) SendContext(ctx context.Context, request []byte, result interface{}) (err error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.RequestTimeout)
+		defer cancel()
+	}
+
+	id := atomic.AddUint64(&c.nextId, 1)
+	msg, method, err := withId(request, id)
+	if err != nil {
+		return err
+	}
+	ctx, end := c.cfg.Hooks.StartCall(ctx, c.url, method)
+	defer func() { end(err) }()
+
+	conn, err := c.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	ch, err := conn.register(id)
+	if err != nil {
+		return err
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.write(msg, deadline); err != nil {
+		conn.unregister(id)
+		c.dropConn(conn, err)
+		return fmt.Errorf("%w: %s", ErrConnectionLost, err)
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrConnectionLost, conn.getErr())
+		}
+		return json.Unmarshal(resp, result)
+	case <-ctx.Done():
+		conn.unregister(id)
+		if ctx.Err() == context.DeadlineExceeded {
+			return ErrTimeout
+		}
+		return ctx.Err()
+	}
+}
+
+// InFlight returns the number of requests waiting for a response
+func (c *Client) InFlight() int {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return 0
+	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return len(conn.pending)
+}
+
+// Close closes the connection, waiting requests fail with ErrConnectionLost
+func (c *Client) Close() {
+	c.mu.Lock()
+	c.closed = true
+	conn := c.conn
+	c.conn = nil
+	c.mu.Unlock()
+
+	if conn != nil {
+		conn.close(ErrClosed)
+	}
+}
+
+// getConn returns the connection, dialing it if there is none. Only one dial runs at a time and it runs without
+// holding c.mu, other callers wait for it
+func (c *Client) getConn(ctx context.Context) (*muxConn, error) {
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return nil, ErrClosed
+		}
+		if c.conn != nil {
+			conn := c.conn
+			c.mu.Unlock()
+			return conn, nil
+		}
+		if c.dialing == nil {
+			break
+		}
+		dialing := c.dialing
+		c.mu.Unlock()
+
+		select {
+		case <-dialing:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %s", ErrNotConnected, ctx.Err())
+		}
+	}
+	dialing := make(chan struct{})
+	c.dialing = dialing
+	c.mu.Unlock()
+
+	ws, _, err := c.dialer.DialContext(ctx, c.url, c.cfg.Header)
+	c.cfg.Hooks.Reconnect(c.url, err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dialing = nil
+	close(dialing)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotConnected, err)
+	}
+	if c.closed {
+		_ = ws.Close()
+		return nil, ErrClosed
+	}
+	conn := &muxConn{ws: ws, done: make(chan struct{}), pending: make(map[uint64]chan []byte)}
+	c.conn = conn
+	go c.readLoop(conn)
+	go c.pingLoop(conn)
+	return conn, nil
+}
+
+// dropConn closes conn and fails its waiting requests, the next request dials a new connection
+func (c *Client) dropConn(conn *muxConn, err error) {
+	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+	}
+	c.mu.Unlock()
+	conn.close(err)
+}
+
+// readLoop routes the responses of conn. Every message and pong extends the read deadline by KeepAliveTimeout, so a
+// connection that stays silent is dropped
+func (c *Client) readLoop(conn *muxConn) {
+	extend := func() { _ = conn.ws.SetReadDeadline(time.Now().Add(c.cfg.KeepAliveTimeout)) }
+	conn.ws.SetPongHandler(func(string) error {
+		extend()
+		return nil
+	})
+	for {
+		extend()
+		_, msg, err := conn.ws.ReadMessage()
+		if err != nil {
+			logrus.Tracef("wsmux read error: %s", err)
+			c.dropConn(conn, err)
+			return
+		}
+
+		var head struct {
+			Id *uint64 `json:"id"`
+		}
+		if err := json.Unmarshal(msg, &head); err != nil || head.Id == nil || !conn.deliver(*head.Id, msg) {
+			if c.cfg.OnUnmatched != nil {
+				c.cfg.OnUnmatched(msg)
+			}
+		}
+	}
+}
+
+// pingLoop pings the server every half of KeepAliveTimeout until conn is closed
+func (c *Client) pingLoop(conn *muxConn) {
+	ticker := time.NewTicker(c.cfg.KeepAliveTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := conn.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(pingWriteTimeout))
+			if err != nil {
+				logrus.Tracef("wsmux ping error: %s", err)
+				c.dropConn(conn, err)
+				return
+			}
+		case <-conn.done:
+			return
+		}
+	}
+}
+
+func (m *muxConn) register(id uint64) (chan []byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pending == nil {
+		return nil, fmt.Errorf("%w: %s", ErrConnectionLost, m.err)
+	}
+	ch := make(chan []byte, 1)
+	m.pending[id] = ch
+	return ch, nil
+}
+
+func (m *muxConn) unregister(id uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, id)
+}
+
+func (m *muxConn) deliver(id uint64, msg []byte) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch, ok := m.pending[id]
+	if !ok {
+		return false
+	}
+	delete(m.pending, id)
+	ch <- msg
+	return true
+}
+
+// write writes msg as one frame, a write that does not finish by deadline breaks the connection
+func (m *muxConn) write(msg []byte, deadline time.Time) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	if err := m.ws.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	return m.ws.WriteMessage(websocket.TextMessage, msg)
+}
+
+func (m *muxConn) getErr() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// close closes the websocket once and wakes up all waiting requests by closing their channels
+func (m *muxConn) close(err error) {
+	m.mu.Lock()
+	if m.pending == nil {
+		m.mu.Unlock()
+		return
+	}
+	pending := m.pending
+	m.pending = nil
+	m.err = err
+	m.mu.Unlock()
+
+	for _, ch := range pending {
+		close(ch)
+	}
+	close(m.done)
+	_ = m.ws.Close()
+}
+
+// withId replaces the id of a json rpc request, it also returns the method of the request
+func withId(request []byte, id uint64) ([]byte, string, error) {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(request, &req); err != nil {
+		return nil, "", fmt.Errorf("wsmux: invalid request: %s", err)
+	}
+	var method string
+	_ = json.Unmarshal(req["method"], &method)
+	req["id"] = json.RawMessage(fmt.Sprintf("%d", id))
+	msg, err := json.Marshal(req)
+	return msg, method, err
+}
@@ -0,0 +1,288 @@
+package wsmux_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/wsmux"
+	"github.com/stretchr/testify/assert"
+)
+
+type request struct {
+	Id     uint64        `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+type response struct {
+	Id     uint64 `json:"id"`
+	Result string `json:"result"`
+}
+
+// newServer starts a websocket server that hands every connection to serve
+func newServer(t *testing.T, serve func(ws *websocket.Conn)) string {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		serve(ws)
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestSendOutOfOrder(t *testing.T) {
+	const n = 8
+	url := newServer(t, func(ws *websocket.Conn) {
+		reqs := make([]request, 0, n)
+		for len(reqs) < n {
+			var req request
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			reqs = append(reqs, req)
+		}
+		// a notification and the responses in reverse order
+		_ = ws.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"chain_newHead","params":{}}`))
+		for i := len(reqs) - 1; i >= 0; i-- {
+			_ = ws.WriteJSON(response{Id: reqs[i].Id, Result: reqs[i].Params[0].(string)})
+		}
+		_, _, _ = ws.ReadMessage()
+	})
+
+	unmatched := make(chan []byte, 1)
+	c := wsmux.NewClient(url, wsmux.Config{OnUnmatched: func(msg []byte) { unmatched <- msg }})
+	defer c.Close()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			param := string(rune('a' + i))
+			// every request is built with the same id
+			req, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "echo", "params": []string{param}})
+			var res response
+			err := c.Send(req, &res)
+			assert.NoError(t, err)
+			assert.Equal(t, param, res.Result)
+		}(i)
+	}
+	wg.Wait()
+
+	select {
+	case msg := <-unmatched:
+		assert.Contains(t, string(msg), "chain_newHead")
+	case <-time.After(time.Second):
+		t.Fatal("notification not passed to OnUnmatched")
+	}
+	assert.Equal(t, 0, c.InFlight())
+}
+
+func TestSendTimeout(t *testing.T) {
+	url := newServer(t, func(ws *websocket.Conn) {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	c := wsmux.NewClient(url, wsmux.Config{})
+	defer c.Close()
+
+	var res response
+	err := c.SendWithTimeout([]byte(`{"jsonrpc":"2.0","id":1,"method":"echo","params":[]}`), &res, 50*time.Millisecond)
+	assert.Equal(t, wsmux.ErrTimeout, err)
+	assert.Equal(t, 0, c.InFlight())
+}
+
+func TestSendConnectionLost(t *testing.T) {
+	conns := 0
+	mu := sync.Mutex{}
+	url := newServer(t, func(ws *websocket.Conn) {
+		mu.Lock()
+		conns++
+		first := conns == 1
+		mu.Unlock()
+
+		var req request
+		if err := ws.ReadJSON(&req); err != nil {
+			return
+		}
+		if first {
+			// drop the connection with the request in flight
+			return
+		}
+		_ = ws.WriteJSON(response{Id: req.Id, Result: "ok"})
+		_, _, _ = ws.ReadMessage()
+	})
+
+	c := wsmux.NewClient(url, wsmux.Config{})
+	defer c.Close()
+
+	req := []byte(`{"jsonrpc":"2.0","id":1,"method":"echo","params":[]}`)
+	var res response
+	err := c.Send(req, &res)
+	assert.True(t, errors.Is(err, wsmux.ErrConnectionLost), err)
+
+	// the next request dials a new connection
+	err = c.Send(req, &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Result)
+}
+
+func TestSendTimeoutKeepsConnection(t *testing.T) {
+	conns := 0
+	mu := sync.Mutex{}
+	url := newServer(t, func(ws *websocket.Conn) {
+		mu.Lock()
+		conns++
+		mu.Unlock()
+
+		// slow requests are never answered
+		for {
+			var req request
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			if req.Method != "slow" {
+				_ = ws.WriteJSON(response{Id: req.Id, Result: "ok"})
+			}
+		}
+	})
+
+	c := wsmux.NewClient(url, wsmux.Config{})
+	defer c.Close()
+
+	var res response
+	slow := make(chan error, 1)
+	go func() {
+		var res response
+		slow <- c.SendWithTimeout([]byte(`{"jsonrpc":"2.0","id":1,"method":"slow","params":[]}`), &res, time.Second)
+	}()
+	req := []byte(`{"jsonrpc":"2.0","id":1,"method":"echo","params":[]}`)
+	assert.NoError(t, c.Send(req, &res))
+	err := c.SendWithTimeout([]byte(`{"jsonrpc":"2.0","id":1,"method":"slow","params":[]}`), &res, 50*time.Millisecond)
+	assert.Equal(t, wsmux.ErrTimeout, err)
+
+	// the timeout only ended its own request
+	assert.Equal(t, 1, c.InFlight())
+	assert.NoError(t, c.Send(req, &res))
+	assert.Equal(t, "ok", res.Result)
+	assert.Equal(t, wsmux.ErrTimeout, <-slow)
+	mu.Lock()
+	assert.Equal(t, 1, conns)
+	mu.Unlock()
+}
+
+func TestKeepAlive(t *testing.T) {
+	conns := 0
+	mu := sync.Mutex{}
+	url := newServer(t, func(ws *websocket.Conn) {
+		mu.Lock()
+		conns++
+		mu.Unlock()
+
+		// reading answers the pings of the client
+		for {
+			var req request
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			_ = ws.WriteJSON(response{Id: req.Id, Result: "ok"})
+		}
+	})
+
+	c := wsmux.NewClient(url, wsmux.Config{KeepAliveTimeout: 100 * time.Millisecond})
+	defer c.Close()
+
+	req := []byte(`{"jsonrpc":"2.0","id":1,"method":"echo","params":[]}`)
+	var res response
+	assert.NoError(t, c.Send(req, &res))
+	time.Sleep(300 * time.Millisecond)
+	assert.NoError(t, c.Send(req, &res))
+
+	// the silent but answering connection was kept
+	mu.Lock()
+	assert.Equal(t, 1, conns)
+	mu.Unlock()
+}
+
+func TestKeepAliveTimeout(t *testing.T) {
+	conns := 0
+	mu := sync.Mutex{}
+	url := newServer(t, func(ws *websocket.Conn) {
+		mu.Lock()
+		conns++
+		first := conns == 1
+		mu.Unlock()
+
+		if first {
+			// a server that stops reading never answers pings
+			time.Sleep(time.Second)
+			return
+		}
+		var req request
+		if err := ws.ReadJSON(&req); err != nil {
+			return
+		}
+		_ = ws.WriteJSON(response{Id: req.Id, Result: "ok"})
+		_, _, _ = ws.ReadMessage()
+	})
+
+	c := wsmux.NewClient(url, wsmux.Config{KeepAliveTimeout: 100 * time.Millisecond})
+	defer c.Close()
+
+	req := []byte(`{"jsonrpc":"2.0","id":1,"method":"echo","params":[]}`)
+	var res response
+	err := c.SendWithTimeout(req, &res, time.Second)
+	assert.True(t, errors.Is(err, wsmux.ErrConnectionLost), err)
+
+	err = c.Send(req, &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Result)
+}
+
+func TestCloseWhileDialing(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := wsmux.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), wsmux.Config{HandshakeTimeout: 5 * time.Second})
+	errs := make(chan error, 1)
+	go func() {
+		var res response
+		errs <- c.Send([]byte(`{"jsonrpc":"2.0","id":1,"method":"echo","params":[]}`), &res)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// the dial does not hold the client lock
+	closed := make(chan struct{})
+	go func() {
+		assert.Equal(t, 0, c.InFlight())
+		c.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked by the dial")
+	}
+
+	release <- struct{}{}
+	assert.Error(t, <-errs)
+}
@@ -17,13 +17,20 @@
 package author
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // PendingExtrinsics returns all pending extrinsics, potentially grouped by sender
 func (a *Author) PendingExtrinsics() ([]types.Extrinsic, error) {
+	return a.PendingExtrinsicsContext(context.Background())
+}
+
+// PendingExtrinsicsContext is PendingExtrinsics with a context
+func (a *Author) PendingExtrinsicsContext(ctx context.Context) ([]types.Extrinsic, error) {
 	var res []string
-	err := a.client.Call(&res, "author_pendingExtrinsics")
+	err := a.client.CallContext(ctx, &res, "author_pendingExtrinsics")
 	if err != nil {
 		return nil, err
 	}
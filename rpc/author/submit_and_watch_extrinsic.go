@@ -20,7 +20,6 @@ import (
 	"context"
 	"sync"
 
-	"github.com/stafiprotocol/go-substrate-rpc-client/config"
 	gethrpc "github.com/stafiprotocol/go-substrate-rpc-client/pkg/gethrpc"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
@@ -63,9 +62,14 @@ func (s *ExtrinsicStatusSubscription) Unsubscribe() {
 // SubmitAndWatchExtrinsic will submit and subscribe to watch an extrinsic until unsubscribed, returning a subscription
 // that will receive server notifications containing the extrinsic status updates.
 func (a *Author) SubmitAndWatchExtrinsic(xt types.Extrinsic) (*ExtrinsicStatusSubscription, error) { //nolint:lll
-	ctx, cancel := context.WithTimeout(context.Background(), config.Default().SubscribeTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), a.client.Config().SubscribeTimeout)
 	defer cancel()
 
+	return a.SubmitAndWatchExtrinsicContext(ctx, xt)
+}
+
+// SubmitAndWatchExtrinsicContext is SubmitAndWatchExtrinsic with a context
+func (a *Author) SubmitAndWatchExtrinsicContext(ctx context.Context, xt types.Extrinsic) (*ExtrinsicStatusSubscription, error) { //nolint:lll
 	c := make(chan types.ExtrinsicStatus)
 
 	enc, err := types.EncodeToHexString(xt)
@@ -83,9 +87,14 @@ func (a *Author) SubmitAndWatchExtrinsic(xt types.Extrinsic) (*ExtrinsicStatusSu
 }
 
 func (a *Author) SubmitAndWatch(xt interface{}) (*ExtrinsicStatusSubscription, error) { //nolint:lll
-	ctx, cancel := context.WithTimeout(context.Background(), config.Default().SubscribeTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), a.client.Config().SubscribeTimeout)
 	defer cancel()
 
+	return a.SubmitAndWatchContext(ctx, xt)
+}
+
+// SubmitAndWatchContext is SubmitAndWatch with a context
+func (a *Author) SubmitAndWatchContext(ctx context.Context, xt interface{}) (*ExtrinsicStatusSubscription, error) { //nolint:lll
 	c := make(chan types.ExtrinsicStatus)
 
 	enc, err := types.EncodeToHexString(xt)
@@ -16,17 +16,26 @@
 
 package author
 
-import "github.com/stafiprotocol/go-substrate-rpc-client/types"
+import (
+	"context"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
 
 // SubmitExtrinsic will submit a fully formatted extrinsic for block inclusion
 func (a *Author) SubmitExtrinsic(xt types.Extrinsic) (types.Hash, error) {
+	return a.SubmitExtrinsicContext(context.Background(), xt)
+}
+
+// SubmitExtrinsicContext is SubmitExtrinsic with a context
+func (a *Author) SubmitExtrinsicContext(ctx context.Context, xt types.Extrinsic) (types.Hash, error) {
 	enc, err := types.EncodeToHexString(xt)
 	if err != nil {
 		return types.Hash{}, err
 	}
 
 	var res string
-	err = a.client.Call(&res, "author_submitExtrinsic", enc)
+	err = a.client.CallContext(ctx, &res, "author_submitExtrinsic", enc)
 	if err != nil {
 		return types.Hash{}, err
 	}
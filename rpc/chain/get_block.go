@@ -17,23 +17,35 @@
 package chain
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetBlock returns the header and body of the relay chain block with the given hash
 func (c *Chain) GetBlock(blockHash types.Hash) (*types.SignedBlock, error) {
-	return c.getBlock(&blockHash)
+	return c.GetBlockContext(context.Background(), blockHash)
+}
+
+// GetBlockContext is GetBlock with a context
+func (c *Chain) GetBlockContext(ctx context.Context, blockHash types.Hash) (*types.SignedBlock, error) {
+	return c.getBlock(ctx, &blockHash)
 }
 
 // GetBlockLatest returns the header and body of the latest relay chain block
 func (c *Chain) GetBlockLatest() (*types.SignedBlock, error) {
-	return c.getBlock(nil)
+	return c.GetBlockLatestContext(context.Background())
+}
+
+// GetBlockLatestContext is GetBlockLatest with a context
+func (c *Chain) GetBlockLatestContext(ctx context.Context) (*types.SignedBlock, error) {
+	return c.getBlock(ctx, nil)
 }
 
-func (c *Chain) getBlock(blockHash *types.Hash) (*types.SignedBlock, error) {
+func (c *Chain) getBlock(ctx context.Context, blockHash *types.Hash) (*types.SignedBlock, error) {
 	var SignedBlock types.SignedBlock
-	err := client.CallWithBlockHash(c.client, &SignedBlock, "chain_getBlock", blockHash)
+	err := client.CallWithBlockHashContext(ctx, c.client, &SignedBlock, "chain_getBlock", blockHash)
 	if err != nil {
 		return nil, err
 	}
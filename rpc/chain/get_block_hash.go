@@ -17,27 +17,39 @@
 package chain
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetBlockHash returns the block hash for a specific block height
 func (c *Chain) GetBlockHash(blockNumber uint64) (types.Hash, error) {
-	return c.getBlockHash(&blockNumber)
+	return c.GetBlockHashContext(context.Background(), blockNumber)
+}
+
+// GetBlockHashContext is GetBlockHash with a context
+func (c *Chain) GetBlockHashContext(ctx context.Context, blockNumber uint64) (types.Hash, error) {
+	return c.getBlockHash(ctx, &blockNumber)
 }
 
 // GetBlockHashLatest returns the latest block hash
 func (c *Chain) GetBlockHashLatest() (types.Hash, error) {
-	return c.getBlockHash(nil)
+	return c.GetBlockHashLatestContext(context.Background())
+}
+
+// GetBlockHashLatestContext is GetBlockHashLatest with a context
+func (c *Chain) GetBlockHashLatestContext(ctx context.Context) (types.Hash, error) {
+	return c.getBlockHash(ctx, nil)
 }
 
-func (c *Chain) getBlockHash(blockNumber *uint64) (types.Hash, error) {
+func (c *Chain) getBlockHash(ctx context.Context, blockNumber *uint64) (types.Hash, error) {
 	var res string
 	var err error
 
 	if blockNumber == nil {
-		err = c.client.Call(&res, "chain_getBlockHash")
+		err = c.client.CallContext(ctx, &res, "chain_getBlockHash")
 	} else {
-		err = c.client.Call(&res, "chain_getBlockHash", *blockNumber)
+		err = c.client.CallContext(ctx, &res, "chain_getBlockHash", *blockNumber)
 	}
 
 	if err != nil {
@@ -17,14 +17,21 @@
 package chain
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetFinalizedHead returns the hash of the last finalized block in the canon chain
 func (c *Chain) GetFinalizedHead() (types.Hash, error) {
+	return c.GetFinalizedHeadContext(context.Background())
+}
+
+// GetFinalizedHeadContext is GetFinalizedHead with a context
+func (c *Chain) GetFinalizedHeadContext(ctx context.Context) (types.Hash, error) {
 	var res string
 
-	err := c.client.Call(&res, "chain_getFinalizedHead")
+	err := c.client.CallContext(ctx, &res, "chain_getFinalizedHead")
 	if err != nil {
 		return types.Hash{}, err
 	}
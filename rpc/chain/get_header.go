@@ -17,23 +17,35 @@
 package chain
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetHeader retrieves the header for the specific block
 func (c *Chain) GetHeader(blockHash types.Hash) (*types.Header, error) {
-	return c.getHeader(&blockHash)
+	return c.GetHeaderContext(context.Background(), blockHash)
+}
+
+// GetHeaderContext is GetHeader with a context
+func (c *Chain) GetHeaderContext(ctx context.Context, blockHash types.Hash) (*types.Header, error) {
+	return c.getHeader(ctx, &blockHash)
 }
 
 // GetHeaderLatest retrieves the header of the latest block
 func (c *Chain) GetHeaderLatest() (*types.Header, error) {
-	return c.getHeader(nil)
+	return c.GetHeaderLatestContext(context.Background())
+}
+
+// GetHeaderLatestContext is GetHeaderLatest with a context
+func (c *Chain) GetHeaderLatestContext(ctx context.Context) (*types.Header, error) {
+	return c.getHeader(ctx, nil)
 }
 
-func (c *Chain) getHeader(blockHash *types.Hash) (*types.Header, error) {
+func (c *Chain) getHeader(ctx context.Context, blockHash *types.Hash) (*types.Header, error) {
 	var Header types.Header
-	err := client.CallWithBlockHash(c.client, &Header, "chain_getHeader", blockHash)
+	err := client.CallWithBlockHashContext(ctx, c.client, &Header, "chain_getHeader", blockHash)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,213 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// headsSubscription is the common part of NewHeadsSubscription and FinalizedHeadsSubscription
+type headsSubscription interface {
+	Chan() <-chan types.Header
+	Err() <-chan error
+	Unsubscribe()
+}
+
+// ResilientHeadsSubscription is a heads subscription that resubscribes with back-off when the underlying subscription
+// ends with an error. After a resubscription the headers missed during the gap are fetched and delivered first, so
+// block numbers arrive without holes. A missed header that can not be fetched makes it resubscribe as well, the gap is
+// fetched again from that header on.
+type ResilientHeadsSubscription struct {
+	chain     *Chain
+	subscribe func() (headsSubscription, error)
+	channel   chan types.Header
+	err       chan error
+	quit      chan struct{}
+	quitOnce  sync.Once
+	done      chan struct{}
+
+	// number of the last delivered header, used by run only
+	last    types.BlockNumber
+	hasLast bool
+}
+
+// Chan returns the subscription channel.
+//
+// The channel is closed when Unsubscribe is called on the subscription.
+func (s *ResilientHeadsSubscription) Chan() <-chan types.Header {
+	return s.channel
+}
+
+// Err returns the errors that made the subscription resubscribe or fail to backfill. They are informational, the
+// subscription keeps running. Errors are dropped when nobody reads the channel.
+func (s *ResilientHeadsSubscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe stops resubscribing, unsubscribes the notification and closes the channel.
+// It can safely be called more than once.
+func (s *ResilientHeadsSubscription) Unsubscribe() {
+	s.quitOnce.Do(func() {
+		close(s.quit)
+	})
+	<-s.done
+}
+
+// SubscribeNewHeadsResilient subscribes the best headers like SubscribeNewHeads, but survives reconnects.
+func (c *Chain) SubscribeNewHeadsResilient() (*ResilientHeadsSubscription, error) {
+	return c.subscribeHeadsResilient(func() (headsSubscription, error) {
+		return c.SubscribeNewHeads()
+	})
+}
+
+// SubscribeFinalizedHeadsResilient subscribes the finalized headers like SubscribeFinalizedHeads, but survives
+// reconnects.
+func (c *Chain) SubscribeFinalizedHeadsResilient() (*ResilientHeadsSubscription, error) {
+	return c.subscribeHeadsResilient(func() (headsSubscription, error) {
+		return c.SubscribeFinalizedHeads()
+	})
+}
+
+func (c *Chain) subscribeHeadsResilient(subscribe func() (headsSubscription, error)) (
+	*ResilientHeadsSubscription, error) {
+	sub, err := subscribe()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ResilientHeadsSubscription{
+		chain:     c,
+		subscribe: subscribe,
+		channel:   make(chan types.Header),
+		err:       make(chan error, 1),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.run(sub)
+	return s, nil
+}
+
+func (s *ResilientHeadsSubscription) run(sub headsSubscription) {
+	defer close(s.done)
+	defer close(s.channel)
+
+	b := &backoff.Backoff{
+		Min:    s.chain.client.Config().ResubscribeMinInterval,
+		Max:    s.chain.client.Config().ResubscribeMaxInterval,
+		Jitter: true,
+	}
+	for {
+		err := s.forward(sub)
+		sub.Unsubscribe()
+		if err == nil {
+			return
+		}
+		s.report(err)
+
+		for {
+			select {
+			case <-time.After(b.Duration()):
+			case <-s.quit:
+				return
+			}
+			sub, err = s.subscribe()
+			if err == nil {
+				break
+			}
+			s.report(err)
+		}
+		b.Reset()
+	}
+}
+
+// forward delivers the headers of sub until it fails or the subscription is stopped, which returns nil
+func (s *ResilientHeadsSubscription) forward(sub headsSubscription) error {
+	for {
+		select {
+		case head, ok := <-sub.Chan():
+			if !ok {
+				return nil
+			}
+			if s.hasLast && head.Number > s.last+1 {
+				stopped, err := s.backfill(s.last+1, head.Number-1)
+				if stopped {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if !s.deliver(head) {
+				return nil
+			}
+		case err := <-sub.Err():
+			if err == nil {
+				// the client was closed, there is nothing to resubscribe to
+				return nil
+			}
+			return err
+		case <-s.quit:
+			return nil
+		}
+	}
+}
+
+// backfill delivers the canonical headers from..to. It stops at the first header that can not be fetched and returns
+// its error, the headers before it were delivered. It returns true if the subscription was stopped.
+func (s *ResilientHeadsSubscription) backfill(from, to types.BlockNumber) (bool, error) {
+	for n := from; n <= to; n++ {
+		hash, err := s.chain.GetBlockHash(uint64(n))
+		if err != nil {
+			return false, err
+		}
+		head, err := s.chain.GetHeader(hash)
+		if err != nil {
+			return false, err
+		}
+		// an unknown block comes back as an empty header
+		if head.Number != n {
+			return false, fmt.Errorf("header %d not found", n)
+		}
+		if !s.deliver(*head) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *ResilientHeadsSubscription) deliver(head types.Header) bool {
+	select {
+	case s.channel <- head:
+		s.last = head.Number
+		s.hasLast = true
+		return true
+	case <-s.quit:
+		return false
+	}
+}
+
+func (s *ResilientHeadsSubscription) report(err error) {
+	select {
+	case s.err <- err:
+	default:
+	}
+}
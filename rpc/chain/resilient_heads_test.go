@@ -0,0 +1,136 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/rpcmocksrv"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeHeadsSubscription struct {
+	channel chan types.Header
+	err     chan error
+}
+
+func newFakeHeadsSubscription(numbers ...types.BlockNumber) *fakeHeadsSubscription {
+	s := &fakeHeadsSubscription{channel: make(chan types.Header, len(numbers)), err: make(chan error, 1)}
+	for _, n := range numbers {
+		s.channel <- types.Header{Number: n}
+	}
+	return s
+}
+
+func (s *fakeHeadsSubscription) Chan() <-chan types.Header { return s.channel }
+func (s *fakeHeadsSubscription) Err() <-chan error         { return s.err }
+func (s *fakeHeadsSubscription) Unsubscribe()              {}
+
+// fastClient resubscribes without waiting long
+type fastClient struct {
+	client.Client
+}
+
+func (c fastClient) Config() config.Config {
+	cfg := c.Client.Config()
+	cfg.ResubscribeMinInterval = time.Millisecond
+	cfg.ResubscribeMaxInterval = 10 * time.Millisecond
+	return cfg
+}
+
+// newNodeChain returns a chain over a simulated node with blocks up to number
+func newNodeChain(t *testing.T, number int) (*Chain, *rpcmocksrv.Node) {
+	node, err := rpcmocksrv.NewNode(rpcmocksrv.NodeConfig{})
+	assert.NoError(t, err)
+	t.Cleanup(func() { node.Close() })
+	for i := 0; i < number; i++ {
+		node.ProduceBlock()
+	}
+	cl, err := client.Connect(node.URL)
+	assert.NoError(t, err)
+	t.Cleanup(cl.Close)
+	return NewChain(fastClient{cl}), node
+}
+
+func TestChain_SubscribeHeadsResilient(t *testing.T) {
+	chain, _ := newNodeChain(t, 8)
+
+	subs := []*fakeHeadsSubscription{
+		newFakeHeadsSubscription(5),
+		newFakeHeadsSubscription(8),
+	}
+	calls := 0
+	sub, err := chain.subscribeHeadsResilient(func() (headsSubscription, error) {
+		if calls >= len(subs) {
+			return nil, errors.New("no more subscriptions")
+		}
+		s := subs[calls]
+		calls++
+		return s, nil
+	})
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	assert.Equal(t, types.BlockNumber(5), (<-sub.Chan()).Number)
+
+	// the connection drops, the missed headers 6 and 7 are fetched before 8
+	subs[0].err <- errors.New("connection lost")
+	assert.Equal(t, types.BlockNumber(6), (<-sub.Chan()).Number)
+	assert.Equal(t, types.BlockNumber(7), (<-sub.Chan()).Number)
+	assert.Equal(t, types.BlockNumber(8), (<-sub.Chan()).Number)
+}
+
+func TestChain_SubscribeHeadsResilient_BackfillFails(t *testing.T) {
+	// the node knows block 6 but not yet 7 when the subscription jumps to 8
+	chain, node := newNodeChain(t, 6)
+
+	subs := []*fakeHeadsSubscription{
+		newFakeHeadsSubscription(5, 8),
+		newFakeHeadsSubscription(8),
+	}
+	calls := 0
+	sub, err := chain.subscribeHeadsResilient(func() (headsSubscription, error) {
+		if calls >= len(subs) {
+			return nil, errors.New("no more subscriptions")
+		}
+		if calls == 1 {
+			node.ProduceBlock()
+			node.ProduceBlock()
+		}
+		s := subs[calls]
+		calls++
+		return s, nil
+	})
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	// 7 can not be fetched, the subscription resubscribes and fetches the gap again instead of skipping 7
+	for _, n := range []types.BlockNumber{5, 6, 7, 8} {
+		select {
+		case head := <-sub.Chan():
+			assert.Equal(t, n, head.Number)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("header %d not delivered", n)
+		}
+	}
+	assert.Equal(t, 2, calls)
+}
@@ -20,7 +20,6 @@ import (
 	"context"
 	"sync"
 
-	"github.com/stafiprotocol/go-substrate-rpc-client/config"
 	gethrpc "github.com/stafiprotocol/go-substrate-rpc-client/pkg/gethrpc"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
@@ -63,9 +62,14 @@ func (s *FinalizedHeadsSubscription) Unsubscribe() {
 // SubscribeFinalizedHeads subscribes the best finalized headers, returning a subscription that will
 // receive server notifications containing the Header.
 func (c *Chain) SubscribeFinalizedHeads() (*FinalizedHeadsSubscription, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.Default().SubscribeTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.client.Config().SubscribeTimeout)
 	defer cancel()
 
+	return c.SubscribeFinalizedHeadsContext(ctx)
+}
+
+// SubscribeFinalizedHeadsContext is SubscribeFinalizedHeads with a context
+func (c *Chain) SubscribeFinalizedHeadsContext(ctx context.Context) (*FinalizedHeadsSubscription, error) {
 	ch := make(chan types.Header)
 
 	sub, err := c.client.Subscribe(ctx, "chain", "subscribeFinalizedHeads", "unsubscribeFinalizedHeads",
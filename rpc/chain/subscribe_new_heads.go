@@ -20,7 +20,6 @@ import (
 	"context"
 	"sync"
 
-	"github.com/stafiprotocol/go-substrate-rpc-client/config"
 	gethrpc "github.com/stafiprotocol/go-substrate-rpc-client/pkg/gethrpc"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
@@ -63,9 +62,14 @@ func (s *NewHeadsSubscription) Unsubscribe() {
 // SubscribeNewHeads subscribes the best headers, returning a subscription that will
 // receive server notifications containing the Header.
 func (c *Chain) SubscribeNewHeads() (*NewHeadsSubscription, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.Default().SubscribeTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.client.Config().SubscribeTimeout)
 	defer cancel()
 
+	return c.SubscribeNewHeadsContext(ctx)
+}
+
+// SubscribeNewHeadsContext is SubscribeNewHeads with a context
+func (c *Chain) SubscribeNewHeadsContext(ctx context.Context) (*NewHeadsSubscription, error) {
 	ch := make(chan types.Header)
 
 	sub, err := c.client.Subscribe(ctx, "chain", "subscribeNewHead", "unsubscribeNewHead", "newHead", ch)
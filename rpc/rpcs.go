@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/rpc/author"
 	"github.com/stafiprotocol/go-substrate-rpc-client/rpc/chain"
@@ -33,16 +34,26 @@ type RPCS struct {
 }
 
 func NewRPCS(endpoint string) (*RPCS, error) {
-	cl, err := client.Connect(endpoint)
+	return NewRPCSWithConfig(endpoint, config.Default())
+}
+
+// NewRPCSWithConfig is NewRPCS with the timeouts of cfg instead of the package defaults
+func NewRPCSWithConfig(endpoint string, cfg config.Config) (*RPCS, error) {
+	cl, err := client.ConnectWithConfig(endpoint, cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	return NewRPCSWithClient(cl), nil
+}
+
+// NewRPCSWithClient creates the rpc modules over an existing client, like a client.MultiClient
+func NewRPCSWithClient(cl client.Client) *RPCS {
 	return &RPCS{
 		Author: author.NewAuthor(cl),
 		Chain:  chain.NewChain(cl),
 		State:  state.NewState(cl),
 		System: system.NewSystem(cl),
 		Client: cl,
-	}, nil
+	}
 }
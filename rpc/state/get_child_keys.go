@@ -17,6 +17,8 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
@@ -24,18 +26,30 @@ import (
 // GetChildKeys retreives the keys with the given prefix of a specific child storage
 func (s *State) GetChildKeys(childStorageKey, prefix types.StorageKey, blockHash types.Hash) (
 	[]types.StorageKey, error) {
-	return s.getChildKeys(childStorageKey, prefix, &blockHash)
+	return s.GetChildKeysContext(context.Background(), childStorageKey, prefix, blockHash)
+}
+
+// GetChildKeysContext is GetChildKeys with a context
+func (s *State) GetChildKeysContext(ctx context.Context, childStorageKey, prefix types.StorageKey,
+	blockHash types.Hash) ([]types.StorageKey, error) {
+	return s.getChildKeys(ctx, childStorageKey, prefix, &blockHash)
 }
 
 // GetChildKeysLatest retreives the keys with the given prefix of a specific child storage for the latest block height
 func (s *State) GetChildKeysLatest(childStorageKey, prefix types.StorageKey) ([]types.StorageKey, error) {
-	return s.getChildKeys(childStorageKey, prefix, nil)
+	return s.GetChildKeysLatestContext(context.Background(), childStorageKey, prefix)
+}
+
+// GetChildKeysLatestContext is GetChildKeysLatest with a context
+func (s *State) GetChildKeysLatestContext(ctx context.Context, childStorageKey, prefix types.StorageKey) (
+	[]types.StorageKey, error) {
+	return s.getChildKeys(ctx, childStorageKey, prefix, nil)
 }
 
-func (s *State) getChildKeys(childStorageKey, prefix types.StorageKey, blockHash *types.Hash) (
+func (s *State) getChildKeys(ctx context.Context, childStorageKey, prefix types.StorageKey, blockHash *types.Hash) (
 	[]types.StorageKey, error) {
 	var res []string
-	err := client.CallWithBlockHash(s.client, &res, "state_getChildKeys", blockHash, childStorageKey.Hex(), prefix.Hex())
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_getChildKeys", blockHash, childStorageKey.Hex(), prefix.Hex())
 	if err != nil {
 		return nil, err
 	}
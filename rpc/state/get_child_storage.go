@@ -17,6 +17,8 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
@@ -25,7 +27,13 @@ import (
 // value is not empty.
 func (s *State) GetChildStorage(childStorageKey, key types.StorageKey, target interface{}, blockHash types.Hash) (
 	ok bool, err error) {
-	raw, err := s.getChildStorageRaw(childStorageKey, key, &blockHash)
+	return s.GetChildStorageContext(context.Background(), childStorageKey, key, target, blockHash)
+}
+
+// GetChildStorageContext is GetChildStorage with a context
+func (s *State) GetChildStorageContext(ctx context.Context, childStorageKey, key types.StorageKey, target interface{},
+	blockHash types.Hash) (ok bool, err error) {
+	raw, err := s.getChildStorageRaw(ctx, childStorageKey, key, &blockHash)
 	if err != nil {
 		return false, err
 	}
@@ -38,7 +46,13 @@ func (s *State) GetChildStorage(childStorageKey, key types.StorageKey, target in
 // GetChildStorageLatest retreives the child storage for a key for the latest block height and decodes them into the
 // provided interface. Ok is true if the value is not empty.
 func (s *State) GetChildStorageLatest(childStorageKey, key types.StorageKey, target interface{}) (ok bool, err error) {
-	raw, err := s.getChildStorageRaw(childStorageKey, key, nil)
+	return s.GetChildStorageLatestContext(context.Background(), childStorageKey, key, target)
+}
+
+// GetChildStorageLatestContext is GetChildStorageLatest with a context
+func (s *State) GetChildStorageLatestContext(ctx context.Context, childStorageKey, key types.StorageKey,
+	target interface{}) (ok bool, err error) {
+	raw, err := s.getChildStorageRaw(ctx, childStorageKey, key, nil)
 	if err != nil {
 		return false, err
 	}
@@ -51,19 +65,31 @@ func (s *State) GetChildStorageLatest(childStorageKey, key types.StorageKey, tar
 // GetChildStorageRaw retreives the child storage for a key as raw bytes, without decoding them
 func (s *State) GetChildStorageRaw(childStorageKey, key types.StorageKey, blockHash types.Hash) (
 	*types.StorageDataRaw, error) {
-	return s.getChildStorageRaw(childStorageKey, key, &blockHash)
+	return s.GetChildStorageRawContext(context.Background(), childStorageKey, key, blockHash)
+}
+
+// GetChildStorageRawContext is GetChildStorageRaw with a context
+func (s *State) GetChildStorageRawContext(ctx context.Context, childStorageKey, key types.StorageKey,
+	blockHash types.Hash) (*types.StorageDataRaw, error) {
+	return s.getChildStorageRaw(ctx, childStorageKey, key, &blockHash)
 }
 
 // GetChildStorageRawLatest retreives the child storage for a key for the latest block height as raw bytes,
 // without decoding them
 func (s *State) GetChildStorageRawLatest(childStorageKey, key types.StorageKey) (*types.StorageDataRaw, error) {
-	return s.getChildStorageRaw(childStorageKey, key, nil)
+	return s.GetChildStorageRawLatestContext(context.Background(), childStorageKey, key)
+}
+
+// GetChildStorageRawLatestContext is GetChildStorageRawLatest with a context
+func (s *State) GetChildStorageRawLatestContext(ctx context.Context, childStorageKey, key types.StorageKey) (
+	*types.StorageDataRaw, error) {
+	return s.getChildStorageRaw(ctx, childStorageKey, key, nil)
 }
 
-func (s *State) getChildStorageRaw(childStorageKey, key types.StorageKey, blockHash *types.Hash) (
+func (s *State) getChildStorageRaw(ctx context.Context, childStorageKey, key types.StorageKey, blockHash *types.Hash) (
 	*types.StorageDataRaw, error) {
 	var res string
-	err := client.CallWithBlockHash(s.client, &res, "state_getChildStorage", blockHash, childStorageKey.Hex(),
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_getChildStorage", blockHash, childStorageKey.Hex(),
 		key.Hex())
 	if err != nil {
 		return nil, err
@@ -17,23 +17,38 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetChildStorageHash retreives the child storage hash for the given key
 func (s *State) GetChildStorageHash(childStorageKey, key types.StorageKey, blockHash types.Hash) (types.Hash, error) {
-	return s.getChildStorageHash(childStorageKey, key, &blockHash)
+	return s.GetChildStorageHashContext(context.Background(), childStorageKey, key, blockHash)
+}
+
+// GetChildStorageHashContext is GetChildStorageHash with a context
+func (s *State) GetChildStorageHashContext(ctx context.Context, childStorageKey, key types.StorageKey,
+	blockHash types.Hash) (types.Hash, error) {
+	return s.getChildStorageHash(ctx, childStorageKey, key, &blockHash)
 }
 
 // GetChildStorageHashLatest retreives the child storage hash for the given key for the latest block height
 func (s *State) GetChildStorageHashLatest(childStorageKey, key types.StorageKey) (types.Hash, error) {
-	return s.getChildStorageHash(childStorageKey, key, nil)
+	return s.GetChildStorageHashLatestContext(context.Background(), childStorageKey, key)
+}
+
+// GetChildStorageHashLatestContext is GetChildStorageHashLatest with a context
+func (s *State) GetChildStorageHashLatestContext(ctx context.Context, childStorageKey, key types.StorageKey) (
+	types.Hash, error) {
+	return s.getChildStorageHash(ctx, childStorageKey, key, nil)
 }
 
-func (s *State) getChildStorageHash(childStorageKey, key types.StorageKey, blockHash *types.Hash) (types.Hash, error) {
+func (s *State) getChildStorageHash(ctx context.Context, childStorageKey, key types.StorageKey, blockHash *types.Hash) (
+	types.Hash, error) {
 	var res string
-	err := client.CallWithBlockHash(s.client, &res, "state_getChildStorageHash", blockHash, childStorageKey.Hex(),
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_getChildStorageHash", blockHash, childStorageKey.Hex(),
 		key.Hex())
 	if err != nil {
 		return types.Hash{}, err
@@ -17,23 +17,38 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetChildStorageSize retreives the child storage size for the given key
 func (s *State) GetChildStorageSize(childStorageKey, key types.StorageKey, blockHash types.Hash) (types.U64, error) {
-	return s.getChildStorageSize(childStorageKey, key, &blockHash)
+	return s.GetChildStorageSizeContext(context.Background(), childStorageKey, key, blockHash)
+}
+
+// GetChildStorageSizeContext is GetChildStorageSize with a context
+func (s *State) GetChildStorageSizeContext(ctx context.Context, childStorageKey, key types.StorageKey,
+	blockHash types.Hash) (types.U64, error) {
+	return s.getChildStorageSize(ctx, childStorageKey, key, &blockHash)
 }
 
 // GetChildStorageSizeLatest retreives the child storage size for the given key for the latest block height
 func (s *State) GetChildStorageSizeLatest(childStorageKey, key types.StorageKey) (types.U64, error) {
-	return s.getChildStorageSize(childStorageKey, key, nil)
+	return s.GetChildStorageSizeLatestContext(context.Background(), childStorageKey, key)
+}
+
+// GetChildStorageSizeLatestContext is GetChildStorageSizeLatest with a context
+func (s *State) GetChildStorageSizeLatestContext(ctx context.Context, childStorageKey, key types.StorageKey) (
+	types.U64, error) {
+	return s.getChildStorageSize(ctx, childStorageKey, key, nil)
 }
 
-func (s *State) getChildStorageSize(childStorageKey, key types.StorageKey, blockHash *types.Hash) (types.U64, error) {
+func (s *State) getChildStorageSize(ctx context.Context, childStorageKey, key types.StorageKey, blockHash *types.Hash) (
+	types.U64, error) {
 	var res types.U64
-	err := client.CallWithBlockHash(s.client, &res, "state_getChildStorageSize", blockHash, childStorageKey.Hex(),
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_getChildStorageSize", blockHash, childStorageKey.Hex(),
 		key.Hex())
 	if err != nil {
 		return 0, err
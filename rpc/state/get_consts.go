@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"fmt"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
@@ -13,7 +14,12 @@ const (
 )
 
 func (s *State) GetConst(prefix, name string, res interface{}) error {
-	meta, err := s.GetMetadataLatest()
+	return s.GetConstContext(context.Background(), prefix, name, res)
+}
+
+// GetConstContext is GetConst with a context
+func (s *State) GetConstContext(ctx context.Context, prefix, name string, res interface{}) error {
+	meta, err := s.GetMetadataLatestContext(ctx)
 	if err != nil {
 		return err
 	}
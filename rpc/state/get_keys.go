@@ -17,23 +17,37 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetKeys retreives the keys with the given prefix
 func (s *State) GetKeys(prefix types.StorageKey, blockHash types.Hash) ([]types.StorageKey, error) {
-	return s.getKeys(prefix, &blockHash)
+	return s.GetKeysContext(context.Background(), prefix, blockHash)
+}
+
+// GetKeysContext is GetKeys with a context
+func (s *State) GetKeysContext(ctx context.Context, prefix types.StorageKey, blockHash types.Hash) (
+	[]types.StorageKey, error) {
+	return s.getKeys(ctx, prefix, &blockHash)
 }
 
 // GetKeysLatest retreives the keys with the given prefix for the latest block height
 func (s *State) GetKeysLatest(prefix types.StorageKey) ([]types.StorageKey, error) {
-	return s.getKeys(prefix, nil)
+	return s.GetKeysLatestContext(context.Background(), prefix)
+}
+
+// GetKeysLatestContext is GetKeysLatest with a context
+func (s *State) GetKeysLatestContext(ctx context.Context, prefix types.StorageKey) ([]types.StorageKey, error) {
+	return s.getKeys(ctx, prefix, nil)
 }
 
-func (s *State) getKeys(prefix types.StorageKey, blockHash *types.Hash) ([]types.StorageKey, error) {
+func (s *State) getKeys(ctx context.Context, prefix types.StorageKey, blockHash *types.Hash) (
+	[]types.StorageKey, error) {
 	var res []string
-	err := client.CallWithBlockHash(s.client, &res, "state_getKeys", blockHash, prefix.Hex())
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_getKeys", blockHash, prefix.Hex())
 	if err != nil {
 		return nil, err
 	}
@@ -17,23 +17,35 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetMetadata returns the metadata at the given block
 func (s *State) GetMetadata(blockHash types.Hash) (*types.Metadata, error) {
-	return s.getMetadata(&blockHash)
+	return s.GetMetadataContext(context.Background(), blockHash)
+}
+
+// GetMetadataContext is GetMetadata with a context
+func (s *State) GetMetadataContext(ctx context.Context, blockHash types.Hash) (*types.Metadata, error) {
+	return s.getMetadata(ctx, &blockHash)
 }
 
 // GetMetadataLatest returns the latest metadata
 func (s *State) GetMetadataLatest() (*types.Metadata, error) {
-	return s.getMetadata(nil)
+	return s.GetMetadataLatestContext(context.Background())
+}
+
+// GetMetadataLatestContext is GetMetadataLatest with a context
+func (s *State) GetMetadataLatestContext(ctx context.Context) (*types.Metadata, error) {
+	return s.getMetadata(ctx, nil)
 }
 
-func (s *State) getMetadata(blockHash *types.Hash) (*types.Metadata, error) {
+func (s *State) getMetadata(ctx context.Context, blockHash *types.Hash) (*types.Metadata, error) {
 	var res string
-	err := client.CallWithBlockHash(s.client, &res, "state_getMetadata", blockHash)
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_getMetadata", blockHash)
 	if err != nil {
 		return nil, err
 	}
@@ -17,23 +17,35 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetRuntimeVersion returns the runtime version at the given block
 func (s *State) GetRuntimeVersion(blockHash types.Hash) (*types.RuntimeVersion, error) {
-	return s.getRuntimeVersion(&blockHash)
+	return s.GetRuntimeVersionContext(context.Background(), blockHash)
+}
+
+// GetRuntimeVersionContext is GetRuntimeVersion with a context
+func (s *State) GetRuntimeVersionContext(ctx context.Context, blockHash types.Hash) (*types.RuntimeVersion, error) {
+	return s.getRuntimeVersion(ctx, &blockHash)
 }
 
 // GetRuntimeVersionLatest returns the latest runtime version
 func (s *State) GetRuntimeVersionLatest() (*types.RuntimeVersion, error) {
-	return s.getRuntimeVersion(nil)
+	return s.GetRuntimeVersionLatestContext(context.Background())
+}
+
+// GetRuntimeVersionLatestContext is GetRuntimeVersionLatest with a context
+func (s *State) GetRuntimeVersionLatestContext(ctx context.Context) (*types.RuntimeVersion, error) {
+	return s.getRuntimeVersion(ctx, nil)
 }
 
-func (s *State) getRuntimeVersion(blockHash *types.Hash) (*types.RuntimeVersion, error) {
+func (s *State) getRuntimeVersion(ctx context.Context, blockHash *types.Hash) (*types.RuntimeVersion, error) {
 	var runtimeVersion types.RuntimeVersion
-	err := client.CallWithBlockHash(s.client, &runtimeVersion, "state_getRuntimeVersion", blockHash)
+	err := client.CallWithBlockHashContext(ctx, s.client, &runtimeVersion, "state_getRuntimeVersion", blockHash)
 	if err != nil {
 		return nil, err
 	}
@@ -17,6 +17,8 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
@@ -24,7 +26,13 @@ import (
 // GetStorage retreives the stored data and decodes them into the provided interface. Ok is true if the value is not
 // empty.
 func (s *State) GetStorage(key types.StorageKey, target interface{}, blockHash types.Hash) (ok bool, err error) {
-	raw, err := s.getStorageRaw(key, &blockHash)
+	return s.GetStorageContext(context.Background(), key, target, blockHash)
+}
+
+// GetStorageContext is GetStorage with a context
+func (s *State) GetStorageContext(ctx context.Context, key types.StorageKey, target interface{}, blockHash types.Hash) (
+	ok bool, err error) {
+	raw, err := s.getStorageRaw(ctx, key, &blockHash)
 	if err != nil {
 		return false, err
 	}
@@ -37,7 +45,13 @@ func (s *State) GetStorage(key types.StorageKey, target interface{}, blockHash t
 // GetStorageLatest retreives the stored data for the latest block height and decodes them into the provided interface.
 // Ok is true if the value is not empty.
 func (s *State) GetStorageLatest(key types.StorageKey, target interface{}) (ok bool, err error) {
-	raw, err := s.getStorageRaw(key, nil)
+	return s.GetStorageLatestContext(context.Background(), key, target)
+}
+
+// GetStorageLatestContext is GetStorageLatest with a context
+func (s *State) GetStorageLatestContext(ctx context.Context, key types.StorageKey, target interface{}) (
+	ok bool, err error) {
+	raw, err := s.getStorageRaw(ctx, key, nil)
 	if err != nil {
 		return false, err
 	}
@@ -49,17 +63,29 @@ func (s *State) GetStorageLatest(key types.StorageKey, target interface{}) (ok b
 
 // GetStorageRaw retreives the stored data as raw bytes, without decoding them
 func (s *State) GetStorageRaw(key types.StorageKey, blockHash types.Hash) (*types.StorageDataRaw, error) {
-	return s.getStorageRaw(key, &blockHash)
+	return s.GetStorageRawContext(context.Background(), key, blockHash)
+}
+
+// GetStorageRawContext is GetStorageRaw with a context
+func (s *State) GetStorageRawContext(ctx context.Context, key types.StorageKey, blockHash types.Hash) (
+	*types.StorageDataRaw, error) {
+	return s.getStorageRaw(ctx, key, &blockHash)
 }
 
 // GetStorageRawLatest retreives the stored data for the latest block height as raw bytes, without decoding them
 func (s *State) GetStorageRawLatest(key types.StorageKey) (*types.StorageDataRaw, error) {
-	return s.getStorageRaw(key, nil)
+	return s.GetStorageRawLatestContext(context.Background(), key)
+}
+
+// GetStorageRawLatestContext is GetStorageRawLatest with a context
+func (s *State) GetStorageRawLatestContext(ctx context.Context, key types.StorageKey) (*types.StorageDataRaw, error) {
+	return s.getStorageRaw(ctx, key, nil)
 }
 
-func (s *State) getStorageRaw(key types.StorageKey, blockHash *types.Hash) (*types.StorageDataRaw, error) {
+func (s *State) getStorageRaw(ctx context.Context, key types.StorageKey, blockHash *types.Hash) (
+	*types.StorageDataRaw, error) {
 	var res string
-	err := client.CallWithBlockHash(s.client, &res, "state_getStorage", blockHash, key.Hex())
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_getStorage", blockHash, key.Hex())
 	if err != nil {
 		return nil, err
 	}
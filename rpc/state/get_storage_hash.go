@@ -17,23 +17,36 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetStorageHash retreives the storage hash for the given key
 func (s *State) GetStorageHash(key types.StorageKey, blockHash types.Hash) (types.Hash, error) {
-	return s.getStorageHash(key, &blockHash)
+	return s.GetStorageHashContext(context.Background(), key, blockHash)
+}
+
+// GetStorageHashContext is GetStorageHash with a context
+func (s *State) GetStorageHashContext(ctx context.Context, key types.StorageKey, blockHash types.Hash) (
+	types.Hash, error) {
+	return s.getStorageHash(ctx, key, &blockHash)
 }
 
 // GetStorageHashLatest retreives the storage hash for the given key for the latest block height
 func (s *State) GetStorageHashLatest(key types.StorageKey) (types.Hash, error) {
-	return s.getStorageHash(key, nil)
+	return s.GetStorageHashLatestContext(context.Background(), key)
+}
+
+// GetStorageHashLatestContext is GetStorageHashLatest with a context
+func (s *State) GetStorageHashLatestContext(ctx context.Context, key types.StorageKey) (types.Hash, error) {
+	return s.getStorageHash(ctx, key, nil)
 }
 
-func (s *State) getStorageHash(key types.StorageKey, blockHash *types.Hash) (types.Hash, error) {
+func (s *State) getStorageHash(ctx context.Context, key types.StorageKey, blockHash *types.Hash) (types.Hash, error) {
 	var res string
-	err := client.CallWithBlockHash(s.client, &res, "state_getStorageHash", blockHash, key.Hex())
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_getStorageHash", blockHash, key.Hex())
 	if err != nil {
 		return types.Hash{}, err
 	}
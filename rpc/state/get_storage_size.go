@@ -17,23 +17,36 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // GetStorageSize retreives the storage size for the given key
 func (s *State) GetStorageSize(key types.StorageKey, blockHash types.Hash) (types.U64, error) {
-	return s.getStorageSize(key, &blockHash)
+	return s.GetStorageSizeContext(context.Background(), key, blockHash)
+}
+
+// GetStorageSizeContext is GetStorageSize with a context
+func (s *State) GetStorageSizeContext(ctx context.Context, key types.StorageKey, blockHash types.Hash) (
+	types.U64, error) {
+	return s.getStorageSize(ctx, key, &blockHash)
 }
 
 // GetStorageSizeLatest retreives the storage size for the given key for the latest block height
 func (s *State) GetStorageSizeLatest(key types.StorageKey) (types.U64, error) {
-	return s.getStorageSize(key, nil)
+	return s.GetStorageSizeLatestContext(context.Background(), key)
+}
+
+// GetStorageSizeLatestContext is GetStorageSizeLatest with a context
+func (s *State) GetStorageSizeLatestContext(ctx context.Context, key types.StorageKey) (types.U64, error) {
+	return s.getStorageSize(ctx, key, nil)
 }
 
-func (s *State) getStorageSize(key types.StorageKey, blockHash *types.Hash) (types.U64, error) {
+func (s *State) getStorageSize(ctx context.Context, key types.StorageKey, blockHash *types.Hash) (types.U64, error) {
 	var res types.U64
-	err := client.CallWithBlockHash(s.client, &res, "state_getStorageSize", blockHash, key.Hex())
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_getStorageSize", blockHash, key.Hex())
 	if err != nil {
 		return 0, err
 	}
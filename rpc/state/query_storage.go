@@ -17,6 +17,8 @@
 package state
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
@@ -24,15 +26,27 @@ import (
 // QueryStorage queries historical storage entries (by key) starting from a start block until an end block
 func (s *State) QueryStorage(keys []types.StorageKey, startBlock types.Hash, block types.Hash) (
 	[]types.StorageChangeSet, error) {
-	return s.queryStorage(keys, startBlock, &block)
+	return s.QueryStorageContext(context.Background(), keys, startBlock, block)
+}
+
+// QueryStorageContext is QueryStorage with a context
+func (s *State) QueryStorageContext(ctx context.Context, keys []types.StorageKey, startBlock types.Hash,
+	block types.Hash) ([]types.StorageChangeSet, error) {
+	return s.queryStorage(ctx, keys, startBlock, &block)
 }
 
 // QueryStorageLatest queries historical storage entries (by key) starting from a start block until the latest block
 func (s *State) QueryStorageLatest(keys []types.StorageKey, startBlock types.Hash) ([]types.StorageChangeSet, error) {
-	return s.queryStorage(keys, startBlock, nil)
+	return s.QueryStorageLatestContext(context.Background(), keys, startBlock)
+}
+
+// QueryStorageLatestContext is QueryStorageLatest with a context
+func (s *State) QueryStorageLatestContext(ctx context.Context, keys []types.StorageKey, startBlock types.Hash) (
+	[]types.StorageChangeSet, error) {
+	return s.queryStorage(ctx, keys, startBlock, nil)
 }
 
-func (s *State) queryStorage(keys []types.StorageKey, startBlock types.Hash, block *types.Hash) (
+func (s *State) queryStorage(ctx context.Context, keys []types.StorageKey, startBlock types.Hash, block *types.Hash) (
 	[]types.StorageChangeSet, error) {
 	hexKeys := make([]string, len(keys))
 	for i, key := range keys {
@@ -40,7 +54,7 @@ func (s *State) queryStorage(keys []types.StorageKey, startBlock types.Hash, blo
 	}
 
 	var res []types.StorageChangeSet
-	err := client.CallWithBlockHash(s.client, &res, "state_queryStorage", block, hexKeys, startBlock.Hex())
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_queryStorage", block, hexKeys, startBlock.Hex())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,62 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"context"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// QueryStorageAt queries the storage entries (by key) at a block, the result holds one change set with all keys
+func (s *State) QueryStorageAt(keys []types.StorageKey, block types.Hash) ([]types.StorageChangeSet, error) {
+	return s.QueryStorageAtContext(context.Background(), keys, block)
+}
+
+// QueryStorageAtContext is QueryStorageAt with a context
+func (s *State) QueryStorageAtContext(ctx context.Context, keys []types.StorageKey, block types.Hash) (
+	[]types.StorageChangeSet, error) {
+	return s.queryStorageAt(ctx, keys, &block)
+}
+
+// QueryStorageAtLatest queries the storage entries (by key) at the latest block
+func (s *State) QueryStorageAtLatest(keys []types.StorageKey) ([]types.StorageChangeSet, error) {
+	return s.QueryStorageAtLatestContext(context.Background(), keys)
+}
+
+// QueryStorageAtLatestContext is QueryStorageAtLatest with a context
+func (s *State) QueryStorageAtLatestContext(ctx context.Context, keys []types.StorageKey) (
+	[]types.StorageChangeSet, error) {
+	return s.queryStorageAt(ctx, keys, nil)
+}
+
+func (s *State) queryStorageAt(ctx context.Context, keys []types.StorageKey, block *types.Hash) (
+	[]types.StorageChangeSet, error) {
+	hexKeys := make([]string, len(keys))
+	for i, key := range keys {
+		hexKeys[i] = key.Hex()
+	}
+
+	var res []types.StorageChangeSet
+	err := client.CallWithBlockHashContext(ctx, s.client, &res, "state_queryStorageAt", block, hexKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,198 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+)
+
+// storageSubscription is the part of StorageSubscription ResilientStorageSubscription uses
+type storageSubscription interface {
+	Chan() <-chan types.StorageChangeSet
+	Err() <-chan error
+	Unsubscribe()
+}
+
+// ResilientStorageSubscription is a storage subscription that resubscribes with back-off when the underlying
+// subscription ends with an error. A new subscription starts with the current values of all watched keys, of those
+// only the keys whose value changed during the gap are delivered, so no change is lost and none is sent twice.
+type ResilientStorageSubscription struct {
+	state     *State
+	subscribe func() (storageSubscription, error)
+	channel   chan types.StorageChangeSet
+	err       chan error
+	quit      chan struct{}
+	quitOnce  sync.Once
+	done      chan struct{}
+
+	// last delivered value by hex encoded key, used by run only
+	values map[string]types.KeyValueOption
+}
+
+// Chan returns the subscription channel.
+//
+// The channel is closed when Unsubscribe is called on the subscription.
+func (s *ResilientStorageSubscription) Chan() <-chan types.StorageChangeSet {
+	return s.channel
+}
+
+// Err returns the errors that made the subscription resubscribe. They are informational, the subscription keeps
+// running. Errors are dropped when nobody reads the channel.
+func (s *ResilientStorageSubscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe stops resubscribing, unsubscribes the notification and closes the channel.
+// It can safely be called more than once.
+func (s *ResilientStorageSubscription) Unsubscribe() {
+	s.quitOnce.Do(func() {
+		close(s.quit)
+	})
+	<-s.done
+}
+
+// SubscribeStorageRawResilient subscribes the storage for the given keys like SubscribeStorageRaw, but survives
+// reconnects.
+func (s *State) SubscribeStorageRawResilient(keys []types.StorageKey) (*ResilientStorageSubscription, error) {
+	return s.subscribeStorageResilient(func() (storageSubscription, error) {
+		return s.SubscribeStorageRaw(keys)
+	})
+}
+
+func (s *State) subscribeStorageResilient(subscribe func() (storageSubscription, error)) (
+	*ResilientStorageSubscription, error) {
+	sub, err := subscribe()
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &ResilientStorageSubscription{
+		state:     s,
+		subscribe: subscribe,
+		channel:   make(chan types.StorageChangeSet),
+		err:       make(chan error, 1),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+		values:    make(map[string]types.KeyValueOption),
+	}
+	go rs.run(sub)
+	return rs, nil
+}
+
+func (s *ResilientStorageSubscription) run(sub storageSubscription) {
+	defer close(s.done)
+	defer close(s.channel)
+
+	b := &backoff.Backoff{
+		Min:    s.state.client.Config().ResubscribeMinInterval,
+		Max:    s.state.client.Config().ResubscribeMaxInterval,
+		Jitter: true,
+	}
+	resumed := false
+	for {
+		err := s.forward(sub, resumed)
+		sub.Unsubscribe()
+		if err == nil {
+			return
+		}
+		s.report(err)
+
+		for {
+			select {
+			case <-time.After(b.Duration()):
+			case <-s.quit:
+				return
+			}
+			sub, err = s.subscribe()
+			if err == nil {
+				break
+			}
+			s.report(err)
+		}
+		b.Reset()
+		resumed = true
+	}
+}
+
+// forward delivers the change sets of sub until it fails or the subscription is stopped, which returns nil. The first
+// change set of a resumed subscription holds the current values, it is reduced to the values that changed.
+func (s *ResilientStorageSubscription) forward(sub storageSubscription, resumed bool) error {
+	first := resumed
+	for {
+		select {
+		case set, ok := <-sub.Chan():
+			if !ok {
+				return nil
+			}
+			if first {
+				first = false
+				set.Changes = s.changed(set.Changes)
+				if len(set.Changes) == 0 {
+					continue
+				}
+			}
+			if !s.deliver(set) {
+				return nil
+			}
+		case err := <-sub.Err():
+			if err == nil {
+				// the client was closed, there is nothing to resubscribe to
+				return nil
+			}
+			return err
+		case <-s.quit:
+			return nil
+		}
+	}
+}
+
+// changed returns the changes whose value differs from the last delivered one
+func (s *ResilientStorageSubscription) changed(changes []types.KeyValueOption) []types.KeyValueOption {
+	out := make([]types.KeyValueOption, 0, len(changes))
+	for _, c := range changes {
+		last, ok := s.values[c.StorageKey.Hex()]
+		if ok && last.HasStorageData == c.HasStorageData && bytes.Equal(last.StorageData, c.StorageData) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (s *ResilientStorageSubscription) deliver(set types.StorageChangeSet) bool {
+	select {
+	case s.channel <- set:
+		for _, c := range set.Changes {
+			s.values[c.StorageKey.Hex()] = c
+		}
+		return true
+	case <-s.quit:
+		return false
+	}
+}
+
+func (s *ResilientStorageSubscription) report(err error) {
+	select {
+	case s.err <- err:
+	default:
+	}
+}
@@ -0,0 +1,130 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/config"
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/client"
+	"github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStorageSubscription struct {
+	channel chan types.StorageChangeSet
+	err     chan error
+}
+
+func newFakeStorageSubscription(sets ...types.StorageChangeSet) *fakeStorageSubscription {
+	s := &fakeStorageSubscription{channel: make(chan types.StorageChangeSet, len(sets)), err: make(chan error, 1)}
+	for _, set := range sets {
+		s.channel <- set
+	}
+	return s
+}
+
+func (s *fakeStorageSubscription) Chan() <-chan types.StorageChangeSet { return s.channel }
+func (s *fakeStorageSubscription) Err() <-chan error                   { return s.err }
+func (s *fakeStorageSubscription) Unsubscribe()                        {}
+
+// fastClient resubscribes without waiting long
+type fastClient struct {
+	client.Client
+}
+
+func (c fastClient) Config() config.Config {
+	cfg := c.Client.Config()
+	cfg.ResubscribeMinInterval = time.Millisecond
+	cfg.ResubscribeMaxInterval = 10 * time.Millisecond
+	return cfg
+}
+
+// keyValue is a change of changeSet, a nil value removes the key
+type keyValue struct {
+	key   types.StorageKey
+	value []byte
+}
+
+func changeSet(block byte, changes ...keyValue) types.StorageChangeSet {
+	set := types.StorageChangeSet{Block: types.Hash{block}}
+	for _, c := range changes {
+		set.Changes = append(set.Changes, types.KeyValueOption{
+			StorageKey:     c.key,
+			HasStorageData: c.value != nil,
+			StorageData:    c.value,
+		})
+	}
+	return set
+}
+
+func TestState_SubscribeStorageResilient(t *testing.T) {
+	state := NewState(fastClient{state.client})
+	a := types.NewStorageKey([]byte{0x0a})
+	b := types.NewStorageKey([]byte{0x0b})
+
+	subs := []*fakeStorageSubscription{
+		newFakeStorageSubscription(
+			changeSet(1, keyValue{a, []byte{1}}),
+			changeSet(2, keyValue{b, []byte{1}}),
+		),
+		// a new subscription starts with the current values, a did not change during the gap
+		newFakeStorageSubscription(
+			changeSet(4, keyValue{a, []byte{1}}, keyValue{b, []byte{2}}),
+			changeSet(5, keyValue{a, []byte{3}}),
+		),
+		newFakeStorageSubscription(
+			changeSet(5, keyValue{a, []byte{3}}, keyValue{b, []byte{2}}),
+			changeSet(6, keyValue{b, nil}),
+		),
+	}
+	calls := 0
+	sub, err := state.subscribeStorageResilient(func() (storageSubscription, error) {
+		if calls >= len(subs) {
+			return nil, errors.New("no more subscriptions")
+		}
+		s := subs[calls]
+		calls++
+		return s, nil
+	})
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	next := func() types.StorageChangeSet {
+		select {
+		case set := <-sub.Chan():
+			return set
+		case <-time.After(2 * time.Second):
+			t.Fatal("no change set delivered")
+			return types.StorageChangeSet{}
+		}
+	}
+
+	assert.Equal(t, changeSet(1, keyValue{a, []byte{1}}), next())
+	assert.Equal(t, changeSet(2, keyValue{b, []byte{1}}), next())
+
+	// the initial values of the new subscription are sent only for b, which changed during the gap
+	subs[0].err <- errors.New("connection lost")
+	assert.Equal(t, changeSet(4, keyValue{b, []byte{2}}), next())
+	assert.Equal(t, changeSet(5, keyValue{a, []byte{3}}), next())
+
+	// nothing changed during this gap, the initial values are not sent again
+	subs[1].err <- errors.New("connection lost")
+	assert.Equal(t, changeSet(6, keyValue{b, nil}), next())
+}
@@ -20,7 +20,6 @@ import (
 	"context"
 	"sync"
 
-	"github.com/stafiprotocol/go-substrate-rpc-client/config"
 	gethrpc "github.com/stafiprotocol/go-substrate-rpc-client/pkg/gethrpc"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
@@ -64,9 +63,15 @@ func (s *RuntimeVersionSubscription) Unsubscribe() {
 // receive server notifications containing the RuntimeVersion.
 func (s *State) SubscribeRuntimeVersion() (
 	*RuntimeVersionSubscription, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.Default().SubscribeTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Config().SubscribeTimeout)
 	defer cancel()
 
+	return s.SubscribeRuntimeVersionContext(ctx)
+}
+
+// SubscribeRuntimeVersionContext is SubscribeRuntimeVersion with a context
+func (s *State) SubscribeRuntimeVersionContext(ctx context.Context) (
+	*RuntimeVersionSubscription, error) {
 	c := make(chan types.RuntimeVersion)
 
 	sub, err := s.client.Subscribe(ctx, "state", "subscribeRuntimeVersion", "unsubscribeRuntimeVersion",
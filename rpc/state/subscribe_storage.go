@@ -20,7 +20,6 @@ import (
 	"context"
 	"sync"
 
-	"github.com/stafiprotocol/go-substrate-rpc-client/config"
 	gethrpc "github.com/stafiprotocol/go-substrate-rpc-client/pkg/gethrpc"
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
@@ -68,9 +67,15 @@ func (s *StorageSubscription) Unsubscribe() {
 // large buffer on the channel or ensure that the channel usually has at least one reader to prevent this issue.
 func (s *State) SubscribeStorageRaw(keys []types.StorageKey) (
 	*StorageSubscription, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.Default().SubscribeTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Config().SubscribeTimeout)
 	defer cancel()
 
+	return s.SubscribeStorageRawContext(ctx, keys)
+}
+
+// SubscribeStorageRawContext is SubscribeStorageRaw with a context
+func (s *State) SubscribeStorageRawContext(ctx context.Context, keys []types.StorageKey) (
+	*StorageSubscription, error) {
 	c := make(chan types.StorageChangeSet)
 
 	keyss := make([]string, len(keys))
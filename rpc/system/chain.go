@@ -17,12 +17,19 @@
 package system
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // Chain retrieves the chain
 func (c *System) Chain() (types.Text, error) {
+	return c.ChainContext(context.Background())
+}
+
+// ChainContext is Chain with a context
+func (c *System) ChainContext(ctx context.Context) (types.Text, error) {
 	var t types.Text
-	err := c.client.Call(&t, "system_chain")
+	err := c.client.CallContext(ctx, &t, "system_chain")
 	return t, err
 }
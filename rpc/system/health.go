@@ -17,12 +17,19 @@
 package system
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // Health retrieves the health status of the connected node
 func (c *System) Health() (types.Health, error) {
+	return c.HealthContext(context.Background())
+}
+
+// HealthContext is Health with a context
+func (c *System) HealthContext(ctx context.Context) (types.Health, error) {
 	var h types.Health
-	err := c.client.Call(&h, "system_health")
+	err := c.client.CallContext(ctx, &h, "system_health")
 	return h, err
 }
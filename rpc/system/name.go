@@ -17,12 +17,19 @@
 package system
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // Name retrieves the node name
 func (c *System) Name() (types.Text, error) {
+	return c.NameContext(context.Background())
+}
+
+// NameContext is Name with a context
+func (c *System) NameContext(ctx context.Context) (types.Text, error) {
 	var t types.Text
-	err := c.client.Call(&t, "system_name")
+	err := c.client.CallContext(ctx, &t, "system_name")
 	return t, err
 }
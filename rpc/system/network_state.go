@@ -17,12 +17,19 @@
 package system
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // NetworkState retrieves the current state of the network
 func (c *System) NetworkState() (types.NetworkState, error) {
+	return c.NetworkStateContext(context.Background())
+}
+
+// NetworkStateContext is NetworkState with a context
+func (c *System) NetworkStateContext(ctx context.Context) (types.NetworkState, error) {
 	var n types.NetworkState
-	err := c.client.Call(&n, "system_networkState")
+	err := c.client.CallContext(ctx, &n, "system_networkState")
 	return n, err
 }
@@ -17,12 +17,19 @@
 package system
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // Peers retrieves the currently connected peers
 func (c *System) Peers() ([]types.PeerInfo, error) {
+	return c.PeersContext(context.Background())
+}
+
+// PeersContext is Peers with a context
+func (c *System) PeersContext(ctx context.Context) ([]types.PeerInfo, error) {
 	var p []types.PeerInfo
-	err := c.client.Call(&p, "system_peers")
+	err := c.client.CallContext(ctx, &p, "system_peers")
 	return p, err
 }
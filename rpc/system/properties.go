@@ -17,12 +17,19 @@
 package system
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // Properties retrieves a custom set of properties as a JSON object, defined in the chain spec
 func (c *System) Properties() (types.ChainProperties, error) {
+	return c.PropertiesContext(context.Background())
+}
+
+// PropertiesContext is Properties with a context
+func (c *System) PropertiesContext(ctx context.Context) (types.ChainProperties, error) {
 	var p types.ChainProperties
-	err := c.client.Call(&p, "system_properties")
+	err := c.client.CallContext(ctx, &p, "system_properties")
 	return p, err
 }
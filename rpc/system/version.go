@@ -17,12 +17,19 @@
 package system
 
 import (
+	"context"
+
 	"github.com/stafiprotocol/go-substrate-rpc-client/types"
 )
 
 // Version retrieves the version of the node
 func (c *System) Version() (types.Text, error) {
+	return c.VersionContext(context.Background())
+}
+
+// VersionContext is Version with a context
+func (c *System) VersionContext(ctx context.Context) (types.Text, error) {
 	var t types.Text
-	err := c.client.Call(&t, "system_version")
+	err := c.client.CallContext(ctx, &t, "system_version")
 	return t, err
 }
@@ -0,0 +1,355 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/scale"
+)
+
+// Consensus engine ids, the little endian u32 of the 4 byte engine identifier
+const (
+	BabeEngineID    = ConsensusEngineID(0x45424142) // BABE
+	AuraEngineID    = ConsensusEngineID(0x61727561) // aura
+	GrandpaEngineID = ConsensusEngineID(0x4b4e5246) // FRNK
+)
+
+// BabePrimaryPreDigest is the pre-digest of a block authored in a primary slot
+type BabePrimaryPreDigest struct {
+	AuthorityIndex U32
+	Slot           U64
+	VrfOutput      [32]byte
+	VrfProof       [64]byte
+}
+
+// BabeSecondaryPlainPreDigest is the pre-digest of a block authored in a secondary slot without a vrf
+type BabeSecondaryPlainPreDigest struct {
+	AuthorityIndex U32
+	Slot           U64
+}
+
+// BabeSecondaryVRFPreDigest is the pre-digest of a block authored in a secondary slot with a vrf
+type BabeSecondaryVRFPreDigest struct {
+	AuthorityIndex U32
+	Slot           U64
+	VrfOutput      [32]byte
+	VrfProof       [64]byte
+}
+
+// BabePreDigest is the BABE pre-runtime digest of a block
+type BabePreDigest struct {
+	IsPrimary        bool
+	AsPrimary        BabePrimaryPreDigest // 1
+	IsSecondaryPlain bool
+	AsSecondaryPlain BabeSecondaryPlainPreDigest // 2
+	IsSecondaryVRF   bool
+	AsSecondaryVRF   BabeSecondaryVRFPreDigest // 3
+}
+
+func (m *BabePreDigest) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return err
+	}
+
+	switch b {
+	case 1:
+		m.IsPrimary = true
+		err = decoder.Decode(&m.AsPrimary)
+	case 2:
+		m.IsSecondaryPlain = true
+		err = decoder.Decode(&m.AsSecondaryPlain)
+	case 3:
+		m.IsSecondaryVRF = true
+		err = decoder.Decode(&m.AsSecondaryVRF)
+	default:
+		return fmt.Errorf("unknown babe pre digest type: %d", b)
+	}
+
+	return err
+}
+
+func (m BabePreDigest) Encode(encoder scale.Encoder) error {
+	var err1, err2 error
+	switch {
+	case m.IsPrimary:
+		err1 = encoder.PushByte(1)
+		err2 = encoder.Encode(m.AsPrimary)
+	case m.IsSecondaryPlain:
+		err1 = encoder.PushByte(2)
+		err2 = encoder.Encode(m.AsSecondaryPlain)
+	case m.IsSecondaryVRF:
+		err1 = encoder.PushByte(3)
+		err2 = encoder.Encode(m.AsSecondaryVRF)
+	}
+
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// AuthorityIndex returns the index of the block author in the BABE authority set
+func (m BabePreDigest) AuthorityIndex() uint32 {
+	switch {
+	case m.IsPrimary:
+		return uint32(m.AsPrimary.AuthorityIndex)
+	case m.IsSecondaryPlain:
+		return uint32(m.AsSecondaryPlain.AuthorityIndex)
+	default:
+		return uint32(m.AsSecondaryVRF.AuthorityIndex)
+	}
+}
+
+// Slot returns the slot the block was authored in
+func (m BabePreDigest) Slot() uint64 {
+	switch {
+	case m.IsPrimary:
+		return uint64(m.AsPrimary.Slot)
+	case m.IsSecondaryPlain:
+		return uint64(m.AsSecondaryPlain.Slot)
+	default:
+		return uint64(m.AsSecondaryVRF.Slot)
+	}
+}
+
+// GrandpaScheduledChange is an authority set change that is enacted after delay blocks once the announcing block is
+// finalized
+type GrandpaScheduledChange struct {
+	NextAuthorities GrandpaAuthorityList
+	Delay           U32
+}
+
+// GrandpaForcedChange is an authority set change that is enacted after delay blocks once the announcing block is
+// imported, regardless of finality
+type GrandpaForcedChange struct {
+	MedianLastFinalized U32
+	Change              GrandpaScheduledChange
+}
+
+// GrandpaConsensusLog is a GRANDPA consensus digest
+type GrandpaConsensusLog struct {
+	IsScheduledChange bool
+	AsScheduledChange GrandpaScheduledChange // 1
+	IsForcedChange    bool
+	AsForcedChange    GrandpaForcedChange // 2
+	IsOnDisabled      bool
+	AsOnDisabled      U64 // 3
+	IsPause           bool
+	AsPause           U32 // 4
+	IsResume          bool
+	AsResume          U32 // 5
+}
+
+func (m *GrandpaConsensusLog) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return err
+	}
+
+	switch b {
+	case 1:
+		m.IsScheduledChange = true
+		err = decoder.Decode(&m.AsScheduledChange)
+	case 2:
+		m.IsForcedChange = true
+		err = decoder.Decode(&m.AsForcedChange)
+	case 3:
+		m.IsOnDisabled = true
+		err = decoder.Decode(&m.AsOnDisabled)
+	case 4:
+		m.IsPause = true
+		err = decoder.Decode(&m.AsPause)
+	case 5:
+		m.IsResume = true
+		err = decoder.Decode(&m.AsResume)
+	default:
+		return fmt.Errorf("unknown grandpa consensus log type: %d", b)
+	}
+
+	return err
+}
+
+func (m GrandpaConsensusLog) Encode(encoder scale.Encoder) error {
+	var err1, err2 error
+	switch {
+	case m.IsScheduledChange:
+		err1 = encoder.PushByte(1)
+		err2 = encoder.Encode(m.AsScheduledChange)
+	case m.IsForcedChange:
+		err1 = encoder.PushByte(2)
+		err2 = encoder.Encode(m.AsForcedChange)
+	case m.IsOnDisabled:
+		err1 = encoder.PushByte(3)
+		err2 = encoder.Encode(m.AsOnDisabled)
+	case m.IsPause:
+		err1 = encoder.PushByte(4)
+		err2 = encoder.Encode(m.AsPause)
+	case m.IsResume:
+		err1 = encoder.PushByte(5)
+		err2 = encoder.Encode(m.AsResume)
+	}
+
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// BabeAuthority is a BABE authority with its weight
+type BabeAuthority struct {
+	ID     AuthorityID
+	Weight U64
+}
+
+// BabeNextEpochData is the authority set and randomness of the next BABE epoch
+type BabeNextEpochData struct {
+	Authorities []BabeAuthority
+	Randomness  [32]byte
+}
+
+// BabeNextConfigData is the BABE epoch configuration of the next epoch, the V1 variant of NextConfigDescriptor
+type BabeNextConfigData struct {
+	C            [2]U64
+	AllowedSlots U8
+}
+
+// BabeConsensusLog is a BABE consensus digest
+type BabeConsensusLog struct {
+	IsNextEpochData  bool
+	AsNextEpochData  BabeNextEpochData // 1
+	IsOnDisabled     bool
+	AsOnDisabled     U32 // 2
+	IsNextConfigData bool
+	AsNextConfigData BabeNextConfigData // 3
+}
+
+func (m *BabeConsensusLog) Decode(decoder scale.Decoder) error {
+	b, err := decoder.ReadOneByte()
+	if err != nil {
+		return err
+	}
+
+	switch b {
+	case 1:
+		m.IsNextEpochData = true
+		err = decoder.Decode(&m.AsNextEpochData)
+	case 2:
+		m.IsOnDisabled = true
+		err = decoder.Decode(&m.AsOnDisabled)
+	case 3:
+		m.IsNextConfigData = true
+		var version byte
+		version, err = decoder.ReadOneByte()
+		if err != nil {
+			return err
+		}
+		if version != 1 {
+			return fmt.Errorf("unknown babe next config version: %d", version)
+		}
+		err = decoder.Decode(&m.AsNextConfigData)
+	default:
+		return fmt.Errorf("unknown babe consensus log type: %d", b)
+	}
+
+	return err
+}
+
+func (m BabeConsensusLog) Encode(encoder scale.Encoder) error {
+	var err1, err2 error
+	switch {
+	case m.IsNextEpochData:
+		err1 = encoder.PushByte(1)
+		err2 = encoder.Encode(m.AsNextEpochData)
+	case m.IsOnDisabled:
+		err1 = encoder.PushByte(2)
+		err2 = encoder.Encode(m.AsOnDisabled)
+	case m.IsNextConfigData:
+		err1 = encoder.PushByte(3)
+		if err1 == nil {
+			err1 = encoder.PushByte(1)
+		}
+		err2 = encoder.Encode(m.AsNextConfigData)
+	}
+
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// BabePreDigest decodes the payload as a BABE pre-digest
+func (p PreRuntime) BabePreDigest() (BabePreDigest, error) {
+	var d BabePreDigest
+	if p.ConsensusEngineID != BabeEngineID {
+		return d, fmt.Errorf("pre runtime digest of engine %#x is not babe", uint32(p.ConsensusEngineID))
+	}
+	err := DecodeFromBytes(p.Bytes, &d)
+	return d, err
+}
+
+// AuraSlot decodes the payload as an Aura pre-digest, which only contains the slot
+func (p PreRuntime) AuraSlot() (U64, error) {
+	var slot U64
+	if p.ConsensusEngineID != AuraEngineID {
+		return slot, fmt.Errorf("pre runtime digest of engine %#x is not aura", uint32(p.ConsensusEngineID))
+	}
+	err := DecodeFromBytes(p.Bytes, &slot)
+	return slot, err
+}
+
+// GrandpaConsensusLog decodes the payload as a GRANDPA consensus log
+func (c Consensus) GrandpaConsensusLog() (GrandpaConsensusLog, error) {
+	var l GrandpaConsensusLog
+	if c.ConsensusEngineID != GrandpaEngineID {
+		return l, fmt.Errorf("consensus digest of engine %#x is not grandpa", uint32(c.ConsensusEngineID))
+	}
+	err := DecodeFromBytes(c.Bytes, &l)
+	return l, err
+}
+
+// BabeConsensusLog decodes the payload as a BABE consensus log
+func (c Consensus) BabeConsensusLog() (BabeConsensusLog, error) {
+	var l BabeConsensusLog
+	if c.ConsensusEngineID != BabeEngineID {
+		return l, fmt.Errorf("consensus digest of engine %#x is not babe", uint32(c.ConsensusEngineID))
+	}
+	err := DecodeFromBytes(c.Bytes, &l)
+	return l, err
+}
+
+// FindPreRuntime returns the first pre-runtime digest of the given engine
+func (d Digest) FindPreRuntime(engine ConsensusEngineID) (PreRuntime, bool) {
+	for _, item := range d {
+		if item.IsPreRuntime && item.AsPreRuntime.ConsensusEngineID == engine {
+			return item.AsPreRuntime, true
+		}
+	}
+	return PreRuntime{}, false
+}
+
+// FindConsensus returns all consensus digests of the given engine
+func (d Digest) FindConsensus(engine ConsensusEngineID) []Consensus {
+	logs := make([]Consensus, 0)
+	for _, item := range d {
+		if item.IsConsensus && item.AsConsensus.ConsensusEngineID == engine {
+			logs = append(logs, item.AsConsensus)
+		}
+	}
+	return logs
+}
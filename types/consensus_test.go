@@ -0,0 +1,121 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types_test
+
+import (
+	"testing"
+
+	. "github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var testBabePreDigest1 = BabePreDigest{IsPrimary: true, AsPrimary: BabePrimaryPreDigest{AuthorityIndex: 3, Slot: 100, VrfOutput: [32]byte{0x01}, VrfProof: [64]byte{0x02}}} //nolint:lll
+var testBabePreDigest2 = BabePreDigest{IsSecondaryPlain: true, AsSecondaryPlain: BabeSecondaryPlainPreDigest{AuthorityIndex: 4, Slot: 101}}                                 //nolint:lll
+var testBabePreDigest3 = BabePreDigest{IsSecondaryVRF: true, AsSecondaryVRF: BabeSecondaryVRFPreDigest{AuthorityIndex: 5, Slot: 102}}                                       //nolint:lll
+
+var testGrandpaConsensusLog1 = GrandpaConsensusLog{IsScheduledChange: true, AsScheduledChange: GrandpaScheduledChange{NextAuthorities: GrandpaAuthorityList{{ID: NewAuthorityID([32]byte{0xab}), Weight: 1}}, Delay: 10}} //nolint:lll
+var testGrandpaConsensusLog2 = GrandpaConsensusLog{IsForcedChange: true, AsForcedChange: GrandpaForcedChange{MedianLastFinalized: 9, Change: testGrandpaConsensusLog1.AsScheduledChange}}                                 //nolint:lll
+var testGrandpaConsensusLog3 = GrandpaConsensusLog{IsResume: true, AsResume: 7}
+
+var testBabeConsensusLog1 = BabeConsensusLog{IsNextEpochData: true, AsNextEpochData: BabeNextEpochData{Authorities: []BabeAuthority{{ID: NewAuthorityID([32]byte{0xcd}), Weight: 1}}, Randomness: [32]byte{0xef}}} //nolint:lll
+var testBabeConsensusLog2 = BabeConsensusLog{IsNextConfigData: true, AsNextConfigData: BabeNextConfigData{C: [2]U64{1, 4}, AllowedSlots: 1}}                                                                       //nolint:lll
+
+func TestBabePreDigest_EncodeDecode(t *testing.T) {
+	assertRoundtrip(t, testBabePreDigest1)
+	assertRoundtrip(t, testBabePreDigest2)
+	assertRoundtrip(t, testBabePreDigest3)
+}
+
+func TestBabePreDigest_Encode(t *testing.T) {
+	assertEncode(t, []encodingAssert{
+		{testBabePreDigest2, MustHexDecodeString("0x02040000006500000000000000")},
+	})
+}
+
+func TestBabePreDigest_AuthorityIndexAndSlot(t *testing.T) {
+	assert.Equal(t, uint32(3), testBabePreDigest1.AuthorityIndex())
+	assert.Equal(t, uint64(101), testBabePreDigest2.Slot())
+	assert.Equal(t, uint32(5), testBabePreDigest3.AuthorityIndex())
+}
+
+func TestGrandpaConsensusLog_EncodeDecode(t *testing.T) {
+	assertRoundtrip(t, testGrandpaConsensusLog1)
+	assertRoundtrip(t, testGrandpaConsensusLog2)
+	assertRoundtrip(t, testGrandpaConsensusLog3)
+}
+
+func TestBabeConsensusLog_EncodeDecode(t *testing.T) {
+	assertRoundtrip(t, testBabeConsensusLog1)
+	assertRoundtrip(t, testBabeConsensusLog2)
+}
+
+func TestBabeConsensusLog_Encode(t *testing.T) {
+	assertEncode(t, []encodingAssert{
+		{testBabeConsensusLog2, MustHexDecodeString("0x03010100000000000000040000000000000001")},
+	})
+}
+
+func TestDigest_FindPreRuntime(t *testing.T) {
+	bz, err := EncodeToBytes(testBabePreDigest2)
+	assert.NoError(t, err)
+	slot, err := EncodeToBytes(NewU64(42))
+	assert.NoError(t, err)
+
+	d := Digest{
+		{IsPreRuntime: true, AsPreRuntime: PreRuntime{ConsensusEngineID: AuraEngineID, Bytes: slot}},
+		{IsPreRuntime: true, AsPreRuntime: PreRuntime{ConsensusEngineID: BabeEngineID, Bytes: bz}},
+		{IsSeal: true, AsSeal: Seal{ConsensusEngineID: BabeEngineID}},
+	}
+
+	pre, ok := d.FindPreRuntime(BabeEngineID)
+	assert.True(t, ok)
+	babe, err := pre.BabePreDigest()
+	assert.NoError(t, err)
+	assert.Equal(t, testBabePreDigest2, babe)
+
+	pre, ok = d.FindPreRuntime(AuraEngineID)
+	assert.True(t, ok)
+	auraSlot, err := pre.AuraSlot()
+	assert.NoError(t, err)
+	assert.Equal(t, NewU64(42), auraSlot)
+
+	_, err = pre.BabePreDigest()
+	assert.Error(t, err)
+
+	_, ok = d.FindPreRuntime(GrandpaEngineID)
+	assert.False(t, ok)
+}
+
+func TestConsensus_GrandpaConsensusLog(t *testing.T) {
+	bz, err := EncodeToBytes(testGrandpaConsensusLog1)
+	assert.NoError(t, err)
+
+	d := Digest{{IsConsensus: true, AsConsensus: Consensus{ConsensusEngineID: GrandpaEngineID, Bytes: bz}}}
+	logs := d.FindConsensus(GrandpaEngineID)
+	assert.Len(t, logs, 1)
+	l, err := logs[0].GrandpaConsensusLog()
+	assert.NoError(t, err)
+	assert.Equal(t, testGrandpaConsensusLog1, l)
+}
+
+func TestConsensusEngineID_Encode(t *testing.T) {
+	assertEncode(t, []encodingAssert{
+		{BabeEngineID, []byte("BABE")},
+		{AuraEngineID, []byte("aura")},
+		{GrandpaEngineID, []byte("FRNK")},
+	})
+}
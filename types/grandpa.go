@@ -0,0 +1,195 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrGrandpaThresholdNotMet = errors.New("grandpa justification does not meet the voting threshold")
+	ErrGrandpaUnknownAncestry = errors.New("grandpa precommit target is not a descendant of the commit target")
+)
+
+// GrandpaPrecommit is a precommit for a block and its ancestors
+type GrandpaPrecommit struct {
+	TargetHash   Hash
+	TargetNumber U32
+}
+
+// GrandpaSignedPrecommit is a precommit signed by a GRANDPA authority
+type GrandpaSignedPrecommit struct {
+	Precommit GrandpaPrecommit
+	Signature Signature
+	ID        AuthorityID
+}
+
+// GrandpaCommit is a commit message, the aggregation of signed precommits for a target block
+type GrandpaCommit struct {
+	TargetHash   Hash
+	TargetNumber U32
+	Precommits   []GrandpaSignedPrecommit
+}
+
+// GrandpaJustification is a GRANDPA justification for block finality. It contains the commit message and the headers
+// of all blocks between the precommit targets and the commit target
+type GrandpaJustification struct {
+	Round           U64
+	Commit          GrandpaCommit
+	VotesAncestries []Header
+}
+
+// NewGrandpaJustification decodes a GRANDPA justification from the given justification bytes
+func NewGrandpaJustification(j Justification) (GrandpaJustification, error) {
+	var gj GrandpaJustification
+	err := DecodeFromBytes(j, &gj)
+	return gj, err
+}
+
+// GrandpaAuthority is a GRANDPA voter with its voting weight
+type GrandpaAuthority struct {
+	ID     AuthorityID
+	Weight U64
+}
+
+// GrandpaAuthorityList is the authority set that is active in a GRANDPA set id
+type GrandpaAuthorityList []GrandpaAuthority
+
+// VersionedGrandpaAuthorityList is the value stored under the well-known `:grandpa_authorities` storage key
+type VersionedGrandpaAuthorityList struct {
+	Version       U8
+	AuthorityList GrandpaAuthorityList
+}
+
+// TotalWeight returns the sum of the weights of all authorities
+func (l GrandpaAuthorityList) TotalWeight() uint64 {
+	var total uint64
+	for _, a := range l {
+		total += uint64(a.Weight)
+	}
+	return total
+}
+
+// Threshold returns the minimum weight that has to precommit for a block to be final, i.e. more than 2/3 of the total
+// weight
+func (l GrandpaAuthorityList) Threshold() uint64 {
+	total := l.TotalWeight()
+	if total == 0 {
+		return 0
+	}
+	return total - (total-1)/3
+}
+
+// grandpaPrecommitMessage is the payload an authority signs for a precommit, an encoded
+// (Message::Precommit(precommit), round, set_id) tuple
+type grandpaPrecommitMessage struct {
+	MessageType U8
+	Precommit   GrandpaPrecommit
+	Round       U64
+	SetID       U64
+}
+
+// SigningPayload returns the bytes the authority signed for this precommit in the given round and set id
+func (p GrandpaPrecommit) SigningPayload(round, setID uint64) ([]byte, error) {
+	return EncodeToBytes(grandpaPrecommitMessage{
+		MessageType: 1,
+		Precommit:   p,
+		Round:       NewU64(round),
+		SetID:       NewU64(setID),
+	})
+}
+
+// Verify checks the ed25519 signature of the precommit for the given round and set id
+func (sp GrandpaSignedPrecommit) Verify(round, setID uint64) (bool, error) {
+	msg, err := sp.Precommit.SigningPayload(round, setID)
+	if err != nil {
+		return false, err
+	}
+	return ed25519.Verify(sp.ID[:], msg, sp.Signature[:]), nil
+}
+
+// Verify checks that the justification finalizes the commit target under the given authority set and set id. Every
+// precommit must be signed by a member of the set and target a descendant of the commit target, and the weight of the
+// distinct signers has to reach the threshold of the set
+func (j GrandpaJustification) Verify(authorities GrandpaAuthorityList, setID uint64) error {
+	weights := make(map[AuthorityID]uint64, len(authorities))
+	for _, a := range authorities {
+		weights[a.ID] = uint64(a.Weight)
+	}
+
+	ancestry := make(map[Hash]Header, len(j.VotesAncestries))
+	for _, h := range j.VotesAncestries {
+		hash, err := GetHash(h)
+		if err != nil {
+			return err
+		}
+		ancestry[hash] = h
+	}
+
+	var signed uint64
+	seen := make(map[AuthorityID]bool, len(j.Commit.Precommits))
+	for i, sp := range j.Commit.Precommits {
+		weight, ok := weights[sp.ID]
+		if !ok {
+			return fmt.Errorf("precommit %d signed by unknown authority %#x", i, sp.ID[:])
+		}
+
+		ok, err := sp.Verify(uint64(j.Round), setID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("precommit %d has an invalid signature from authority %#x", i, sp.ID[:])
+		}
+
+		if !j.isDescendant(ancestry, sp.Precommit) {
+			return ErrGrandpaUnknownAncestry
+		}
+
+		// equivocating voters are only counted once
+		if seen[sp.ID] {
+			continue
+		}
+		seen[sp.ID] = true
+		signed += weight
+	}
+
+	if signed < authorities.Threshold() {
+		return ErrGrandpaThresholdNotMet
+	}
+	return nil
+}
+
+// isDescendant walks the votes ancestries from the precommit target back to the commit target
+func (j GrandpaJustification) isDescendant(ancestry map[Hash]Header, p GrandpaPrecommit) bool {
+	hash, number := p.TargetHash, uint32(p.TargetNumber)
+	for {
+		if hash == j.Commit.TargetHash {
+			return true
+		}
+		if number <= uint32(j.Commit.TargetNumber) {
+			return false
+		}
+		h, ok := ancestry[hash]
+		if !ok {
+			return false
+		}
+		hash, number = h.ParentHash, uint32(h.Number)-1
+	}
+}
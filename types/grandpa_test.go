@@ -0,0 +1,116 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types_test
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	. "github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var testGrandpaPrecommit = GrandpaPrecommit{TargetHash: NewHash([]byte{0xab}), TargetNumber: 7}
+
+func TestGrandpaPrecommit_SigningPayload(t *testing.T) {
+	payload, err := testGrandpaPrecommit.SigningPayload(2, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, MustHexDecodeString("0x01ab000000000000000000000000000000000000000000000000000000000000000700000002000000000000000300000000000000"), payload) //nolint:lll
+}
+
+func TestGrandpaJustification_EncodeDecode(t *testing.T) {
+	assertRoundtrip(t, GrandpaJustification{
+		Round: 1,
+		Commit: GrandpaCommit{
+			TargetHash:   testGrandpaPrecommit.TargetHash,
+			TargetNumber: testGrandpaPrecommit.TargetNumber,
+			Precommits: []GrandpaSignedPrecommit{
+				{Precommit: testGrandpaPrecommit, Signature: NewSignature([]byte{0x01}), ID: NewAuthorityID([32]byte{0x02})},
+			},
+		},
+	})
+}
+
+func TestGrandpaAuthorityList_Threshold(t *testing.T) {
+	list := GrandpaAuthorityList{{Weight: 1}, {Weight: 1}, {Weight: 1}, {Weight: 1}}
+	assert.Equal(t, uint64(3), list.Threshold())
+	assert.Equal(t, uint64(0), GrandpaAuthorityList{}.Threshold())
+}
+
+func newTestGrandpaVoters(n int) ([]ed25519.PrivateKey, GrandpaAuthorityList) {
+	keys := make([]ed25519.PrivateKey, n)
+	list := make(GrandpaAuthorityList, n)
+	for i := range keys {
+		seed := make([]byte, ed25519.SeedSize)
+		seed[0] = byte(i + 1)
+		keys[i] = ed25519.NewKeyFromSeed(seed)
+		var id [32]byte
+		copy(id[:], keys[i].Public().(ed25519.PublicKey))
+		list[i] = GrandpaAuthority{ID: NewAuthorityID(id), Weight: 1}
+	}
+	return keys, list
+}
+
+func signTestPrecommit(t *testing.T, key ed25519.PrivateKey, id AuthorityID, p GrandpaPrecommit, round, setID uint64) GrandpaSignedPrecommit { //nolint:lll
+	payload, err := p.SigningPayload(round, setID)
+	assert.NoError(t, err)
+	return GrandpaSignedPrecommit{Precommit: p, Signature: NewSignature(ed25519.Sign(key, payload)), ID: id}
+}
+
+func TestGrandpaJustification_Verify(t *testing.T) {
+	keys, authorities := newTestGrandpaVoters(4)
+
+	child := Header{ParentHash: testGrandpaPrecommit.TargetHash, Number: 8}
+	childHash, err := GetHash(child)
+	assert.NoError(t, err)
+	childPrecommit := GrandpaPrecommit{TargetHash: childHash, TargetNumber: 8}
+
+	j := GrandpaJustification{
+		Round: 5,
+		Commit: GrandpaCommit{
+			TargetHash:   testGrandpaPrecommit.TargetHash,
+			TargetNumber: testGrandpaPrecommit.TargetNumber,
+			Precommits: []GrandpaSignedPrecommit{
+				signTestPrecommit(t, keys[0], authorities[0].ID, testGrandpaPrecommit, 5, 1),
+				signTestPrecommit(t, keys[1], authorities[1].ID, testGrandpaPrecommit, 5, 1),
+				signTestPrecommit(t, keys[2], authorities[2].ID, childPrecommit, 5, 1),
+			},
+		},
+		VotesAncestries: []Header{child},
+	}
+
+	enc, err := EncodeToBytes(j)
+	assert.NoError(t, err)
+	dec, err := NewGrandpaJustification(enc)
+	assert.NoError(t, err)
+	assert.NoError(t, dec.Verify(authorities, 1))
+
+	// wrong set id invalidates all signatures
+	assert.Error(t, dec.Verify(authorities, 2))
+
+	// a missing ancestry header is rejected
+	noAncestry := dec
+	noAncestry.VotesAncestries = nil
+	assert.Equal(t, ErrGrandpaUnknownAncestry, noAncestry.Verify(authorities, 1))
+
+	// two distinct signers of four are not enough, duplicates are counted once
+	short := dec
+	short.Commit.Precommits = []GrandpaSignedPrecommit{
+		dec.Commit.Precommits[0], dec.Commit.Precommits[1], dec.Commit.Precommits[1],
+	}
+	assert.Equal(t, ErrGrandpaThresholdNotMet, short.Verify(authorities, 1))
+}
@@ -0,0 +1,225 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/stafiprotocol/go-substrate-rpc-client/pkg/scale"
+)
+
+// ErrNoTypeRegistry is returned for metadata before V14, which names the types it uses without defining them
+var ErrNoTypeRegistry = errors.New("metadata has no type registry")
+
+// FindMetadataEnum returns the variant names by index of the enum whose type path ends with name, read from the
+// type registry at the start of V14 and later metadata. raw is the metadata as returned by state_getMetadata.
+func FindMetadataEnum(raw []byte, name string) (map[uint8]string, error) {
+	if len(raw) < 5 || string(raw[:4]) != "meta" {
+		return nil, errors.New("not metadata")
+	}
+	if raw[4] < 14 {
+		return nil, ErrNoTypeRegistry
+	}
+
+	d := registryDecoder{scale.NewDecoder(bytes.NewReader(raw[5:]))}
+	n, err := d.compact()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < n; i++ {
+		if _, err := d.compact(); err != nil { // type id
+			return nil, err
+		}
+		path, err := d.texts()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.typeParams(); err != nil {
+			return nil, err
+		}
+		variants, err := d.typeDef()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := d.texts(); err != nil { // docs
+			return nil, err
+		}
+		if variants != nil && len(path) > 0 && path[len(path)-1] == name {
+			return variants, nil
+		}
+	}
+	return nil, fmt.Errorf("enum %s not found in metadata", name)
+}
+
+// registryDecoder reads the scale-info PortableRegistry
+type registryDecoder struct {
+	*scale.Decoder
+}
+
+func (d registryDecoder) compact() (uint64, error) {
+	v, err := d.DecodeUintCompact()
+	if err != nil {
+		return 0, err
+	}
+	return v.Uint64(), nil
+}
+
+func (d registryDecoder) text() (string, error) {
+	var t Text
+	err := d.Decode(&t)
+	return string(t), err
+}
+
+func (d registryDecoder) texts() ([]string, error) {
+	n, err := d.compact()
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, n)
+	for i := range texts {
+		if texts[i], err = d.text(); err != nil {
+			return nil, err
+		}
+	}
+	return texts, nil
+}
+
+func (d registryDecoder) optionalCompact() error {
+	some, err := d.ReadOneByte()
+	if err != nil || some == 0 {
+		return err
+	}
+	_, err = d.compact()
+	return err
+}
+
+func (d registryDecoder) optionalText() error {
+	some, err := d.ReadOneByte()
+	if err != nil || some == 0 {
+		return err
+	}
+	_, err = d.text()
+	return err
+}
+
+func (d registryDecoder) typeParams() error {
+	n, err := d.compact()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < n; i++ {
+		if _, err := d.text(); err != nil {
+			return err
+		}
+		if err := d.optionalCompact(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d registryDecoder) fields() error {
+	n, err := d.compact()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < n; i++ {
+		if err := d.optionalText(); err != nil { // name
+			return err
+		}
+		if _, err := d.compact(); err != nil { // type
+			return err
+		}
+		if err := d.optionalText(); err != nil { // type name
+			return err
+		}
+		if _, err := d.texts(); err != nil { // docs
+			return err
+		}
+	}
+	return nil
+}
+
+// typeDef reads a type definition, it returns the variants of an enum and nil for the other kinds
+func (d registryDecoder) typeDef() (map[uint8]string, error) {
+	kind, err := d.ReadOneByte()
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case 0: // composite
+		return nil, d.fields()
+	case 1: // variant
+		n, err := d.compact()
+		if err != nil {
+			return nil, err
+		}
+		variants := make(map[uint8]string, n)
+		for i := uint64(0); i < n; i++ {
+			name, err := d.text()
+			if err != nil {
+				return nil, err
+			}
+			if err := d.fields(); err != nil {
+				return nil, err
+			}
+			index, err := d.ReadOneByte()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := d.texts(); err != nil {
+				return nil, err
+			}
+			variants[index] = name
+		}
+		return variants, nil
+	case 2, 6: // sequence, compact
+		_, err := d.compact()
+		return nil, err
+	case 3: // array
+		var length U32
+		if err := d.Decode(&length); err != nil {
+			return nil, err
+		}
+		_, err := d.compact()
+		return nil, err
+	case 4: // tuple
+		n, err := d.compact()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < n; i++ {
+			if _, err := d.compact(); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case 5: // primitive
+		_, err := d.ReadOneByte()
+		return nil, err
+	case 7: // bit sequence
+		if _, err := d.compact(); err != nil {
+			return nil, err
+		}
+		_, err := d.compact()
+		return nil, err
+	default:
+		return nil, fmt.Errorf("unknown type definition %d", kind)
+	}
+}
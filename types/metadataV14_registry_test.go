@@ -0,0 +1,71 @@
+// Go Substrate RPC Client (GSRPC) provides APIs and types around Polkadot and any Substrate-based chain RPC calls
+//
+// Copyright 2020 Stafi Protocol
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types_test
+
+import (
+	"testing"
+
+	. "github.com/stafiprotocol/go-substrate-rpc-client/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// registryV14 is the start of V14 metadata with a registry of a u8, a struct and the RSymbol enum
+func registryV14(t *testing.T) []byte {
+	text := func(s string) []byte {
+		bz, err := EncodeToBytes(Text(s))
+		assert.NoError(t, err)
+		return bz
+	}
+	variant := func(name string, index byte) []byte {
+		return append(append(text(name), 0x00), index, 0x00) // no fields, index, no docs
+	}
+
+	raw := []byte("meta")
+	raw = append(raw, 14, 3<<2)
+	// 0: u8
+	raw = append(raw, 0x00, 0x00, 0x00, 0x05, 0x02, 0x00)
+	// 1: struct with one named field of type 0
+	raw = append(raw, 1<<2, 1<<2)
+	raw = append(raw, text("Foo")...)
+	raw = append(raw, 0x00, 0x00, 1<<2, 0x01)
+	raw = append(raw, text("a")...)
+	raw = append(raw, 0x00, 0x00, 0x00, 0x00)
+	// 2: enum RSymbol
+	raw = append(raw, 2<<2, 2<<2)
+	raw = append(raw, text("node_primitives")...)
+	raw = append(raw, text("RSymbol")...)
+	raw = append(raw, 0x00, 0x01, 3<<2)
+	raw = append(raw, variant("RFIS", 0)...)
+	raw = append(raw, variant("RDOT", 1)...)
+	raw = append(raw, variant("RNEW", 9)...)
+	raw = append(raw, 0x00)
+	return raw
+}
+
+func TestFindMetadataEnum(t *testing.T) {
+	raw := registryV14(t)
+
+	variants, err := FindMetadataEnum(raw, "RSymbol")
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint8]string{0: "RFIS", 1: "RDOT", 9: "RNEW"}, variants)
+
+	_, err = FindMetadataEnum(raw, "ProxyType")
+	assert.EqualError(t, err, "enum ProxyType not found in metadata")
+
+	_, err = FindMetadataEnum(MustHexDecodeString(ExamplaryMetadataV13SubstrateString), "RSymbol")
+	assert.Equal(t, ErrNoTypeRegistry, err)
+}
@@ -16,9 +16,70 @@
 
 package types
 
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
 type SignedBlock struct {
 	Block         Block         `json:"block"`
 	Justification Justification `json:"justification"`
+	// Justifications replace Justification on nodes since substrate 3, one per consensus engine
+	Justifications []EngineJustification `json:"justifications"`
+}
+
+// GrandpaJustification returns the grandpa justification of the block, the FRNK entry of Justifications or else
+// Justification
+func (b SignedBlock) GrandpaJustification() (Justification, bool) {
+	for _, j := range b.Justifications {
+		if j.ConsensusEngineID == GrandpaEngineID {
+			return j.Justification, true
+		}
+	}
+	return b.Justification, len(b.Justification) > 0
+}
+
+// EngineJustification is the justification of a consensus engine
+type EngineJustification struct {
+	ConsensusEngineID ConsensusEngineID
+	Justification     Justification
+}
+
+// UnmarshalJSON fills j from a JSON pair of the engine id and the encoded justification, each a byte array or a hex
+// string
+func (j *EngineJustification) UnmarshalJSON(bz []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(bz, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("justification has %d elements, expected engine id and justification", len(pair))
+	}
+	id, err := jsonBytes(pair[0])
+	if err != nil {
+		return err
+	}
+	if len(id) != 4 {
+		return fmt.Errorf("consensus engine id has %d bytes, expected 4", len(id))
+	}
+	justification, err := jsonBytes(pair[1])
+	if err != nil {
+		return err
+	}
+	j.ConsensusEngineID = ConsensusEngineID(binary.LittleEndian.Uint32(id))
+	j.Justification = justification
+	return nil
+}
+
+func jsonBytes(raw json.RawMessage) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return HexDecodeString(s)
+	}
+	var bz []byte
+	err := json.Unmarshal(raw, &bz)
+	return bz, err
 }
 
 // Block encoded with header and extrinsics
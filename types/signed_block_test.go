@@ -17,6 +17,7 @@
 package types_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	. "github.com/stafiprotocol/go-substrate-rpc-client/types"
@@ -33,3 +34,29 @@ func TestBlock_EncodeDecode(t *testing.T) {
 
 	assert.Equal(t, ExamplarySignedBlock, dec)
 }
+
+func TestSignedBlock_GrandpaJustification(t *testing.T) {
+	var blk SignedBlock
+	assert.NoError(t, json.Unmarshal([]byte(`{"block":{"header":null,"extrinsics":[]},"justifications":[`+
+		`[[66,69,69,70],[9]],["0x46524e4b","0x0102"]]}`), &blk))
+	assert.Equal(t, []EngineJustification{
+		{ConsensusEngineID: ConsensusEngineID(0x46454542), Justification: Justification{9}},
+		{ConsensusEngineID: GrandpaEngineID, Justification: Justification{1, 2}},
+	}, blk.Justifications)
+	j, ok := blk.GrandpaJustification()
+	assert.True(t, ok)
+	assert.Equal(t, Justification{1, 2}, j)
+
+	blk = SignedBlock{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"block":{"header":null,"extrinsics":[]},"justification":[3,4]}`), &blk))
+	j, ok = blk.GrandpaJustification()
+	assert.True(t, ok)
+	assert.Equal(t, Justification{3, 4}, j)
+
+	blk = SignedBlock{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"block":{"header":null,"extrinsics":[]},"justifications":null}`), &blk))
+	_, ok = blk.GrandpaJustification()
+	assert.False(t, ok)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"justifications":[[[1,2],[3]]]}`), &blk))
+}